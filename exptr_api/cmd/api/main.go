@@ -1,13 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 
 	"alex_gorbunov_exptr_api/internal/config"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
 	"alex_gorbunov_exptr_api/internal/lib/crons"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
 	"alex_gorbunov_exptr_api/internal/server/router"
 	"alex_gorbunov_exptr_api/internal/storage/postgres"
 
@@ -46,11 +49,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	blobs, err := blobStore(cfg.Attachments)
+	if err != nil {
+		log.Error("failed to init attachment store", sl.Error(err))
+		os.Exit(1)
+	}
+
 	log.Info("strating server", slog.String("address", cfg.Address))
 
 	srv := &http.Server{
 		Addr:         cfg.Address,
-		Handler:      router.Router(log, storage),
+		Handler:      router.Router(log, storage, blobs, cfg),
 		ReadTimeout:  cfg.HTTPServer.Timeout,
 		WriteTimeout: cfg.HTTPServer.Timeout,
 		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
@@ -62,6 +71,22 @@ func main() {
 		c.AddFunc("@every 1h", func() {
 			crons.DeleteOutdatedSessions(storage, log)
 		})
+		c.AddFunc(cfg.Accounts.PurgeSchedule, func() {
+			crons.PurgeDeletedAccounts(storage, blobs, log)
+		})
+		c.AddFunc(cfg.Attachments.SweepSchedule, func() {
+			crons.SweepAttachments(storage, blobs, cfg.Attachments.SweepGrace, log)
+		})
+		if cfg.Retention.Period > 0 {
+			c.AddFunc(cfg.Retention.Schedule, func() {
+				crons.PurgeSoftDeleted(storage, cfg.Retention.Period, cfg.Retention.BatchSize, log)
+			})
+		}
+		if provider := ratesProvider(cfg.Rates); provider != nil {
+			c.AddFunc(cfg.Rates.Schedule, func() {
+				crons.RefreshExchangeRates(storage, provider, log)
+			})
+		}
 		c.Start()
 	}()
 
@@ -70,3 +95,32 @@ func main() {
 		log.Error("server stopped", sl.Error(err))
 	}
 }
+
+func ratesProvider(cfg config.Rates) rates.Provider {
+	switch cfg.Provider {
+	case "ecb":
+		return rates.ECBFeed{URL: cfg.URL}
+	case "csv":
+		return rates.CSVFile{Path: cfg.CSVPath}
+	default:
+		return nil
+	}
+}
+
+func blobStore(cfg config.Attachments) (blob.Store, error) {
+	switch cfg.Store {
+	case "fs", "":
+		return blob.FS{Root: cfg.Dir}, nil
+	case "s3":
+		return &blob.S3{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown attachment store %q", cfg.Store)
+	}
+}
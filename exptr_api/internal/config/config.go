@@ -9,9 +9,14 @@ import (
 )
 
 type Config struct {
-	Env        string `yaml:"env" env-default:"local"`
-	HTTPServer `yaml:"http_server"`
-	Database   `yaml:"database"`
+	Env         string `yaml:"env" env-default:"local"`
+	HTTPServer  `yaml:"http_server"`
+	Database    `yaml:"database"`
+	Rates       `yaml:"rates"`
+	Accounts    `yaml:"accounts"`
+	Retention   `yaml:"retention"`
+	Categorizer `yaml:"categorizer"`
+	Attachments `yaml:"attachments"`
 }
 
 type HTTPServer struct {
@@ -29,6 +34,75 @@ type Database struct {
 	Password string `yaml:"password" env-required:"true"`
 }
 
+// Rates configures the scheduled exchange rate refresh. Provider is "ecb",
+// "csv" or empty to disable the refresh. Rates are shared by all users, so
+// only the user ids listed in Uploaders may upload them.
+type Rates struct {
+	Provider  string   `yaml:"provider"`
+	URL       string   `yaml:"url"`
+	CSVPath   string   `yaml:"csv_path"`
+	Schedule  string   `yaml:"schedule" env-default:"@daily"`
+	Uploaders []string `yaml:"uploaders"`
+}
+
+// Accounts configures account deletion. A requested deletion can be
+// cancelled during DeletionGracePeriod; the purge job runs on PurgeSchedule.
+type Accounts struct {
+	DeletionGracePeriod time.Duration `yaml:"deletion_grace_period" env-default:"720h"`
+	PurgeSchedule       string        `yaml:"purge_schedule" env-default:"@hourly"`
+}
+
+// Retention configures the purge of soft-deleted rows. Rows deleted more
+// than Period ago are removed for good, BatchSize at a time. A zero Period
+// disables the purge.
+type Retention struct {
+	Period    time.Duration `yaml:"period" env-default:"720h"`
+	BatchSize int           `yaml:"batch_size" env-default:"1000"`
+	Schedule  string        `yaml:"schedule" env-default:"@daily"`
+}
+
+// Categorizer configures category suggestions. Provider is "ollama" or
+// empty to go without a language model. With Learn a classifier is trained
+// on every user's own operations and retrained after LearnMaxAge. A
+// suggestion is applied to an operation without a category when its
+// confidence reaches MinConfidence; after a failed model call the model is
+// skipped for Cooldown.
+type Categorizer struct {
+	Provider      string        `yaml:"provider"`
+	URL           string        `yaml:"url" env-default:"http://localhost:11434"`
+	Model         string        `yaml:"model" env-default:"llama2"`
+	Timeout       time.Duration `yaml:"timeout" env-default:"3s"`
+	CacheTTL      time.Duration `yaml:"cache_ttl" env-default:"24h"`
+	Cooldown      time.Duration `yaml:"cooldown" env-default:"1m"`
+	MinConfidence float64       `yaml:"min_confidence" env-default:"0.6"`
+	Learn         bool          `yaml:"learn" env-default:"true"`
+	LearnMaxAge   time.Duration `yaml:"learn_max_age" env-default:"6h"`
+}
+
+// Attachments configures files attached to operations. Store is "fs" to
+// keep them under Dir or "s3" for an S3-compatible bucket. Uploads above
+// MaxSize bytes are refused. The sweep on SweepSchedule deletes files no
+// attachment refers to once they are older than SweepGrace.
+type Attachments struct {
+	Store         string        `yaml:"store" env-default:"fs"`
+	Dir           string        `yaml:"dir" env-default:"data/attachments"`
+	MaxSize       int64         `yaml:"max_size" env-default:"10485760"`
+	SweepSchedule string        `yaml:"sweep_schedule" env-default:"@daily"`
+	SweepGrace    time.Duration `yaml:"sweep_grace" env-default:"1h"`
+	S3            S3            `yaml:"s3"`
+}
+
+// S3 locates a bucket on Amazon S3 or a compatible server such as MinIO.
+// Self-hosted servers usually need PathStyle.
+type S3 struct {
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region" env-default:"us-east-1"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"`
+}
+
 func MustLoad() *Config {
 	configPath := os.Getenv("CONFIG_PATH")
 	if configPath == "" {
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountTombstone is the audit record left behind when an account is
+// purged. It keeps no personal data: the email is stored as a SHA-256 hash
+// so a later request about the address can still be answered.
+type AccountTombstone struct {
+	BaseEntity
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	EmailHash      string     `json:"email_hash" gorm:"type:varchar(64);not null;index"`
+	RequestedAt    *time.Time `json:"requested_at"`
+	Categories     int        `json:"categories" gorm:"not null;default:0"`
+	Operations     int        `json:"operations" gorm:"not null;default:0"`
+	ImportProfiles int        `json:"import_profiles" gorm:"not null;default:0"`
+	Sessions       int        `json:"sessions" gorm:"not null;default:0"`
+}
+
+func (AccountTombstone) TableName() string {
+	return "account_tombstones"
+}
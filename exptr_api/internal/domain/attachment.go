@@ -0,0 +1,21 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Attachment is a receipt or other document attached to an operation. The
+// file itself lives in the blob store, keyed by the user and Checksum, so
+// the same file attached twice is stored once.
+type Attachment struct {
+	BaseEntity
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	OperationID uuid.UUID `json:"operation_id" gorm:"type:uuid;not null;index"`
+	FileName    string    `json:"file_name" gorm:"type:varchar(255);not null"`
+	ContentType string    `json:"content_type" gorm:"type:varchar(255);not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	Checksum    string    `json:"checksum" gorm:"type:varchar(64);not null;index"`
+	Thumbnail   bool      `json:"thumbnail" gorm:"not null;default:false"`
+}
+
+func (Attachment) TableName() string {
+	return "attachments"
+}
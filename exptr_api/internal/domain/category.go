@@ -4,13 +4,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// Category groups operations. ParentID nests it under another category of
+// the same user, so "Food > Restaurants > Coffee" is three categories.
 type Category struct {
 	BaseEntity
-	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
-	Name   string    `json:"name" gorm:"type:varchar(255);not null"`
-	Type   string    `json:"type" gorm:"type:varchar(255);not null"`
-	Color  string    `json:"color" gorm:"type:varchar(255)"`
-	Icon   string    `json:"icon" gorm:"type:varchar(255)"`
+	UserID   uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	ParentID *uuid.UUID `json:"parent_id" gorm:"type:uuid;index"`
+	Name     string     `json:"name" gorm:"type:varchar(255);not null"`
+	Type     string     `json:"type" gorm:"type:varchar(255);not null"`
+	Color    string     `json:"color" gorm:"type:varchar(255)"`
+	Icon     string     `json:"icon" gorm:"type:varchar(255)"`
 }
 
 func (Category) TableName() string {
@@ -0,0 +1,17 @@
+package domain
+
+import "github.com/google/uuid"
+
+// DuplicateDismissal remembers that the user looked at two operations
+// suggested as duplicates and decided to keep both. OperationID and OtherID
+// are stored in a fixed order so a pair has a single row.
+type DuplicateDismissal struct {
+	BaseEntity
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_duplicate_dismissals_pair"`
+	OperationID uuid.UUID `json:"operation_id" gorm:"type:uuid;not null;uniqueIndex:idx_duplicate_dismissals_pair"`
+	OtherID     uuid.UUID `json:"other_id" gorm:"type:uuid;not null;uniqueIndex:idx_duplicate_dismissals_pair"`
+}
+
+func (DuplicateDismissal) TableName() string {
+	return "duplicate_dismissals"
+}
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+)
+
+// ExchangeRate stores how many units of Quote one unit of Base was worth on Date.
+type ExchangeRate struct {
+	BaseEntity
+	Date   time.Time `json:"date" gorm:"type:date;not null;uniqueIndex:idx_exchange_rates_date_pair"`
+	Base   string    `json:"base" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_date_pair"`
+	Quote  string    `json:"quote" gorm:"type:varchar(3);not null;uniqueIndex:idx_exchange_rates_date_pair"`
+	Rate   float64   `json:"rate" gorm:"type:decimal(19,8);not null"`
+	Source string    `json:"source" gorm:"type:varchar(255)"`
+}
+
+func (ExchangeRate) TableName() string {
+	return "exchange_rates"
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// CSV sign conventions describe how a statement marks income and expense.
+const (
+	// SignNegativeExpense treats negative amounts as expenses.
+	SignNegativeExpense = "negative_expense"
+	// SignPositiveExpense treats positive amounts as expenses, as credit card statements do.
+	SignPositiveExpense = "positive_expense"
+	// SignTypeColumn reads the direction from the type column.
+	SignTypeColumn = "type_column"
+	// SignDebitCredit reads expenses from the debit column and income from the credit column.
+	SignDebitCredit = "debit_credit"
+)
+
+// CSVColumns maps operation fields onto statement columns. Columns are
+// referenced by header name, or by 1-based number when the file has no header.
+type CSVColumns struct {
+	Date      string `json:"date"`
+	Amount    string `json:"amount,omitempty"`
+	Debit     string `json:"debit,omitempty"`
+	Credit    string `json:"credit,omitempty"`
+	Currency  string `json:"currency,omitempty"`
+	Name      string `json:"name"`
+	Comment   string `json:"comment,omitempty"`
+	Type      string `json:"type,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+// CSVMapping describes how to read a bank's CSV statement.
+type CSVMapping struct {
+	Delimiter          string     `json:"delimiter,omitempty"`
+	Encoding           string     `json:"encoding,omitempty"`
+	NoHeader           bool       `json:"no_header,omitempty"`
+	SkipRows           int        `json:"skip_rows,omitempty"`
+	DateFormat         string     `json:"date_format,omitempty"`
+	DecimalSeparator   string     `json:"decimal_separator,omitempty"`
+	ThousandsSeparator string     `json:"thousands_separator,omitempty"`
+	SignConvention     string     `json:"sign_convention,omitempty"`
+	IncomeValues       []string   `json:"income_values,omitempty"`
+	DefaultCurrency    string     `json:"default_currency,omitempty"`
+	DefaultCategoryID  *uuid.UUID `json:"default_category_id,omitempty"`
+	Columns            CSVColumns `json:"columns"`
+}
+
+func (m CSVMapping) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+func (m *CSVMapping) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	case nil:
+		return nil
+	default:
+		return errors.New("unsupported csv mapping type")
+	}
+}
+
+// ImportProfile is a saved statement mapping for one bank.
+type ImportProfile struct {
+	BaseEntity
+	UserID  uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name    string     `json:"name" gorm:"type:varchar(255);not null"`
+	Bank    string     `json:"bank" gorm:"type:varchar(255)"`
+	Mapping CSVMapping `json:"mapping" gorm:"type:jsonb;not null"`
+}
+
+func (ImportProfile) TableName() string {
+	return "import_profiles"
+}
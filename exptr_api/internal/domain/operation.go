@@ -4,15 +4,54 @@ import (
 	"github.com/google/uuid"
 )
 
+// Operation is a single income or expense. Amount is kept in minor units of
+// Currency (cents for USD, yen for JPY) and CreatedAt is the operation date.
+//
+// Foreign-currency operations may carry the amount actually charged by the
+// bank in SettledAmount/SettledCurrency and the resulting EffectiveRate
+// (settled units per unit of Currency).
+//
+// ExternalID identifies an imported operation in its source (an OFX FITID,
+// a bank reference or a fingerprint of payee, date and amount) so repeated
+// imports of the same statement are idempotent.
+//
+// Account optionally names the bank account or wallet the money moved
+// through, e.g. "Checking" or "Cash". Tags are loaded only where listed
+// operations need them.
+//
+// Splits spread the operation over several categories. CategoryID then holds
+// the category of the first split so the operation still lists under one.
+//
+// PayeeID links the merchant found from Name, if any. Items are the lines of
+// its receipt.
 type Operation struct {
 	BaseEntity
-	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
-	CategoryID uuid.UUID `json:"category_id" gorm:"type:uuid;not null;index"`
-	Amount     int       `json:"amount" gorm:"type:decimal(19,4);not null"`
-	Currency   string    `json:"currency" gorm:"type:varchar(10);not null"`
-	Name       string    `json:"name" gorm:"type:varchar(255);not null"`
-	Comment    string    `json:"comment" gorm:"type:text"`
-	Type       string    `json:"type" gorm:"type:varchar(255)"`
+	UserID          uuid.UUID        `json:"user_id" gorm:"type:uuid;index"`
+	CategoryID      uuid.UUID        `json:"category_id" gorm:"type:uuid;not null;index"`
+	Amount          int              `json:"amount" gorm:"type:decimal(19,4);not null"`
+	Currency        string           `json:"currency" gorm:"type:varchar(10);not null"`
+	SettledAmount   *int             `json:"settled_amount,omitempty" gorm:"type:decimal(19,4)"`
+	SettledCurrency string           `json:"settled_currency,omitempty" gorm:"type:varchar(3)"`
+	EffectiveRate   *float64         `json:"effective_rate,omitempty" gorm:"type:decimal(19,8)"`
+	Name            string           `json:"name" gorm:"type:varchar(255);not null"`
+	Comment         string           `json:"comment" gorm:"type:text"`
+	Type            string           `json:"type" gorm:"type:varchar(255)"`
+	Account         string           `json:"account,omitempty" gorm:"type:varchar(255)"`
+	ExternalID      string           `json:"external_id,omitempty" gorm:"type:varchar(255);index"`
+	PayeeID         *uuid.UUID       `json:"payee_id,omitempty" gorm:"type:uuid;index"`
+	Tags            []Tag            `json:"tags,omitempty" gorm:"many2many:operation_tags"`
+	Splits          []OperationSplit `json:"splits,omitempty" gorm:"foreignKey:OperationID"`
+	Items           []OperationItem  `json:"items,omitempty" gorm:"foreignKey:OperationID"`
+}
+
+// Split reports whether the operation is spread over several categories.
+func (o *Operation) Split() bool {
+	return len(o.Splits) > 0
+}
+
+// Settled reports whether the operation has a settled amount in another currency.
+func (o *Operation) Settled() bool {
+	return o.SettledAmount != nil && o.SettledCurrency != ""
 }
 
 func (Operation) TableName() string {
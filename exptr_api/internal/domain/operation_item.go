@@ -0,0 +1,32 @@
+package domain
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// OperationItem is one line of the receipt behind an operation, such as
+// "Milk 3.2% 1 l". UnitPrice is in minor units of the operation's Currency
+// and Quantity may be fractional for goods sold by weight. Key is the
+// description normalized for search and price history. Unlike splits, the
+// lines need not add up to the operation's Amount: receipts carry
+// discounts and rounding.
+type OperationItem struct {
+	OperationID uuid.UUID  `json:"-" gorm:"type:uuid;primaryKey"`
+	Position    int        `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Description string     `json:"description" gorm:"type:varchar(255);not null"`
+	Key         string     `json:"-" gorm:"type:varchar(255);not null;index"`
+	Quantity    float64    `json:"quantity" gorm:"type:decimal(12,3);not null"`
+	UnitPrice   int        `json:"unit_price" gorm:"type:decimal(19,4);not null"`
+	CategoryID  *uuid.UUID `json:"category_id,omitempty" gorm:"type:uuid;index"`
+}
+
+// Total is the price of the line in minor units.
+func (i OperationItem) Total() int {
+	return int(math.Round(float64(i.UnitPrice) * i.Quantity))
+}
+
+func (OperationItem) TableName() string {
+	return "operation_items"
+}
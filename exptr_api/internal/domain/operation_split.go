@@ -0,0 +1,19 @@
+package domain
+
+import "github.com/google/uuid"
+
+// OperationSplit is one line of an operation spread over several
+// categories, such as the groceries on a supermarket receipt that also
+// covered household goods. Amount is in minor units of the operation's
+// Currency; the lines of an operation add up to its Amount.
+type OperationSplit struct {
+	OperationID uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	Position    int       `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	CategoryID  uuid.UUID `json:"category_id" gorm:"type:uuid;not null;index"`
+	Amount      int       `json:"amount" gorm:"type:decimal(19,4);not null"`
+	Note        string    `json:"note,omitempty" gorm:"type:text"`
+}
+
+func (OperationSplit) TableName() string {
+	return "operation_splits"
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Payee is the merchant or person on the other side of an operation.
+// Operations are linked to a payee through its Aliases, the normalized
+// names banks use for it.
+type Payee struct {
+	BaseEntity
+	UserID  uuid.UUID    `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name    string       `json:"name" gorm:"type:varchar(255);not null"`
+	Aliases []PayeeAlias `json:"aliases,omitempty" gorm:"foreignKey:PayeeID"`
+}
+
+func (Payee) TableName() string {
+	return "payees"
+}
+
+// PayeeAlias maps a normalized operation name to a payee. Keys are unique
+// per user, so a name always leads to one payee.
+type PayeeAlias struct {
+	UserID  uuid.UUID `json:"-" gorm:"type:uuid;primaryKey"`
+	Key     string    `json:"key" gorm:"type:varchar(255);primaryKey"`
+	PayeeID uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+}
+
+func (PayeeAlias) TableName() string {
+	return "payee_aliases"
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// TextCondition matches a text field that contains a substring, ignoring
+// case, or matches a regular expression. With both set both must hold.
+type TextCondition struct {
+	Contains string `json:"contains,omitempty"`
+	Regex    string `json:"regex,omitempty"`
+}
+
+// RuleConditions select the operations a rule applies to. Every condition
+// set must hold; amounts are in minor units and both bounds are inclusive.
+type RuleConditions struct {
+	Name      *TextCondition `json:"name,omitempty"`
+	Comment   *TextCondition `json:"comment,omitempty"`
+	AmountMin *int           `json:"amount_min,omitempty"`
+	AmountMax *int           `json:"amount_max,omitempty"`
+	Currency  string         `json:"currency,omitempty"`
+	Account   string         `json:"account,omitempty"`
+	Type      string         `json:"type,omitempty"`
+}
+
+// Empty reports whether no condition is set, which would match everything.
+func (c RuleConditions) Empty() bool {
+	return c.Name == nil && c.Comment == nil && c.AmountMin == nil && c.AmountMax == nil &&
+		c.Currency == "" && c.Account == "" && c.Type == ""
+}
+
+func (c RuleConditions) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+func (c *RuleConditions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		return nil
+	default:
+		return errors.New("unsupported rule conditions type")
+	}
+}
+
+// RuleActions are applied to a matching operation: CategoryID sets its
+// category, Tags are added to its tags and Name renames it.
+type RuleActions struct {
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	Name       string     `json:"name,omitempty"`
+}
+
+// Empty reports whether the rule would change nothing.
+func (a RuleActions) Empty() bool {
+	return a.CategoryID == nil && len(a.Tags) == 0 && a.Name == ""
+}
+
+func (a RuleActions) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+func (a *RuleActions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case nil:
+		return nil
+	default:
+		return errors.New("unsupported rule actions type")
+	}
+}
+
+// Rule categorizes operations automatically. Rules run by ascending
+// Priority; Stop ends the run after the rule matched.
+type Rule struct {
+	BaseEntity
+	UserID     uuid.UUID      `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name       string         `json:"name" gorm:"type:varchar(255);not null"`
+	Priority   int            `json:"priority" gorm:"not null"`
+	Enabled    bool           `json:"enabled" gorm:"not null"`
+	Stop       bool           `json:"stop" gorm:"not null"`
+	Conditions RuleConditions `json:"conditions" gorm:"type:jsonb;not null"`
+	Actions    RuleActions    `json:"actions" gorm:"type:jsonb;not null"`
+}
+
+func (Rule) TableName() string {
+	return "rules"
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Tag is a free-form label cutting across categories, such as "business" or
+// "vacation-2026". Names are unique per user regardless of case.
+type Tag struct {
+	BaseEntity
+	UserID uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	Name   string    `json:"name" gorm:"type:varchar(64);not null"`
+	Color  string    `json:"color" gorm:"type:varchar(255)"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// OperationTag links an operation to a tag.
+type OperationTag struct {
+	OperationID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	TagID       uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+}
+
+func (OperationTag) TableName() string {
+	return "operation_tags"
+}
@@ -1,13 +1,21 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+// User is an account. BaseCurrency is the ISO 4217 code reports are converted into.
+// DeleteAfter is set while a deletion request is pending; once it passes the
+// account and everything it owns is purged for good.
 type User struct {
 	BaseEntity
-	Email    string `json:"email" gorm:"type:varchar;not null;uniqueIndex"`
-	Password string `json:"password" gorm:"type:varchar;not null"`
+	Email               string     `json:"email" gorm:"type:varchar;not null;uniqueIndex"`
+	Password            string     `json:"password" gorm:"type:varchar;not null"`
+	BaseCurrency        string     `json:"base_currency" gorm:"type:varchar(3);not null;default:'USD'"`
+	DeletionRequestedAt *time.Time `json:"deletion_requested_at,omitempty"`
+	DeleteAfter         *time.Time `json:"delete_after,omitempty" gorm:"index"`
 }
 
 func (User) TableName() string {
@@ -0,0 +1,84 @@
+// Package query parses common query string parameters of list endpoints.
+package query
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const dateLayout = "2006-01-02"
+
+// OperationFilter reads from, to (YYYY-MM-DD, to is inclusive), type, comma
+// separated category_id, tag_id and payee_id lists and tag_match (any or all).
+func OperationFilter(c *gin.Context) (models.OperationFilter, error) {
+	var filter models.OperationFilter
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return filter, fmt.Errorf("invalid from date %q", from)
+		}
+		filter.From = t
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return filter, fmt.Errorf("invalid to date %q", to)
+		}
+		filter.To = t.AddDate(0, 0, 1)
+	}
+
+	filter.Type = c.Query("type")
+
+	ids, err := UUIDList(c.Query("category_id"))
+	if err != nil {
+		return filter, err
+	}
+	filter.CategoryIDs = ids
+
+	ids, err = UUIDList(c.Query("tag_id"))
+	if err != nil {
+		return filter, err
+	}
+	filter.TagIDs = ids
+
+	ids, err = UUIDList(c.Query("payee_id"))
+	if err != nil {
+		return filter, err
+	}
+	filter.PayeeIDs = ids
+
+	switch match := c.DefaultQuery("tag_match", "any"); match {
+	case "any":
+	case "all":
+		filter.AllTags = true
+	default:
+		return filter, fmt.Errorf("invalid tag_match %q", match)
+	}
+
+	return filter, nil
+}
+
+func UUIDList(value string) ([]uuid.UUID, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var ids []uuid.UUID
+	for _, part := range strings.Split(value, ",") {
+		id, err := uuid.Parse(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("invalid id %q", part)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,355 @@
+// Package archive packs everything an account owns into a versioned zip so
+// it can be taken out of the app or moved to another instance.
+//
+// An archive holds manifest.json, describing the format and its schema
+// version, and one JSON file per kind of data. Binary files such as
+// attachments go under attachments/. Reading checks the schema version
+// first and refuses archives outside MinVersion..Version.
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	Format = "exptr-archive"
+	// Version is the schema version written by this build. Bump it when a
+	// file changes shape and add an upgrade step for the previous version.
+	//
+	// Version 2 added tags.json; version 1 archives are read without tags.
+	// Version 3 added splits to operations.json, which older archives simply
+	// lack. Version 4 added rules.json, version 5 payees.json and version 6
+	// attachments.json with the files under attachments/. Version 7 added
+	// receipt items to operations.json.
+	Version = 7
+	// MinVersion is the oldest schema version that can still be read.
+	MinVersion = 1
+)
+
+var (
+	ErrNotArchive         = errors.New("not an account archive")
+	ErrUnsupportedVersion = errors.New("unsupported archive version")
+)
+
+const (
+	fileManifest            = "manifest.json"
+	fileUser                = "user.json"
+	fileCategories          = "categories.json"
+	fileOperations          = "operations.json"
+	fileTags                = "tags.json"
+	fileImportProfiles      = "import_profiles.json"
+	fileRules               = "rules.json"
+	filePayees              = "payees.json"
+	fileAttachments         = "attachments.json"
+	fileDuplicateDismissals = "duplicate_dismissals.json"
+	fileSessions            = "sessions.json"
+
+	// dirAttachments holds the attached files, each named by its checksum.
+	dirAttachments = "attachments/"
+)
+
+// Manifest describes an archive. Counts lets a reader check that nothing
+// went missing on the way.
+type Manifest struct {
+	Format    string         `json:"format"`
+	Version   int            `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Counts    map[string]int `json:"counts"`
+}
+
+// User is the exported profile. Passwords and session tokens never leave
+// the instance.
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	BaseCurrency string    `json:"base_currency"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Session is the metadata of a login session, without its token.
+type Session struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Archive is the content of an account archive.
+type Archive struct {
+	Manifest            Manifest                    `json:"manifest"`
+	User                User                        `json:"user"`
+	Categories          []domain.Category           `json:"categories"`
+	Operations          []domain.Operation          `json:"operations"`
+	Tags                []domain.Tag                `json:"tags"`
+	ImportProfiles      []domain.ImportProfile      `json:"import_profiles"`
+	Rules               []domain.Rule               `json:"rules"`
+	Payees              []domain.Payee              `json:"payees"`
+	Attachments         []domain.Attachment         `json:"attachments"`
+	DuplicateDismissals []domain.DuplicateDismissal `json:"duplicate_dismissals"`
+	Sessions            []Session                   `json:"sessions"`
+
+	// Open returns the content of the attached file with checksum. The
+	// exporter sets it to read from the blob store; Read sets it to read
+	// from the archive.
+	Open func(checksum string) (io.ReadCloser, error) `json:"-"`
+}
+
+// Write stores the archive as a zip, filling in the manifest.
+func Write(w io.Writer, a *Archive) error {
+	a.Manifest = Manifest{
+		Format:    Format,
+		Version:   Version,
+		CreatedAt: time.Now().UTC(),
+		Counts: map[string]int{
+			fileCategories:          len(a.Categories),
+			fileOperations:          len(a.Operations),
+			fileTags:                len(a.Tags),
+			fileImportProfiles:      len(a.ImportProfiles),
+			fileRules:               len(a.Rules),
+			filePayees:              len(a.Payees),
+			fileAttachments:         len(a.Attachments),
+			fileDuplicateDismissals: len(a.DuplicateDismissals),
+			fileSessions:            len(a.Sessions),
+		},
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name  string
+		value any
+	}{
+		{fileManifest, a.Manifest},
+		{fileUser, a.User},
+		{fileCategories, a.Categories},
+		{fileOperations, a.Operations},
+		{fileTags, a.Tags},
+		{fileImportProfiles, a.ImportProfiles},
+		{fileRules, a.Rules},
+		{filePayees, a.Payees},
+		{fileAttachments, a.Attachments},
+		{fileDuplicateDismissals, a.DuplicateDismissals},
+		{fileSessions, a.Sessions},
+	}
+	for _, f := range files {
+		part, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(part)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.value); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	if err := a.writeFiles(zw); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeFiles stores each attached file once, uncompressed: receipts are
+// images and PDFs that hardly compress.
+func (a *Archive) writeFiles(zw *zip.Writer) error {
+	if len(a.Attachments) > 0 && a.Open == nil {
+		return errors.New("archive has attachments but no way to open them")
+	}
+
+	written := make(map[string]bool, len(a.Attachments))
+	for _, att := range a.Attachments {
+		if written[att.Checksum] {
+			continue
+		}
+		written[att.Checksum] = true
+
+		part, err := zw.CreateHeader(&zip.FileHeader{Name: dirAttachments + att.Checksum, Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		rc, err := a.Open(att.Checksum)
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", dirAttachments, att.Checksum, err)
+		}
+		_, err = io.Copy(part, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("%s%s: %w", dirAttachments, att.Checksum, err)
+		}
+	}
+	return nil
+}
+
+// Read opens an archive and checks its format and schema version.
+func Read(r io.ReaderAt, size int64) (*Archive, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrNotArchive
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var a Archive
+	if err := readJSON(files, fileManifest, &a.Manifest); err != nil {
+		return nil, ErrNotArchive
+	}
+	if a.Manifest.Format != Format {
+		return nil, ErrNotArchive
+	}
+	if a.Manifest.Version < MinVersion || a.Manifest.Version > Version {
+		return nil, fmt.Errorf("%w: %d, this instance reads %d to %d",
+			ErrUnsupportedVersion, a.Manifest.Version, MinVersion, Version)
+	}
+
+	parts := []struct {
+		name  string
+		value any
+	}{
+		{fileUser, &a.User},
+		{fileCategories, &a.Categories},
+		{fileOperations, &a.Operations},
+		{fileTags, &a.Tags},
+		{fileImportProfiles, &a.ImportProfiles},
+		{fileRules, &a.Rules},
+		{filePayees, &a.Payees},
+		{fileAttachments, &a.Attachments},
+		{fileDuplicateDismissals, &a.DuplicateDismissals},
+		{fileSessions, &a.Sessions},
+	}
+	for _, p := range parts {
+		if p.name == fileTags && a.Manifest.Version < 2 || p.name == fileRules && a.Manifest.Version < 4 ||
+			p.name == filePayees && a.Manifest.Version < 5 || p.name == fileAttachments && a.Manifest.Version < 6 {
+			continue
+		}
+		if err := readJSON(files, p.name, p.value); err != nil {
+			return nil, err
+		}
+	}
+
+	for name, want := range a.Manifest.Counts {
+		if got := a.count(name); got >= 0 && got != want {
+			return nil, fmt.Errorf("%s: expected %d entries, found %d", name, want, got)
+		}
+	}
+
+	if err := a.checkReferences(); err != nil {
+		return nil, err
+	}
+
+	for _, att := range a.Attachments {
+		if files[dirAttachments+att.Checksum] == nil {
+			return nil, fmt.Errorf("%s: file of attachment %s is missing", fileAttachments, att.ID)
+		}
+	}
+	a.Open = func(checksum string) (io.ReadCloser, error) {
+		f, ok := files[dirAttachments+checksum]
+		if !ok {
+			return nil, fmt.Errorf("%s%s is missing", dirAttachments, checksum)
+		}
+		return f.Open()
+	}
+
+	return &a, nil
+}
+
+// checkReferences makes sure every category, tag and payee an operation, its
+// splits or its items refer to is in the archive, as is the operation of
+// every attachment.
+func (a *Archive) checkReferences() error {
+	categories := make(map[uuid.UUID]bool, len(a.Categories))
+	for _, c := range a.Categories {
+		categories[c.ID] = true
+	}
+	tags := make(map[uuid.UUID]bool, len(a.Tags))
+	for _, t := range a.Tags {
+		tags[t.ID] = true
+	}
+	payees := make(map[uuid.UUID]bool, len(a.Payees))
+	for _, p := range a.Payees {
+		payees[p.ID] = true
+	}
+	for _, op := range a.Operations {
+		if op.PayeeID != nil && !payees[*op.PayeeID] {
+			return fmt.Errorf("%s: operation %s refers to a missing payee", fileOperations, op.ID)
+		}
+		if !categories[op.CategoryID] {
+			return fmt.Errorf("%s: operation %s refers to a missing category", fileOperations, op.ID)
+		}
+		for _, split := range op.Splits {
+			if !categories[split.CategoryID] {
+				return fmt.Errorf("%s: split of operation %s refers to a missing category", fileOperations, op.ID)
+			}
+		}
+		for _, item := range op.Items {
+			if item.CategoryID != nil && !categories[*item.CategoryID] {
+				return fmt.Errorf("%s: item of operation %s refers to a missing category", fileOperations, op.ID)
+			}
+		}
+		for _, t := range op.Tags {
+			if !tags[t.ID] {
+				return fmt.Errorf("%s: operation %s refers to a missing tag", fileOperations, op.ID)
+			}
+		}
+	}
+	operations := make(map[uuid.UUID]bool, len(a.Operations))
+	for _, op := range a.Operations {
+		operations[op.ID] = true
+	}
+	for _, att := range a.Attachments {
+		if !operations[att.OperationID] {
+			return fmt.Errorf("%s: attachment %s refers to a missing operation", fileAttachments, att.ID)
+		}
+	}
+	return nil
+}
+
+func (a *Archive) count(name string) int {
+	switch name {
+	case fileCategories:
+		return len(a.Categories)
+	case fileOperations:
+		return len(a.Operations)
+	case fileTags:
+		return len(a.Tags)
+	case fileImportProfiles:
+		return len(a.ImportProfiles)
+	case fileRules:
+		return len(a.Rules)
+	case filePayees:
+		return len(a.Payees)
+	case fileAttachments:
+		return len(a.Attachments)
+	case fileDuplicateDismissals:
+		return len(a.DuplicateDismissals)
+	case fileSessions:
+		return len(a.Sessions)
+	}
+	return -1
+}
+
+func readJSON(files map[string]*zip.File, name string, v any) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("%s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func sample() *Archive {
+	userID := uuid.New()
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, Name: "Food", Type: "expense"}
+	coffee := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, ParentID: &food.ID, Name: "Coffee", Type: "expense"}
+	business := domain.Tag{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, Name: "business"}
+	cafe := domain.Payee{
+		BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, Name: "Cafe",
+		Aliases: []domain.PayeeAlias{{UserID: userID, Key: "cafe"}},
+	}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	lunch := domain.Operation{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day},
+		UserID:     userID, CategoryID: food.ID, Name: "Lunch", Amount: 1250, Currency: "EUR", Type: "expense",
+		Tags: []domain.Tag{business}, PayeeID: &cafe.ID,
+		Splits: []domain.OperationSplit{
+			{Position: 0, CategoryID: food.ID, Amount: 1000},
+			{Position: 1, CategoryID: coffee.ID, Amount: 250, Note: "espresso"},
+		},
+		Items: []domain.OperationItem{
+			{Position: 0, Description: "Espresso", Quantity: 2, UnitPrice: 125, CategoryID: &coffee.ID},
+			{Position: 1, Description: "Croissant", Quantity: 1, UnitPrice: 1000},
+		},
+	}
+	dinner := lunch
+	dinner.ID = uuid.New()
+	dinner.Name = "Dinner"
+	key := dedup.PairKey(lunch.ID, dinner.ID)
+	receipt := strings.Repeat("ab", 32)
+	files := map[string]string{receipt: "%PDF-1.4 receipt"}
+
+	return &Archive{
+		User:       User{ID: userID.String(), Email: "user@example.com", BaseCurrency: "EUR"},
+		Categories: []domain.Category{food, coffee},
+		Operations: []domain.Operation{lunch, dinner},
+		Tags:       []domain.Tag{business},
+		ImportProfiles: []domain.ImportProfile{{
+			BaseEntity: domain.BaseEntity{ID: uuid.New()},
+			UserID:     userID,
+			Name:       "Bank",
+			Mapping:    domain.CSVMapping{DefaultCategoryID: &food.ID},
+		}},
+		Rules: []domain.Rule{{
+			BaseEntity: domain.BaseEntity{ID: uuid.New()},
+			UserID:     userID,
+			Name:       "Coffee shops",
+			Enabled:    true,
+			Conditions: domain.RuleConditions{Name: &domain.TextCondition{Contains: "coffee"}},
+			Actions:    domain.RuleActions{CategoryID: &coffee.ID},
+		}},
+		Payees:              []domain.Payee{cafe},
+		DuplicateDismissals: []domain.DuplicateDismissal{{UserID: userID, OperationID: key[0], OtherID: key[1]}},
+		Sessions:            []Session{{CreatedAt: day}},
+		Attachments: []domain.Attachment{
+			{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, OperationID: lunch.ID, FileName: "receipt.pdf", Checksum: receipt},
+			{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, OperationID: dinner.ID, FileName: "receipt.pdf", Checksum: receipt},
+		},
+		Open: func(checksum string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader(files[checksum])), nil
+		},
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	original := sample()
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, original))
+
+	restored, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, Format, restored.Manifest.Format)
+	require.Equal(t, Version, restored.Manifest.Version)
+	require.Equal(t, original.User, restored.User)
+	require.Equal(t, original.Categories[0].Name, restored.Categories[0].Name)
+	require.Len(t, restored.Operations, 2)
+	require.Equal(t, original.Operations[1].ID, restored.Operations[1].ID)
+	require.True(t, original.Operations[0].CreatedAt.Equal(restored.Operations[0].CreatedAt))
+	require.Len(t, restored.Tags, 1)
+	require.Equal(t, restored.Tags[0].ID, restored.Operations[0].Tags[0].ID)
+	require.Equal(t, original.Rules[0].Conditions, restored.Rules[0].Conditions)
+	require.Equal(t, "cafe", restored.Payees[0].Aliases[0].Key)
+	require.Equal(t, restored.Payees[0].ID, *restored.Operations[0].PayeeID)
+	require.Len(t, restored.Operations[0].Items, 2)
+	require.Equal(t, "Croissant", restored.Operations[0].Items[1].Description)
+	require.Equal(t, original.Operations[0].Items[0].Quantity, restored.Operations[0].Items[0].Quantity)
+	require.Len(t, restored.DuplicateDismissals, 1)
+	require.Len(t, restored.Sessions, 1)
+
+	require.Len(t, restored.Attachments, 2)
+	require.Equal(t, restored.Operations[1].ID, restored.Attachments[1].OperationID)
+	rc, err := restored.Open(restored.Attachments[0].Checksum)
+	require.NoError(t, err)
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, "%PDF-1.4 receipt", string(content))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	stored := 0
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, dirAttachments) {
+			stored++
+		}
+	}
+	require.Equal(t, 1, stored, "a file attached twice is stored once")
+}
+
+func TestRemap(t *testing.T) {
+	a := sample()
+	oldCategory := a.Categories[0].ID
+	oldOperation := a.Operations[0].ID
+	oldTag := a.Tags[0].ID
+	oldPayee := a.Payees[0].ID
+	newUser := uuid.New()
+
+	a.Remap(newUser)
+
+	require.NotEqual(t, oldCategory, a.Categories[0].ID)
+	require.NotEqual(t, oldOperation, a.Operations[0].ID)
+	require.Equal(t, newUser, a.Categories[0].UserID)
+	require.Equal(t, a.Categories[0].ID, *a.Categories[1].ParentID)
+	for _, op := range a.Operations {
+		require.Equal(t, newUser, op.UserID)
+		require.Equal(t, a.Categories[0].ID, op.CategoryID)
+	}
+	require.Equal(t, a.Categories[0].ID, *a.ImportProfiles[0].Mapping.DefaultCategoryID)
+	require.Equal(t, newUser, a.Rules[0].UserID)
+	require.Equal(t, a.Categories[1].ID, *a.Rules[0].Actions.CategoryID)
+	require.NotEqual(t, oldTag, a.Tags[0].ID)
+	require.NotEqual(t, oldPayee, a.Payees[0].ID)
+	require.Equal(t, newUser, a.Payees[0].Aliases[0].UserID)
+	require.Equal(t, a.Payees[0].ID, a.Payees[0].Aliases[0].PayeeID)
+	for _, op := range a.Operations {
+		require.Equal(t, a.Payees[0].ID, *op.PayeeID)
+		require.Equal(t, a.Tags[0].ID, op.Tags[0].ID)
+		require.Equal(t, op.ID, op.Splits[1].OperationID)
+		require.Equal(t, a.Categories[1].ID, op.Splits[1].CategoryID)
+		require.Equal(t, op.ID, op.Items[0].OperationID)
+		require.Equal(t, "espresso", op.Items[0].Key)
+		require.Equal(t, a.Categories[1].ID, *op.Items[0].CategoryID)
+	}
+
+	d := a.DuplicateDismissals[0]
+	require.Equal(t, dedup.PairKey(a.Operations[0].ID, a.Operations[1].ID), dedup.Key{d.OperationID, d.OtherID})
+	require.Equal(t, newUser.String(), a.User.ID)
+	for i, att := range a.Attachments {
+		require.Equal(t, newUser, att.UserID)
+		require.Equal(t, a.Operations[i].ID, att.OperationID)
+	}
+}
+
+func TestRestoredLinesKeepTheirOrder(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, sample()))
+	restored, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	restored.Remap(uuid.New())
+
+	for _, op := range restored.Operations {
+		require.Len(t, op.Splits, 2)
+		for i, split := range op.Splits {
+			require.Equal(t, i, split.Position)
+			require.Equal(t, op.ID, split.OperationID)
+		}
+		require.Equal(t, "espresso", op.Splits[1].Note)
+
+		require.Len(t, op.Items, 2)
+		for i, item := range op.Items {
+			require.Equal(t, i, item.Position)
+			require.Equal(t, op.ID, item.OperationID)
+		}
+		require.Equal(t, "croissant", op.Items[1].Key)
+	}
+}
+
+func TestReadChecksVersion(t *testing.T) {
+	for _, manifest := range []Manifest{
+		{Format: Format, Version: Version + 1},
+		{Format: Format, Version: MinVersion - 1},
+	} {
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		f, err := zw.Create(fileManifest)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(f).Encode(manifest))
+		require.NoError(t, zw.Close())
+
+		_, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.ErrorIs(t, err, ErrUnsupportedVersion)
+	}
+
+	_, err := Read(bytes.NewReader([]byte("not a zip")), 9)
+	require.ErrorIs(t, err, ErrNotArchive)
+}
+
+func TestReadChecksReferences(t *testing.T) {
+	a := sample()
+	a.Operations[0].CategoryID = uuid.New()
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, a))
+
+	_, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.ErrorContains(t, err, "missing category")
+
+	a = sample()
+	a.Attachments[0].OperationID = uuid.New()
+	buf.Reset()
+	require.NoError(t, Write(&buf, a))
+
+	_, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.ErrorContains(t, err, "missing operation")
+}
+
+func TestReadVersion1WithoutTags(t *testing.T) {
+	a := sample()
+	for i := range a.Operations {
+		a.Operations[i].Tags = nil
+		a.Operations[i].Splits = nil
+		a.Operations[i].PayeeID = nil
+		a.Operations[i].Items = nil
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := map[string]any{
+		fileManifest:            Manifest{Format: Format, Version: 1},
+		fileUser:                a.User,
+		fileCategories:          a.Categories,
+		fileOperations:          a.Operations,
+		fileImportProfiles:      a.ImportProfiles,
+		fileDuplicateDismissals: a.DuplicateDismissals,
+		fileSessions:            a.Sessions,
+	}
+	for name, value := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		require.NoError(t, json.NewEncoder(f).Encode(value))
+	}
+	require.NoError(t, zw.Close())
+
+	restored, err := Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Empty(t, restored.Tags)
+	require.Len(t, restored.Operations, 2)
+}
@@ -0,0 +1,143 @@
+package archive
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+	"alex_gorbunov_exptr_api/internal/lib/items"
+
+	"github.com/google/uuid"
+)
+
+// Remap gives every entity of the archive a new id and moves it to userID,
+// rewriting the references between them, so an archive can be restored
+// next to the account it was taken from. Subcategories whose parent is not
+// in the archive become top-level, import profiles and rules lose a missing
+// category and dismissals of missing operations are dropped.
+func (a *Archive) Remap(userID uuid.UUID) {
+	categories := make(map[uuid.UUID]uuid.UUID, len(a.Categories))
+	for i := range a.Categories {
+		c := &a.Categories[i]
+		categories[c.ID] = uuid.New()
+		c.ID = categories[c.ID]
+		c.UserID = userID
+	}
+	for i := range a.Categories {
+		c := &a.Categories[i]
+		if c.ParentID == nil {
+			continue
+		}
+		if mapped, ok := categories[*c.ParentID]; ok {
+			c.ParentID = &mapped
+		} else {
+			c.ParentID = nil
+		}
+	}
+
+	tags := make(map[uuid.UUID]uuid.UUID, len(a.Tags))
+	for i := range a.Tags {
+		t := &a.Tags[i]
+		tags[t.ID] = uuid.New()
+		t.ID = tags[t.ID]
+		t.UserID = userID
+	}
+
+	payees := make(map[uuid.UUID]uuid.UUID, len(a.Payees))
+	for i := range a.Payees {
+		p := &a.Payees[i]
+		payees[p.ID] = uuid.New()
+		p.ID = payees[p.ID]
+		p.UserID = userID
+		for j := range p.Aliases {
+			p.Aliases[j].UserID, p.Aliases[j].PayeeID = userID, p.ID
+		}
+	}
+
+	operations := make(map[uuid.UUID]uuid.UUID, len(a.Operations))
+	for i := range a.Operations {
+		op := &a.Operations[i]
+		operations[op.ID] = uuid.New()
+		op.ID = operations[op.ID]
+		op.UserID = userID
+		op.CategoryID = categories[op.CategoryID]
+		if op.PayeeID != nil {
+			mapped := payees[*op.PayeeID]
+			op.PayeeID = &mapped
+		}
+		// Positions are not archived; lines are exported in position order.
+		splits := make([]domain.OperationSplit, 0, len(op.Splits))
+		for i, split := range op.Splits {
+			split.OperationID, split.Position, split.CategoryID = op.ID, i, categories[split.CategoryID]
+			splits = append(splits, split)
+		}
+		op.Splits = splits
+		lines := make([]domain.OperationItem, 0, len(op.Items))
+		for i, item := range op.Items {
+			// Search keys are not archived but derived afresh.
+			item.OperationID, item.Position, item.Key = op.ID, i, items.Key(item.Description)
+			if item.CategoryID != nil {
+				if mapped, ok := categories[*item.CategoryID]; ok {
+					item.CategoryID = &mapped
+				} else {
+					item.CategoryID = nil
+				}
+			}
+			lines = append(lines, item)
+		}
+		op.Items = lines
+		opTags := make([]domain.Tag, 0, len(op.Tags))
+		for _, t := range op.Tags {
+			t.ID, t.UserID = tags[t.ID], userID
+			opTags = append(opTags, t)
+		}
+		op.Tags = opTags
+	}
+
+	for i := range a.ImportProfiles {
+		p := &a.ImportProfiles[i]
+		p.ID = uuid.New()
+		p.UserID = userID
+		if id := p.Mapping.DefaultCategoryID; id != nil {
+			if mapped, ok := categories[*id]; ok {
+				p.Mapping.DefaultCategoryID = &mapped
+			} else {
+				p.Mapping.DefaultCategoryID = nil
+			}
+		}
+	}
+
+	for i := range a.Rules {
+		rule := &a.Rules[i]
+		rule.ID = uuid.New()
+		rule.UserID = userID
+		if id := rule.Actions.CategoryID; id != nil {
+			if mapped, ok := categories[*id]; ok {
+				rule.Actions.CategoryID = &mapped
+			} else {
+				rule.Actions.CategoryID = nil
+			}
+		}
+	}
+
+	for i := range a.Attachments {
+		att := &a.Attachments[i]
+		att.ID = uuid.New()
+		att.UserID = userID
+		att.OperationID = operations[att.OperationID]
+	}
+
+	dismissals := a.DuplicateDismissals[:0]
+	for _, d := range a.DuplicateDismissals {
+		operationID, ok1 := operations[d.OperationID]
+		otherID, ok2 := operations[d.OtherID]
+		if !ok1 || !ok2 {
+			continue
+		}
+		d.BaseEntity = domain.BaseEntity{ID: uuid.New(), CreatedAt: d.CreatedAt, UpdatedAt: d.UpdatedAt}
+		key := dedup.PairKey(operationID, otherID)
+		d.UserID, d.OperationID, d.OtherID = userID, key[0], key[1]
+		dismissals = append(dismissals, d)
+	}
+	a.DuplicateDismissals = dismissals
+
+	a.User.ID = userID.String()
+}
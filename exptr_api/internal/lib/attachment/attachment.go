@@ -0,0 +1,215 @@
+// Package attachment checks files attached to operations and keeps them in a
+// blob store. A file is stored once per user under its SHA-256, however many
+// operations it is attached to, next to a JPEG thumbnail when it is an
+// image.
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+
+	"github.com/google/uuid"
+)
+
+const (
+	filesPrefix      = "attachments/"
+	thumbnailsPrefix = "thumbnails/"
+)
+
+var (
+	ErrEmpty       = errors.New("file is empty")
+	ErrTooLarge    = errors.New("file is too large")
+	ErrUnsupported = errors.New("file type is not supported")
+)
+
+// Types are the content types accepted, as sniffed from the content rather
+// than taken from the client.
+var Types = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+// Upload is a file checked for attaching.
+type Upload struct {
+	ContentType string
+	Size        int64
+	Checksum    string
+	// Thumbnail is nil for files that are not images this package decodes.
+	Thumbnail []byte
+}
+
+// Inspect reads r, which must not exceed maxSize bytes, and rewinds it.
+func Inspect(r io.ReadSeeker, maxSize int64) (*Upload, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, ErrEmpty
+	}
+	contentType := http.DetectContentType(head[:n])
+	if !Types[contentType] {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, contentType)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	up := &Upload{
+		ContentType: contentType,
+		Size:        size,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+	}
+
+	if strings.HasPrefix(contentType, "image/") {
+		if _, err := r.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		// Formats the standard library cannot decode, such as WebP, simply
+		// go without a thumbnail.
+		if thumb, err := Thumbnail(r, ThumbnailSize); err == nil {
+			up.Thumbnail = thumb
+		}
+	}
+
+	_, err = r.Seek(0, io.SeekStart)
+	return up, err
+}
+
+// Key is where the user's file with checksum is stored.
+func Key(userID uuid.UUID, checksum string) string {
+	return filesPrefix + userID.String() + "/" + checksum
+}
+
+// ThumbnailKey is where the thumbnail of the user's file is stored.
+func ThumbnailKey(userID uuid.UUID, checksum string) string {
+	return thumbnailsPrefix + userID.String() + "/" + checksum + ".jpg"
+}
+
+// Save stores the file read from r and its thumbnail.
+func Save(ctx context.Context, store blob.Store, userID uuid.UUID, up *Upload, r io.Reader) error {
+	if err := store.Put(ctx, Key(userID, up.Checksum), r, up.Size, up.ContentType); err != nil {
+		return err
+	}
+	if up.Thumbnail == nil {
+		return nil
+	}
+	return store.Put(ctx, ThumbnailKey(userID, up.Checksum), bytes.NewReader(up.Thumbnail), int64(len(up.Thumbnail)), "image/jpeg")
+}
+
+// Remove deletes the user's file with checksum and its thumbnail.
+func Remove(ctx context.Context, store blob.Store, userID uuid.UUID, checksum string) error {
+	if err := store.Delete(ctx, Key(userID, checksum)); err != nil {
+		return err
+	}
+	return store.Delete(ctx, ThumbnailKey(userID, checksum))
+}
+
+// Purge deletes every file and thumbnail of the user and returns how many
+// blobs went.
+func Purge(ctx context.Context, store blob.Store, userID uuid.UUID) (int, error) {
+	files, err := blob.DeletePrefix(ctx, store, filesPrefix+userID.String()+"/")
+	if err != nil {
+		return files, err
+	}
+	thumbnails, err := blob.DeletePrefix(ctx, store, thumbnailsPrefix+userID.String()+"/")
+	return files + thumbnails, err
+}
+
+// References tells which of the user's checksums an attachment still
+// refers to.
+type References interface {
+	GetAttachmentChecksums(userID uuid.UUID, checksums []string) (map[string]bool, error)
+}
+
+// Sweep deletes files and thumbnails no attachment refers to any more, such
+// as those of operations purged from the trash. Blobs modified after before
+// are kept, since their attachment may still be on its way to the database.
+// It returns how many blobs were deleted.
+func Sweep(ctx context.Context, store blob.Store, refs References, before time.Time) (int, error) {
+	orphans := make(map[uuid.UUID]map[string][]string)
+	for _, prefix := range []string{filesPrefix, thumbnailsPrefix} {
+		objects, err := store.List(ctx, prefix)
+		if err != nil {
+			return 0, err
+		}
+		for _, o := range objects {
+			if o.ModTime.After(before) {
+				continue
+			}
+			userID, checksum, ok := parseKey(strings.TrimPrefix(o.Key, prefix))
+			if !ok {
+				continue
+			}
+			if orphans[userID] == nil {
+				orphans[userID] = make(map[string][]string)
+			}
+			orphans[userID][checksum] = append(orphans[userID][checksum], o.Key)
+		}
+	}
+
+	deleted := 0
+	for userID, byChecksum := range orphans {
+		checksums := make([]string, 0, len(byChecksum))
+		for checksum := range byChecksum {
+			checksums = append(checksums, checksum)
+		}
+		used, err := refs.GetAttachmentChecksums(userID, checksums)
+		if err != nil {
+			return deleted, err
+		}
+		for checksum, keys := range byChecksum {
+			if used[checksum] {
+				continue
+			}
+			for _, key := range keys {
+				if err := store.Delete(ctx, key); err != nil {
+					return deleted, err
+				}
+				deleted++
+			}
+		}
+	}
+
+	return deleted, nil
+}
+
+// parseKey splits "<user>/<checksum>[.jpg]".
+func parseKey(rest string) (uuid.UUID, string, bool) {
+	user, name, ok := strings.Cut(rest, "/")
+	if !ok {
+		return uuid.Nil, "", false
+	}
+	userID, err := uuid.Parse(user)
+	if err != nil {
+		return uuid.Nil, "", false
+	}
+	checksum := strings.TrimSuffix(name, ".jpg")
+	if len(checksum) != sha256.Size*2 {
+		return uuid.Nil, "", false
+	}
+	return userID, checksum, true
+}
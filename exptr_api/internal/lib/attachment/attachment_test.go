@@ -0,0 +1,145 @@
+package attachment
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func pngOf(w, h int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+func TestInspectImage(t *testing.T) {
+	data := pngOf(800, 400)
+	r := bytes.NewReader(data)
+
+	up, err := Inspect(r, 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, "image/png", up.ContentType)
+	require.Equal(t, int64(len(data)), up.Size)
+	require.Len(t, up.Checksum, 64)
+
+	thumb, err := jpeg.Decode(bytes.NewReader(up.Thumbnail))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+	red, _, _, _ := thumb.At(10, 10).RGBA()
+	require.Greater(t, red>>8, uint32(180))
+
+	pos, _ := r.Seek(0, 1)
+	require.Zero(t, pos, "the reader is rewound")
+}
+
+func TestInspectKeepsSmallImagesAndTransparency(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	up, err := Inspect(bytes.NewReader(buf.Bytes()), 1<<20)
+	require.NoError(t, err)
+	thumb, err := jpeg.Decode(bytes.NewReader(up.Thumbnail))
+	require.NoError(t, err)
+	require.Equal(t, image.Rect(0, 0, 40, 20), thumb.Bounds())
+	r, g, b, _ := thumb.At(5, 5).RGBA()
+	require.Greater(t, r>>8, uint32(240), "transparent turns white")
+	require.Greater(t, g>>8, uint32(240))
+	require.Greater(t, b>>8, uint32(240))
+}
+
+func TestInspectRefuses(t *testing.T) {
+	pdf := []byte("%PDF-1.4\n" + strings.Repeat("x", 100))
+	up, err := Inspect(bytes.NewReader(pdf), 1<<20)
+	require.NoError(t, err)
+	require.Equal(t, "application/pdf", up.ContentType)
+	require.Nil(t, up.Thumbnail)
+
+	_, err = Inspect(bytes.NewReader(pdf), 50)
+	require.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Inspect(bytes.NewReader([]byte("plain text, not a receipt")), 1<<20)
+	require.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = Inspect(bytes.NewReader(nil), 1<<20)
+	require.ErrorIs(t, err, ErrEmpty)
+}
+
+type fakeReferences map[uuid.UUID]map[string]bool
+
+func (f fakeReferences) GetAttachmentChecksums(userID uuid.UUID, checksums []string) (map[string]bool, error) {
+	used := make(map[string]bool)
+	for _, c := range checksums {
+		if f[userID][c] {
+			used[c] = true
+		}
+	}
+	return used, nil
+}
+
+func TestSaveSweepPurge(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	store := blob.FS{Root: root}
+	alice, bob := uuid.New(), uuid.New()
+
+	save := func(userID uuid.UUID, data []byte) *Upload {
+		up, err := Inspect(bytes.NewReader(data), 1<<20)
+		require.NoError(t, err)
+		require.NoError(t, Save(ctx, store, userID, up, bytes.NewReader(data)))
+		return up
+	}
+	kept := save(alice, pngOf(10, 10))
+	orphan := save(alice, pngOf(20, 20))
+	recent := save(alice, []byte("%PDF-1.4 recent"))
+	other := save(bob, []byte("%PDF-1.4 bob"))
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{
+		Key(alice, kept.Checksum), ThumbnailKey(alice, kept.Checksum),
+		Key(alice, orphan.Checksum), ThumbnailKey(alice, orphan.Checksum),
+		Key(bob, other.Checksum),
+	} {
+		require.NoError(t, os.Chtimes(filepath.Join(root, filepath.FromSlash(key)), old, old))
+	}
+
+	refs := fakeReferences{alice: {kept.Checksum: true}, bob: {other.Checksum: true}}
+	deleted, err := Sweep(ctx, store, refs, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted, "the orphan and its thumbnail")
+
+	_, err = store.Get(ctx, Key(alice, orphan.Checksum))
+	require.ErrorIs(t, err, blob.ErrNotFound)
+	for _, key := range []string{Key(alice, kept.Checksum), Key(alice, recent.Checksum), Key(bob, other.Checksum)} {
+		rc, err := store.Get(ctx, key)
+		require.NoError(t, err, key)
+		rc.Close()
+	}
+
+	purged, err := Purge(ctx, store, alice)
+	require.NoError(t, err)
+	require.Equal(t, 3, purged)
+	objects, err := store.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	require.Equal(t, Key(bob, other.Checksum), objects[0].Key)
+}
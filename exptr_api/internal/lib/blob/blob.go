@@ -0,0 +1,62 @@
+// Package blob stores opaque files under string keys. Keys use "/" as a
+// separator whatever the backend, like "attachments/<user>/<checksum>".
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+)
+
+// Object describes a stored blob.
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Store keeps blobs. Put replaces a blob with the same key; Delete of a
+// missing blob is not an error. List returns every blob whose key starts
+// with prefix.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+// DeletePrefix deletes every blob whose key starts with prefix and returns
+// how many were deleted.
+func DeletePrefix(ctx context.Context, s Store, prefix string) (int, error) {
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	for i, o := range objects {
+		if err := s.Delete(ctx, o.Key); err != nil {
+			return i, err
+		}
+	}
+	return len(objects), nil
+}
+
+// checkKey refuses keys that are empty, absolute or climb out of the store.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
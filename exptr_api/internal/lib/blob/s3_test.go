@@ -0,0 +1,196 @@
+package blob
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 stands in for MinIO: a single bucket kept in memory that checks
+// every request's signature and lists two keys per page.
+type fakeS3 struct {
+	bucket string
+	signer signer
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3(bucket, accessKey, secretKey string) *fakeS3 {
+	return &fakeS3{
+		bucket:  bucket,
+		signer:  signer{accessKey: accessKey, secretKey: secretKey, region: "us-east-1", service: "s3"},
+		objects: make(map[string][]byte),
+		types:   make(map[string]string),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !f.signed(r) {
+		f.fail(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/"+f.bucket && r.Method == http.MethodGet {
+		f.list(w, r)
+		return
+	}
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket+"/")
+	if !ok {
+		f.fail(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			f.fail(w, http.StatusLengthRequired, "MissingContentLength")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		f.objects[key] = body
+		f.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.fail(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// signed signs the request again as received and compares.
+func (f *fakeS3) signed(r *http.Request) bool {
+	date, err := time.Parse(amzDateLayout, r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return false
+	}
+	clone := r.Clone(context.Background())
+	clone.URL.Host = r.Host
+	clone.Header.Del("Authorization")
+	f.signer.sign(clone, r.Header.Get("X-Amz-Content-Sha256"), date)
+	return clone.Header.Get("Authorization") == r.Header.Get("Authorization")
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request) {
+	prefix, after := r.URL.Query().Get("prefix"), r.URL.Query().Get("continuation-token")
+	var keys []string
+	for key := range f.objects {
+		if strings.HasPrefix(key, prefix) && key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var page listBucketResult
+	for i, key := range keys {
+		if i == 2 {
+			page.IsTruncated = true
+			page.NextContinuationToken = keys[1]
+			break
+		}
+		page.Contents = append(page.Contents, struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		}{Key: key, Size: int64(len(f.objects[key])), LastModified: time.Now().UTC()})
+	}
+	xml.NewEncoder(w).Encode(page)
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+	}{Code: code})
+}
+
+func TestS3(t *testing.T) {
+	fake := newFakeS3("receipts", "minio", "minio-secret")
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	s := &S3{Endpoint: srv.URL, Bucket: "receipts", AccessKey: "minio", SecretKey: "minio-secret", PathStyle: true}
+	testStore(t, s)
+
+	require.Equal(t, "text/plain", fake.types["a/one file.txt"])
+
+	wrong := &S3{Endpoint: srv.URL, Bucket: "receipts", AccessKey: "minio", SecretKey: "guess", PathStyle: true}
+	err := wrong.Put(context.Background(), "a/x", strings.NewReader("x"), 1, "")
+	require.ErrorContains(t, err, "SignatureDoesNotMatch")
+}
+
+func TestFS(t *testing.T) {
+	testStore(t, FS{Root: t.TempDir()})
+}
+
+// testStore runs the same checks against any Store.
+func testStore(t *testing.T, s Store) {
+	ctx := context.Background()
+
+	for key, body := range map[string]string{
+		"a/one file.txt": "one",
+		"a/two":          "two",
+		"a/sub/three":    "three",
+		"b/four":         "four",
+	} {
+		require.NoError(t, s.Put(ctx, key, strings.NewReader(body), int64(len(body)), "text/plain"))
+	}
+
+	rc, err := s.Get(ctx, "a/one file.txt")
+	require.NoError(t, err)
+	body, err := io.ReadAll(rc)
+	rc.Close()
+	require.NoError(t, err)
+	require.Equal(t, "one", string(body))
+
+	_, err = s.Get(ctx, "a/missing")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	objects, err := s.List(ctx, "a/")
+	require.NoError(t, err)
+	var keys []string
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	sort.Strings(keys)
+	require.Equal(t, []string{"a/one file.txt", "a/sub/three", "a/two"}, keys)
+
+	require.NoError(t, s.Delete(ctx, "a/two"))
+	require.NoError(t, s.Delete(ctx, "a/two"), "deleting a missing blob is fine")
+	_, err = s.Get(ctx, "a/two")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	deleted, err := DeletePrefix(ctx, s, "a/")
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+	objects, err = s.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	require.Equal(t, "b/four", objects[0].Key)
+
+	for _, key := range []string{"", "/abs", "a/../b", "a//b", `a\b`} {
+		require.ErrorIs(t, s.Put(ctx, key, strings.NewReader("x"), 1, ""), ErrInvalidKey, key)
+	}
+}
@@ -0,0 +1,176 @@
+package categorizer
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Prediction is a category with its probability among the candidates.
+type Prediction struct {
+	CategoryID  uuid.UUID
+	Probability float64
+}
+
+// Classifier is a multinomial naive Bayes classifier over the words of
+// operation names and comments. It is safe for concurrent use.
+type Classifier struct {
+	mu     sync.RWMutex
+	docs   map[uuid.UUID]int
+	counts map[uuid.UUID]map[string]int
+	totals map[uuid.UUID]int
+	vocab  map[string]int
+	total  int
+}
+
+func NewClassifier() *Classifier {
+	return &Classifier{
+		docs:   make(map[uuid.UUID]int),
+		counts: make(map[uuid.UUID]map[string]int),
+		totals: make(map[uuid.UUID]int),
+		vocab:  make(map[string]int),
+	}
+}
+
+// Features returns the tokens the classifier looks at: the words of the
+// name and comment, without numbers and single letters, which are mostly
+// card numbers, dates and noise.
+func Features(in Input) []string {
+	var tokens []string
+	for _, w := range words(in.Name + " " + in.Comment) {
+		if utf8.RuneCountInString(w) < 2 || isNumber(w) {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// Learn counts an operation of the category.
+func (c *Classifier) Learn(categoryID uuid.UUID, tokens []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := c.counts[categoryID]
+	if counts == nil {
+		counts = make(map[string]int)
+		c.counts[categoryID] = counts
+	}
+	for _, t := range tokens {
+		counts[t]++
+		c.vocab[t]++
+	}
+	c.docs[categoryID]++
+	c.totals[categoryID] += len(tokens)
+	c.total++
+}
+
+// Forget takes back an operation learned before, such as one that was
+// recategorized. Forgetting what was never learned is a no-op.
+func (c *Classifier) Forget(categoryID uuid.UUID, tokens []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.docs[categoryID] == 0 {
+		return
+	}
+	counts := c.counts[categoryID]
+	for _, t := range tokens {
+		if counts[t] == 0 {
+			continue
+		}
+		counts[t]--
+		c.totals[categoryID]--
+		if counts[t] == 0 {
+			delete(counts, t)
+		}
+		if c.vocab[t]--; c.vocab[t] <= 0 {
+			delete(c.vocab, t)
+		}
+	}
+	c.total--
+	if c.docs[categoryID]--; c.docs[categoryID] == 0 {
+		delete(c.docs, categoryID)
+		delete(c.counts, categoryID)
+		delete(c.totals, categoryID)
+	}
+}
+
+// Rank returns up to k of the candidates, most probable first. Candidates
+// never seen are left out, and nothing is returned when none of the tokens
+// was seen before, since the class priors alone say little.
+func (c *Classifier) Rank(tokens []string, candidates []uuid.UUID, k int) []Prediction {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var known []string
+	for _, t := range tokens {
+		if c.vocab[t] > 0 {
+			known = append(known, t)
+		}
+	}
+	if len(known) == 0 {
+		return nil
+	}
+
+	vocab := float64(len(c.vocab))
+	var predictions []Prediction
+	var logs []float64
+	for _, id := range candidates {
+		docs := c.docs[id]
+		if docs == 0 {
+			continue
+		}
+		score := math.Log(float64(docs) / float64(c.total))
+		total := float64(c.totals[id])
+		for _, t := range known {
+			score += math.Log((float64(c.counts[id][t]) + 1) / (total + vocab))
+		}
+		predictions = append(predictions, Prediction{CategoryID: id})
+		logs = append(logs, score)
+	}
+	if len(predictions) == 0 {
+		return nil
+	}
+
+	// Softmax over the log scores, shifted by the maximum to stay in range.
+	max := logs[0]
+	for _, l := range logs {
+		max = math.Max(max, l)
+	}
+	var sum float64
+	for i, l := range logs {
+		predictions[i].Probability = math.Exp(l - max)
+		sum += predictions[i].Probability
+	}
+	for i := range predictions {
+		predictions[i].Probability /= sum
+	}
+
+	sort.SliceStable(predictions, func(i, j int) bool {
+		return predictions[i].Probability > predictions[j].Probability
+	})
+	if k > 0 && len(predictions) > k {
+		predictions = predictions[:k]
+	}
+	return predictions
+}
+
+// Len returns the number of operations learned.
+func (c *Classifier) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.total
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package categorizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatures(t *testing.T) {
+	require.Equal(t, []string{"lidl", "berlin", "card", "payment"},
+		Features(Input{Name: "LIDL 1234 Berlin", Comment: "card payment 12.03 x"}))
+}
+
+func TestClassifierRank(t *testing.T) {
+	groceries, transport, rent := uuid.New(), uuid.New(), uuid.New()
+	c := NewClassifier()
+	c.Learn(groceries, []string{"lidl", "berlin"})
+	c.Learn(groceries, []string{"rewe", "berlin"})
+	c.Learn(groceries, []string{"lidl"})
+	c.Learn(transport, []string{"bvg", "ticket", "berlin"})
+	c.Learn(transport, []string{"uber", "trip"})
+
+	ranked := c.Rank([]string{"lidl", "munich"}, []uuid.UUID{groceries, transport, rent}, 3)
+	require.Len(t, ranked, 2, "categories never learned are left out")
+	require.Equal(t, groceries, ranked[0].CategoryID)
+	require.Greater(t, ranked[0].Probability, 0.8)
+	require.InDelta(t, 1, ranked[0].Probability+ranked[1].Probability, 1e-9)
+
+	ranked = c.Rank([]string{"bvg"}, []uuid.UUID{groceries, transport}, 1)
+	require.Len(t, ranked, 1)
+	require.Equal(t, transport, ranked[0].CategoryID)
+
+	require.Empty(t, c.Rank([]string{"unknown"}, []uuid.UUID{groceries, transport}, 3))
+	require.Empty(t, c.Rank([]string{"lidl"}, []uuid.UUID{rent}, 3))
+}
+
+func TestClassifierForget(t *testing.T) {
+	groceries, transport := uuid.New(), uuid.New()
+	c := NewClassifier()
+	c.Learn(groceries, []string{"shop"})
+	c.Learn(transport, []string{"shop", "bus"})
+
+	c.Forget(transport, []string{"shop", "bus"})
+	c.Forget(transport, []string{"shop"})
+	require.Equal(t, 1, c.Len())
+
+	ranked := c.Rank([]string{"shop"}, []uuid.UUID{groceries, transport}, 2)
+	require.Len(t, ranked, 1)
+	require.Equal(t, groceries, ranked[0].CategoryID)
+	require.Equal(t, 1.0, ranked[0].Probability)
+	require.Empty(t, c.Rank([]string{"bus"}, []uuid.UUID{groceries, transport}, 2))
+}
+
+type fakeHistory struct {
+	operations []domain.Operation
+	loads      int
+}
+
+func (h *fakeHistory) GetTrainingOperations(uuid.UUID, int) ([]domain.Operation, error) {
+	h.loads++
+	return h.operations, nil
+}
+
+func TestLearner(t *testing.T) {
+	userID := uuid.New()
+	categories := testCategories()
+	groceries, eatingOut := categories[0], categories[1]
+	history := &fakeHistory{operations: []domain.Operation{
+		{CategoryID: groceries.ID, Name: "Lidl"},
+		{CategoryID: groceries.ID, Name: "Rewe"},
+		{CategoryID: eatingOut.ID, Name: "Pizza place"},
+	}}
+	l := NewLearner(history, LearnerOptions{MaxAge: time.Hour})
+
+	in := Input{UserID: userID, Name: "LIDL 42", Type: "expense"}
+	got, err := l.Suggest(context.Background(), in, categories)
+	require.NoError(t, err)
+	require.Equal(t, groceries.ID, got.CategoryID)
+	require.Equal(t, SourceHistory, got.Source)
+	require.Equal(t, 1, history.loads)
+
+	// Learned incrementally, without reloading the history.
+	sushi := Input{UserID: userID, Name: "Sushi bar", Type: "expense"}
+	_, err = l.Suggest(context.Background(), sushi, categories)
+	require.ErrorIs(t, err, ErrNoMatch)
+	l.Learn(sushi, eatingOut.ID)
+	got, err = l.Suggest(context.Background(), sushi, categories)
+	require.NoError(t, err)
+	require.Equal(t, eatingOut.ID, got.CategoryID)
+
+	// Recategorizing moves what was learned.
+	l.Recategorize(sushi, eatingOut.ID, sushi, groceries.ID)
+	ranked, err := l.Rank(sushi, categories, 3)
+	require.NoError(t, err)
+	require.Len(t, ranked, 2)
+	require.Equal(t, groceries.ID, ranked[0].CategoryID)
+	require.Equal(t, 1, history.loads)
+
+	// Reset and age both retrain from history.
+	l.Reset(userID)
+	_, err = l.Rank(in, categories, 3)
+	require.NoError(t, err)
+	require.Equal(t, 2, history.loads)
+
+	l.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = l.Rank(in, categories, 3)
+	require.NoError(t, err)
+	require.Equal(t, 3, history.loads)
+}
+
+func TestServiceUsesLearnerBeforeKeywords(t *testing.T) {
+	categories := testCategories()
+	history := &fakeHistory{operations: []domain.Operation{
+		{CategoryID: categories[1].ID, Name: "Groceries delivery fee"},
+	}}
+	s := New(nil, Options{Learner: NewLearner(history, LearnerOptions{})})
+
+	got, ok := s.Suggest(context.Background(), Input{UserID: uuid.New(), Name: "Delivery fee", Type: "expense"}, categories)
+	require.True(t, ok)
+	require.Equal(t, categories[1].ID, got.CategoryID)
+	require.Equal(t, SourceHistory, got.Source)
+
+	// Words the learner never saw leave it to the name match.
+	got, ok = s.Suggest(context.Background(), Input{UserID: uuid.New(), Name: "Salary", Type: "income"}, categories)
+	require.True(t, ok)
+	require.Equal(t, categories[2].ID, got.CategoryID)
+	require.Equal(t, SourceKeywords, got.Source)
+}
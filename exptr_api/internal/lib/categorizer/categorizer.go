@@ -0,0 +1,273 @@
+// Package categorizer suggests a category for an operation from its name,
+// comment and amount. A Service asks a language model and falls back to a
+// classifier trained on the user's history, then to matching category
+// names, when the model is missing, slow, down or unsure.
+package categorizer
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SourceModel    = "model"
+	SourceKeywords = "keywords"
+)
+
+// ErrNoMatch is returned by a Model that cannot pick any of the categories.
+var ErrNoMatch = errors.New("no matching category")
+
+// Input is what a suggestion is based on. Type narrows the candidates to
+// categories of the same type.
+type Input struct {
+	UserID   uuid.UUID
+	Name     string
+	Comment  string
+	Amount   int
+	Currency string
+	Type     string
+}
+
+func FromRequest(op *models.OperationRequest) Input {
+	return Input{
+		UserID:   op.UserID,
+		Name:     op.Name,
+		Comment:  op.Comment,
+		Amount:   op.Amount,
+		Currency: op.Currency,
+		Type:     op.Type,
+	}
+}
+
+// Model picks one of the categories for an operation.
+type Model interface {
+	Suggest(ctx context.Context, in Input, categories []domain.Category) (models.CategorySuggestion, error)
+}
+
+// Options of a Service. After a model failure the model is skipped for
+// Cooldown so a batch of operations does not wait for it again and again.
+// Suggestions below MinConfidence are only shown, never applied. Learner,
+// when set, answers before the category name match.
+type Options struct {
+	Learner       *Learner
+	MinConfidence float64
+	Timeout       time.Duration
+	CacheTTL      time.Duration
+	Cooldown      time.Duration
+	// MaxEntries bounds the cache; expired entries are dropped first.
+	MaxEntries int
+}
+
+type entry struct {
+	suggestion models.CategorySuggestion
+	ok         bool
+	expires    time.Time
+}
+
+// Service suggests categories with a model, caching its answers. It is
+// safe for concurrent use.
+type Service struct {
+	model    Model
+	fallback Model
+	opts     Options
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cache     map[string]entry
+	downUntil time.Time
+}
+
+// New returns a Service asking model, or only matching category names when
+// model is nil.
+func New(model Model, opts Options) *Service {
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 10000
+	}
+	return &Service{
+		model:    model,
+		fallback: Keywords{},
+		opts:     opts,
+		now:      time.Now,
+		cache:    make(map[string]entry),
+	}
+}
+
+// Suggest returns a suggestion for the operation, or false when neither the
+// model nor the fallback found a category. Model errors are not returned:
+// the fallback answers instead.
+func (s *Service) Suggest(ctx context.Context, in Input, categories []domain.Category) (models.CategorySuggestion, bool) {
+	candidates := Candidates(in, categories)
+	if len(candidates) == 0 {
+		return models.CategorySuggestion{}, false
+	}
+
+	if s.model != nil && s.available() && ctx.Err() == nil {
+		key := cacheKey(in, candidates)
+		e, cached := s.cached(key)
+		if cached && e.ok {
+			return e.suggestion, true
+		}
+
+		if !cached {
+			suggestion, err := s.ask(ctx, in, candidates)
+			switch {
+			case err == nil:
+				s.store(key, suggestion, true)
+				return suggestion, true
+			case errors.Is(err, ErrNoMatch):
+				s.store(key, suggestion, false)
+			case ctx.Err() == nil:
+				// The request is still alive, so the model itself failed.
+				s.markDown()
+			}
+		}
+	}
+
+	if s.opts.Learner != nil {
+		if suggestion, err := s.opts.Learner.Suggest(ctx, in, candidates); err == nil {
+			return suggestion, true
+		}
+	}
+
+	suggestion, err := s.fallback.Suggest(ctx, in, candidates)
+	if err != nil {
+		return models.CategorySuggestion{}, false
+	}
+	return suggestion, true
+}
+
+// Rank returns up to k categories learned from the user's history, most
+// probable first, or nothing without a Learner.
+func (s *Service) Rank(in Input, categories []domain.Category, k int) ([]models.CategorySuggestion, error) {
+	if s.opts.Learner == nil {
+		return nil, nil
+	}
+	return s.opts.Learner.Rank(in, categories, k)
+}
+
+// Learn tells the Learner, if any, about an operation the user categorized.
+func (s *Service) Learn(in Input, categoryID uuid.UUID) {
+	if s.opts.Learner != nil {
+		s.opts.Learner.Learn(in, categoryID)
+	}
+}
+
+// Recategorize tells the Learner, if any, that an operation changed.
+func (s *Service) Recategorize(old Input, oldCategoryID uuid.UUID, in Input, categoryID uuid.UUID) {
+	if s.opts.Learner != nil {
+		s.opts.Learner.Recategorize(old, oldCategoryID, in, categoryID)
+	}
+}
+
+// Reset makes the Learner, if any, retrain the user from history.
+func (s *Service) Reset(userID uuid.UUID) {
+	if s.opts.Learner != nil {
+		s.opts.Learner.Reset(userID)
+	}
+}
+
+// Confident reports whether a suggestion is sure enough to be applied
+// without asking the user.
+func (s *Service) Confident(suggestion models.CategorySuggestion) bool {
+	return suggestion.Confidence >= s.opts.MinConfidence
+}
+
+func (s *Service) ask(ctx context.Context, in Input, candidates []domain.Category) (models.CategorySuggestion, error) {
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	suggestion, err := s.model.Suggest(ctx, in, candidates)
+	if err != nil {
+		return suggestion, err
+	}
+	suggestion.Source = SourceModel
+	return suggestion, nil
+}
+
+func (s *Service) available() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.now().Before(s.downUntil)
+}
+
+func (s *Service) markDown() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.downUntil = s.now().Add(s.opts.Cooldown)
+}
+
+func (s *Service) cached(key string) (entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.cache[key]
+	if !ok || s.now().After(e.expires) {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func (s *Service) store(key string, suggestion models.CategorySuggestion, ok bool) {
+	if s.opts.CacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.cache) >= s.opts.MaxEntries {
+		for k, e := range s.cache {
+			if now.After(e.expires) {
+				delete(s.cache, k)
+			}
+		}
+		if len(s.cache) >= s.opts.MaxEntries {
+			s.cache = make(map[string]entry)
+		}
+	}
+	s.cache[key] = entry{suggestion: suggestion, ok: ok, expires: now.Add(s.opts.CacheTTL)}
+}
+
+// Candidates returns the categories an operation may go to: those of its
+// type, or all of them when the operation has no type.
+func Candidates(in Input, categories []domain.Category) []domain.Category {
+	if in.Type == "" {
+		return categories
+	}
+	var candidates []domain.Category
+	for _, c := range categories {
+		if c.Type == "" || c.Type == in.Type {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// cacheKey covers the operation and the candidate categories, so renaming
+// or adding a category invalidates earlier answers.
+func cacheKey(in Input, candidates []domain.Category) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s|%s|%d|%s|%s", normalize(in.Name), normalize(in.Comment), in.Amount, in.Currency, in.Type)
+	for _, c := range candidates {
+		fmt.Fprintf(h, "|%s=%s", c.ID, c.Name)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,104 @@
+package categorizer
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCachesAnswers(t *testing.T) {
+	categories := testCategories()
+	srv, calls := fakeOllama(t, http.StatusOK, `{"category": "Groceries", "confidence": 0.8}`, 0)
+	s := New(Ollama{URL: srv.URL, Client: srv.Client()}, Options{MinConfidence: 0.6, CacheTTL: time.Hour})
+
+	in := Input{Name: "Lidl", Amount: 1000, Currency: "EUR", Type: "expense"}
+	for i := 0; i < 3; i++ {
+		got, ok := s.Suggest(context.Background(), in, categories)
+		require.True(t, ok)
+		require.Equal(t, categories[0].ID, got.CategoryID)
+		require.Equal(t, SourceModel, got.Source)
+		require.True(t, s.Confident(got))
+	}
+	require.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	// Renaming a category invalidates the cached answer.
+	categories[1].Name = "Restaurants"
+	_, ok := s.Suggest(context.Background(), in, categories)
+	require.True(t, ok)
+	require.EqualValues(t, 2, atomic.LoadInt32(calls))
+
+	// Cached answers expire.
+	s.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	s.Suggest(context.Background(), in, categories)
+	require.EqualValues(t, 3, atomic.LoadInt32(calls))
+}
+
+func TestServiceFallsBackWhenModelIsDown(t *testing.T) {
+	categories := testCategories()
+	srv, calls := fakeOllama(t, http.StatusInternalServerError, "", 0)
+	s := New(Ollama{URL: srv.URL, Client: srv.Client()}, Options{MinConfidence: 0.6, Cooldown: time.Minute})
+
+	in := Input{Name: "Groceries Lidl", Type: "expense"}
+	got, ok := s.Suggest(context.Background(), in, categories)
+	require.True(t, ok)
+	require.Equal(t, categories[0].ID, got.CategoryID)
+	require.Equal(t, SourceKeywords, got.Source)
+	require.False(t, s.Confident(got))
+
+	// The model is not asked again during the cooldown.
+	s.Suggest(context.Background(), in, categories)
+	require.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	s.Suggest(context.Background(), in, categories)
+	require.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestServiceTimeout(t *testing.T) {
+	srv, _ := fakeOllama(t, http.StatusOK, `{"category": "Salary", "confidence": 1}`, time.Second)
+	s := New(Ollama{URL: srv.URL, Client: srv.Client()}, Options{Timeout: 20 * time.Millisecond, Cooldown: time.Minute})
+
+	start := time.Now()
+	_, ok := s.Suggest(context.Background(), Input{Name: "ACME payroll", Type: "income"}, testCategories())
+	require.False(t, ok)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+	require.False(t, s.available())
+}
+
+func TestServiceFallsBackOnNoMatch(t *testing.T) {
+	categories := testCategories()
+	srv, calls := fakeOllama(t, http.StatusOK, `{"category": "", "confidence": 0}`, 0)
+	s := New(Ollama{URL: srv.URL, Client: srv.Client()}, Options{CacheTTL: time.Hour, Cooldown: time.Minute})
+
+	in := Input{Name: "Salary March", Type: "income"}
+	for i := 0; i < 2; i++ {
+		got, ok := s.Suggest(context.Background(), in, categories)
+		require.True(t, ok)
+		require.Equal(t, categories[2].ID, got.CategoryID)
+		require.Equal(t, SourceKeywords, got.Source)
+	}
+	// Not a failure: the model stays available and its answer is cached.
+	require.True(t, s.available())
+	require.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestKeywords(t *testing.T) {
+	categories := testCategories()
+
+	got, err := Keywords{}.Suggest(context.Background(), Input{Comment: "Dinner, eating-out with Bob"}, categories)
+	require.NoError(t, err)
+	require.Equal(t, categories[1].ID, got.CategoryID)
+
+	_, err = Keywords{}.Suggest(context.Background(), Input{Name: "Grocer"}, categories)
+	require.ErrorIs(t, err, ErrNoMatch)
+}
+
+func TestCandidates(t *testing.T) {
+	categories := testCategories()
+	require.Len(t, Candidates(Input{Type: "expense"}, categories), 2)
+	require.Len(t, Candidates(Input{}, categories), 3)
+}
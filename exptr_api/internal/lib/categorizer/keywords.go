@@ -0,0 +1,52 @@
+package categorizer
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+)
+
+// keywordConfidence is deliberately low: a category name showing up in the
+// operation text is a hint, not a decision.
+const keywordConfidence = 0.5
+
+// Keywords suggests the category whose name appears as a whole word in the
+// operation name or comment, preferring the longest name. It needs no model
+// and is what a Service falls back to.
+type Keywords struct{}
+
+func (Keywords) Suggest(_ context.Context, in Input, categories []domain.Category) (models.CategorySuggestion, error) {
+	text := " " + strings.Join(words(in.Name+" "+in.Comment), " ") + " "
+
+	var best *domain.Category
+	var bestLen int
+	for i := range categories {
+		name := strings.Join(words(categories[i].Name), " ")
+		if name == "" || !strings.Contains(text, " "+name+" ") {
+			continue
+		}
+		if len(name) > bestLen {
+			best, bestLen = &categories[i], len(name)
+		}
+	}
+	if best == nil {
+		return models.CategorySuggestion{}, ErrNoMatch
+	}
+
+	return models.CategorySuggestion{
+		CategoryID: best.ID,
+		Name:       best.Name,
+		Confidence: keywordConfidence,
+		Source:     SourceKeywords,
+	}, nil
+}
+
+// words lowercases s and splits it on anything but letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
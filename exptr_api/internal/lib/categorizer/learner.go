@@ -0,0 +1,186 @@
+package categorizer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const SourceHistory = "history"
+
+// History loads the categorized operations a Learner trains on.
+type History interface {
+	GetTrainingOperations(userID uuid.UUID, limit int) ([]domain.Operation, error)
+}
+
+// LearnerOptions bound the memory a Learner uses. Classifiers are kept for
+// up to MaxUsers users and retrained from history after MaxAge, which also
+// picks up bulk changes such as merged categories.
+type LearnerOptions struct {
+	MaxUsers      int
+	MaxAge        time.Duration
+	TrainingLimit int
+}
+
+type trained struct {
+	classifier *Classifier
+	at         time.Time
+}
+
+// Learner keeps a naive Bayes classifier per user, trained on their own
+// categorized operations. It is a Model, so a Service can use it when no
+// language model is configured or the model cannot answer.
+type Learner struct {
+	history History
+	opts    LearnerOptions
+	now     func() time.Time
+
+	mu    sync.Mutex
+	users map[uuid.UUID]trained
+}
+
+func NewLearner(history History, opts LearnerOptions) *Learner {
+	if opts.MaxUsers <= 0 {
+		opts.MaxUsers = 1000
+	}
+	if opts.TrainingLimit <= 0 {
+		opts.TrainingLimit = 5000
+	}
+	return &Learner{
+		history: history,
+		opts:    opts,
+		now:     time.Now,
+		users:   make(map[uuid.UUID]trained),
+	}
+}
+
+// Suggest returns the most probable category, with its probability as the
+// confidence.
+func (l *Learner) Suggest(_ context.Context, in Input, categories []domain.Category) (models.CategorySuggestion, error) {
+	ranked, err := l.Rank(in, categories, 1)
+	if err != nil {
+		return models.CategorySuggestion{}, err
+	}
+	if len(ranked) == 0 {
+		return models.CategorySuggestion{}, ErrNoMatch
+	}
+	return ranked[0], nil
+}
+
+// Rank returns up to k of the categories, most probable first.
+func (l *Learner) Rank(in Input, categories []domain.Category, k int) ([]models.CategorySuggestion, error) {
+	const fn = "categorizer.Learner.Rank"
+
+	classifier, err := l.classifier(in.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	byID := make(map[uuid.UUID]domain.Category, len(categories))
+	ids := make([]uuid.UUID, 0, len(categories))
+	for _, c := range Candidates(in, categories) {
+		byID[c.ID] = c
+		ids = append(ids, c.ID)
+	}
+
+	predictions := classifier.Rank(Features(in), ids, k)
+	suggestions := make([]models.CategorySuggestion, 0, len(predictions))
+	for _, p := range predictions {
+		suggestions = append(suggestions, models.CategorySuggestion{
+			CategoryID: p.CategoryID,
+			Name:       byID[p.CategoryID].Name,
+			Confidence: p.Probability,
+			Source:     SourceHistory,
+		})
+	}
+	return suggestions, nil
+}
+
+// Learn adds an operation the user categorized. Users whose classifier is
+// not loaded are skipped: it will be trained from history, which includes
+// the operation.
+func (l *Learner) Learn(in Input, categoryID uuid.UUID) {
+	if c := l.loaded(in.UserID); c != nil && categoryID != uuid.Nil {
+		c.Learn(categoryID, Features(in))
+	}
+}
+
+// Recategorize replaces what was learned from an operation with its new
+// category and text.
+func (l *Learner) Recategorize(old Input, oldCategoryID uuid.UUID, in Input, categoryID uuid.UUID) {
+	c := l.loaded(in.UserID)
+	if c == nil {
+		return
+	}
+	if oldCategoryID != uuid.Nil {
+		c.Forget(oldCategoryID, Features(old))
+	}
+	if categoryID != uuid.Nil {
+		c.Learn(categoryID, Features(in))
+	}
+}
+
+// Reset drops the user's classifier so it is retrained from history on
+// next use, after changes too broad to apply one by one.
+func (l *Learner) Reset(userID uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.users, userID)
+}
+
+func (l *Learner) loaded(userID uuid.UUID) *Classifier {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.users[userID]
+	if !ok || l.expired(t) {
+		return nil
+	}
+	return t.classifier
+}
+
+func (l *Learner) expired(t trained) bool {
+	return l.opts.MaxAge > 0 && l.now().Sub(t.at) > l.opts.MaxAge
+}
+
+// classifier returns the user's classifier, training it from history when
+// it is missing or too old. Training happens outside the lock so one slow
+// user does not hold up the others.
+func (l *Learner) classifier(userID uuid.UUID) (*Classifier, error) {
+	if c := l.loaded(userID); c != nil {
+		return c, nil
+	}
+
+	operations, err := l.history.GetTrainingOperations(userID, l.opts.TrainingLimit)
+	if err != nil {
+		return nil, err
+	}
+	c := NewClassifier()
+	for i := range operations {
+		op := &operations[i]
+		c.Learn(op.CategoryID, Features(Input{Name: op.Name, Comment: op.Comment}))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.users) >= l.opts.MaxUsers {
+		for id, t := range l.users {
+			if l.expired(t) {
+				delete(l.users, id)
+			}
+		}
+		if len(l.users) >= l.opts.MaxUsers {
+			for id := range l.users {
+				delete(l.users, id)
+				break
+			}
+		}
+	}
+	l.users[userID] = trained{classifier: c, at: l.now()}
+	return c, nil
+}
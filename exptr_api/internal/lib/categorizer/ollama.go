@@ -0,0 +1,181 @@
+package categorizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/models"
+)
+
+const (
+	OllamaDefaultURL   = "http://localhost:11434"
+	OllamaDefaultModel = "llama2"
+)
+
+// Ollama asks a model served by Ollama to pick a category, using the
+// /api/generate endpoint in JSON mode.
+type Ollama struct {
+	URL    string
+	Model  string
+	Client *http.Client
+}
+
+type ollamaRequest struct {
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Format  string         `json:"format"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+type ollamaResponse struct {
+	Response string `json:"response"`
+	Error    string `json:"error"`
+}
+
+// ollamaAnswer is the JSON the prompt asks the model to reply with.
+type ollamaAnswer struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+func (o Ollama) Suggest(ctx context.Context, in Input, categories []domain.Category) (models.CategorySuggestion, error) {
+	const fn = "categorizer.Ollama.Suggest"
+
+	url := o.URL
+	if url == "" {
+		url = OllamaDefaultURL
+	}
+	model := o.Model
+	if model == "" {
+		model = OllamaDefaultModel
+	}
+	client := o.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	body, err := json.Marshal(ollamaRequest{
+		Model:   model,
+		Prompt:  prompt(in, categories),
+		Format:  "json",
+		Options: map[string]any{"temperature": 0},
+	})
+	if err != nil {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(url, "/")+"/api/generate", bytes.NewReader(body))
+	if err != nil {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer resp.Body.Close()
+
+	var out ollamaResponse
+	if resp.StatusCode != http.StatusOK {
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return models.CategorySuggestion{}, fmt.Errorf("%s: unexpected status %d %s", fn, resp.StatusCode, out.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var answer ollamaAnswer
+	if err := json.Unmarshal([]byte(out.Response), &answer); err != nil {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: invalid answer %q: %w", fn, out.Response, err)
+	}
+
+	category, ok := pick(answer.Category, categories)
+	if !ok {
+		return models.CategorySuggestion{}, fmt.Errorf("%s: %q: %w", fn, answer.Category, ErrNoMatch)
+	}
+
+	return models.CategorySuggestion{
+		CategoryID: category.ID,
+		Name:       category.Name,
+		Confidence: clamp(answer.Confidence),
+	}, nil
+}
+
+func prompt(in Input, categories []domain.Category) string {
+	var b strings.Builder
+	b.WriteString("You categorize personal finance transactions. ")
+	b.WriteString("Pick exactly one category from the list for the transaction below. ")
+	b.WriteString(`Reply with JSON only: {"category": "<category name from the list>", "confidence": <number from 0 to 1>}. `)
+	b.WriteString(`If none of the categories fits, reply {"category": "", "confidence": 0}.`)
+
+	b.WriteString("\n\nCategories:\n")
+	for _, c := range categories {
+		b.WriteString("- ")
+		b.WriteString(c.Name)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\nTransaction:\n")
+	fmt.Fprintf(&b, "Name: %s\n", in.Name)
+	if in.Comment != "" {
+		fmt.Fprintf(&b, "Comment: %s\n", in.Comment)
+	}
+	if in.Amount != 0 {
+		amount := strconv.FormatFloat(currency.ToMajor(in.Amount, in.Currency), 'f', currency.Exponent(in.Currency), 64)
+		fmt.Fprintf(&b, "Amount: %s %s\n", amount, in.Currency)
+	}
+	if in.Type != "" {
+		fmt.Fprintf(&b, "Type: %s\n", in.Type)
+	}
+
+	return b.String()
+}
+
+// pick finds the category the model named. Models tend to add quotes,
+// change case or drop a suffix, so an exact match is tried before a
+// unique prefix.
+func pick(name string, categories []domain.Category) (domain.Category, bool) {
+	name = normalize(strings.Trim(name, ` "'.`))
+	if name == "" {
+		return domain.Category{}, false
+	}
+
+	for _, c := range categories {
+		if normalize(c.Name) == name {
+			return c, true
+		}
+	}
+
+	var found []domain.Category
+	for _, c := range categories {
+		if strings.HasPrefix(normalize(c.Name), name) {
+			found = append(found, c)
+		}
+	}
+	if len(found) == 1 {
+		return found[0], true
+	}
+
+	return domain.Category{}, false
+}
+
+func clamp(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
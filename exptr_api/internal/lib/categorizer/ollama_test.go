@@ -0,0 +1,111 @@
+package categorizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOllama answers /api/generate with answer and counts the calls.
+func fakeOllama(t *testing.T, status int, answer string, delay time.Duration) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		require.Equal(t, "/api/generate", r.URL.Path)
+
+		var req ollamaRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Equal(t, "llama2", req.Model)
+		require.Equal(t, "json", req.Format)
+		require.False(t, req.Stream)
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.WriteHeader(status)
+		if status != http.StatusOK {
+			json.NewEncoder(w).Encode(map[string]string{"error": "model not found"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"model": "llama2", "response": answer, "done": true})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testCategories() []domain.Category {
+	return []domain.Category{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Groceries", Type: "expense"},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Eating out", Type: "expense"},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Salary", Type: "income"},
+	}
+}
+
+func TestOllamaSuggest(t *testing.T) {
+	categories := testCategories()
+	srv, _ := fakeOllama(t, http.StatusOK, `{"category": "groceries", "confidence": 0.9}`, 0)
+
+	got, err := Ollama{URL: srv.URL, Client: srv.Client()}.Suggest(context.Background(),
+		Input{Name: "LIDL 1234", Amount: 2350, Currency: "EUR", Type: "expense"}, categories)
+	require.NoError(t, err)
+	require.Equal(t, categories[0].ID, got.CategoryID)
+	require.Equal(t, "Groceries", got.Name)
+	require.Equal(t, 0.9, got.Confidence)
+}
+
+func TestOllamaSuggestErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		answer  string
+		noMatch bool
+	}{
+		{"bad status", http.StatusNotFound, "", false},
+		{"not json", http.StatusOK, "Groceries, probably", false},
+		{"unknown category", http.StatusOK, `{"category": "Travel", "confidence": 0.8}`, true},
+		{"no category", http.StatusOK, `{"category": "", "confidence": 0}`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, _ := fakeOllama(t, tc.status, tc.answer, 0)
+			_, err := Ollama{URL: srv.URL, Client: srv.Client()}.Suggest(context.Background(), Input{Name: "x"}, testCategories())
+			require.Error(t, err)
+			require.Equal(t, tc.noMatch, errors.Is(err, ErrNoMatch))
+		})
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	p := prompt(Input{Name: "Uber", Comment: "trip home", Amount: 1234, Currency: "EUR", Type: "expense"}, testCategories()[:2])
+	require.Contains(t, p, "- Groceries\n- Eating out\n")
+	require.Contains(t, p, "Name: Uber\nComment: trip home\nAmount: 12.34 EUR\nType: expense\n")
+}
+
+func TestPick(t *testing.T) {
+	categories := testCategories()
+
+	got, ok := pick(`"Eating out."`, categories)
+	require.True(t, ok)
+	require.Equal(t, categories[1].ID, got.ID)
+
+	got, ok = pick("eat", categories)
+	require.True(t, ok)
+	require.Equal(t, categories[1].ID, got.ID)
+
+	_, ok = pick("", categories)
+	require.False(t, ok)
+}
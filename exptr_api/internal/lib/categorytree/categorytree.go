@@ -0,0 +1,136 @@
+// Package categorytree arranges categories linked by ParentID into a forest.
+// A category whose parent is missing, for example because it was deleted,
+// is treated as a root, and a broken chain that loops is cut so every walk
+// terminates.
+package categorytree
+
+import (
+	"sort"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Node is a category with its subcategories, sorted by name.
+type Node struct {
+	domain.Category
+	Children []*Node `json:"children"`
+}
+
+type Tree struct {
+	byID     map[uuid.UUID]domain.Category
+	parents  map[uuid.UUID]uuid.UUID
+	children map[uuid.UUID][]uuid.UUID
+	roots    []uuid.UUID
+}
+
+func New(categories []domain.Category) *Tree {
+	t := &Tree{
+		byID:     make(map[uuid.UUID]domain.Category, len(categories)),
+		parents:  make(map[uuid.UUID]uuid.UUID, len(categories)),
+		children: make(map[uuid.UUID][]uuid.UUID),
+	}
+	for _, c := range categories {
+		t.byID[c.ID] = c
+	}
+
+	sorted := make([]domain.Category, len(categories))
+	copy(sorted, categories)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[uuid.UUID]int, len(categories))
+	var visit func(id uuid.UUID)
+	visit = func(id uuid.UUID) {
+		state[id] = visiting
+		if parent := t.byID[id].ParentID; parent != nil {
+			if _, ok := t.byID[*parent]; ok && state[*parent] != visiting {
+				if state[*parent] == 0 {
+					visit(*parent)
+				}
+				t.parents[id] = *parent
+			}
+		}
+		state[id] = visited
+	}
+
+	for _, c := range sorted {
+		if state[c.ID] == 0 {
+			visit(c.ID)
+		}
+	}
+	for _, c := range sorted {
+		if parent, ok := t.parents[c.ID]; ok {
+			t.children[parent] = append(t.children[parent], c.ID)
+		} else {
+			t.roots = append(t.roots, c.ID)
+		}
+	}
+
+	return t
+}
+
+// Nodes returns the forest of categories.
+func (t *Tree) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(t.roots))
+	for _, id := range t.roots {
+		nodes = append(nodes, t.node(id))
+	}
+	return nodes
+}
+
+func (t *Tree) node(id uuid.UUID) *Node {
+	n := &Node{Category: t.byID[id], Children: []*Node{}}
+	for _, child := range t.children[id] {
+		n.Children = append(n.Children, t.node(child))
+	}
+	return n
+}
+
+// Subtree returns id followed by all of its descendants, parents before children.
+func (t *Tree) Subtree(id uuid.UUID) []uuid.UUID {
+	ids := []uuid.UUID{id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, t.children[ids[i]]...)
+	}
+	return ids
+}
+
+// Contains reports whether candidate is id or one of its descendants.
+func (t *Tree) Contains(id, candidate uuid.UUID) bool {
+	for _, sub := range t.Subtree(id) {
+		if sub == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// Ancestors returns the parents of id, nearest first.
+func (t *Tree) Ancestors(id uuid.UUID) []uuid.UUID {
+	var ids []uuid.UUID
+	for {
+		parent, ok := t.parents[id]
+		if !ok {
+			return ids
+		}
+		ids = append(ids, parent)
+		id = parent
+	}
+}
+
+// Sorted returns the categories ordered so every parent comes before its
+// children, which is the order they can be inserted in.
+func (t *Tree) Sorted() []domain.Category {
+	list := make([]domain.Category, 0, len(t.byID))
+	for _, root := range t.roots {
+		for _, id := range t.Subtree(root) {
+			list = append(list, t.byID[id])
+		}
+	}
+	return list
+}
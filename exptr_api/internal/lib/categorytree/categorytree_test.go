@@ -0,0 +1,66 @@
+package categorytree
+
+import (
+	"testing"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func category(name string, parent *domain.Category) domain.Category {
+	c := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: name}
+	if parent != nil {
+		c.ParentID = &parent.ID
+	}
+	return c
+}
+
+func TestTree(t *testing.T) {
+	food := category("Food", nil)
+	restaurants := category("Restaurants", &food)
+	coffee := category("Coffee", &restaurants)
+	groceries := category("Groceries", &food)
+	salary := category("Salary", nil)
+	orphan := category("Orphan", &domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}})
+
+	tree := New([]domain.Category{coffee, salary, restaurants, food, orphan, groceries})
+
+	nodes := tree.Nodes()
+	require.Len(t, nodes, 3)
+	require.Equal(t, "Food", nodes[0].Name)
+	require.Equal(t, "Orphan", nodes[1].Name)
+	require.Equal(t, "Salary", nodes[2].Name)
+	require.Len(t, nodes[0].Children, 2)
+	require.Equal(t, "Groceries", nodes[0].Children[0].Name)
+	require.Equal(t, "Coffee", nodes[0].Children[1].Children[0].Name)
+
+	require.ElementsMatch(t, []uuid.UUID{food.ID, restaurants.ID, groceries.ID, coffee.ID}, tree.Subtree(food.ID))
+	require.Equal(t, []uuid.UUID{restaurants.ID, food.ID}, tree.Ancestors(coffee.ID))
+	require.True(t, tree.Contains(food.ID, coffee.ID))
+	require.False(t, tree.Contains(coffee.ID, food.ID))
+
+	sorted := tree.Sorted()
+	position := make(map[uuid.UUID]int)
+	for i, c := range sorted {
+		position[c.ID] = i
+	}
+	require.Len(t, sorted, 6)
+	require.Less(t, position[food.ID], position[restaurants.ID])
+	require.Less(t, position[restaurants.ID], position[coffee.ID])
+}
+
+func TestTreeCutsLoops(t *testing.T) {
+	a := category("A", nil)
+	b := category("B", &a)
+	a.ParentID = &b.ID
+
+	tree := New([]domain.Category{a, b})
+
+	nodes := tree.Nodes()
+	require.Len(t, nodes, 1)
+	require.Len(t, nodes[0].Children, 1)
+	require.Len(t, tree.Sorted(), 2)
+	require.Equal(t, 1, len(tree.Ancestors(a.ID))+len(tree.Ancestors(b.ID)))
+}
@@ -1,11 +1,23 @@
 package crons
 
 import (
+	"alex_gorbunov_exptr_api/internal/lib/attachment"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
 	"alex_gorbunov_exptr_api/internal/storage/postgres"
+	"context"
 	"log/slog"
+	"sync"
+	"time"
 )
 
+// retentionMu keeps a slow purge from overlapping with the next scheduled one.
+var retentionMu sync.Mutex
+
+// sweepMu does the same for the attachment sweep.
+var sweepMu sync.Mutex
+
 func DeleteOutdatedSessions(storage *postgres.Storage, log *slog.Logger) {
 	const op = "cron.DeleteOutdatedSessions"
 
@@ -17,3 +29,114 @@ func DeleteOutdatedSessions(storage *postgres.Storage, log *slog.Logger) {
 		log.Error("failed to delete outdated sessions", sl.Error(err))
 	}
 }
+
+func RefreshExchangeRates(storage *postgres.Storage, provider rates.Provider, log *slog.Logger) {
+	const op = "cron.RefreshExchangeRates"
+
+	log = log.With(slog.String("op", op))
+
+	log.Info("refreshing exchange rates")
+	fetched, err := provider.Fetch(context.Background())
+	if err != nil {
+		log.Error("failed to fetch exchange rates", sl.Error(err))
+		return
+	}
+
+	if err := storage.SaveExchangeRates(fetched); err != nil {
+		log.Error("failed to save exchange rates", sl.Error(err))
+		return
+	}
+
+	log.Info("exchange rates refreshed", slog.Int("count", len(fetched)))
+}
+
+// PurgeDeletedAccounts hard-deletes accounts whose deletion grace period
+// ended, along with their attached files.
+func PurgeDeletedAccounts(storage *postgres.Storage, blobs blob.Store, log *slog.Logger) {
+	const op = "cron.PurgeDeletedAccounts"
+
+	log = log.With(slog.String("op", op))
+
+	now := time.Now()
+	ids, err := storage.GetAccountsDueForDeletion(now)
+	if err != nil {
+		log.Error("failed to get accounts due for deletion", sl.Error(err))
+		return
+	}
+
+	for _, id := range ids {
+		tombstone, err := storage.PurgeAccount(id, now)
+		if err != nil {
+			log.Error("failed to purge account", slog.String("user_id", id.String()), sl.Error(err))
+			continue
+		}
+
+		files, err := attachment.Purge(context.Background(), blobs, id)
+		if err != nil {
+			// The sweep picks up whatever is left.
+			log.Error("failed to purge attachments", slog.String("user_id", id.String()), sl.Error(err))
+		}
+
+		log.Info("account purged",
+			slog.String("user_id", id.String()),
+			slog.Int("operations", tombstone.Operations),
+			slog.Int("categories", tombstone.Categories),
+			slog.Int("import_profiles", tombstone.ImportProfiles),
+			slog.Int("sessions", tombstone.Sessions),
+			slog.Int("files", files),
+		)
+	}
+}
+
+// PurgeSoftDeleted hard-deletes rows that were soft-deleted longer than
+// retention ago. A run is skipped while the previous one is still going.
+func PurgeSoftDeleted(storage *postgres.Storage, retention time.Duration, batchSize int, log *slog.Logger) {
+	const op = "cron.PurgeSoftDeleted"
+
+	log = log.With(slog.String("op", op))
+
+	if !retentionMu.TryLock() {
+		log.Warn("previous purge is still running, skipping")
+		return
+	}
+	defer retentionMu.Unlock()
+
+	log.Info("purging soft-deleted rows")
+	start := time.Now()
+	purged, err := storage.PurgeSoftDeleted(start.Add(-retention), batchSize)
+
+	var total int64
+	for _, p := range purged {
+		total += p.Rows
+		log.Info("table purged", slog.String("table", p.Table), slog.Int64("rows", p.Rows))
+	}
+	if err != nil {
+		log.Error("failed to purge soft-deleted rows", sl.Error(err))
+		return
+	}
+
+	log.Info("soft-deleted rows purged", slog.Int64("rows", total), slog.Duration("took", time.Since(start)))
+}
+
+// SweepAttachments deletes attached files no attachment refers to any more,
+// such as those of purged operations, once they are older than grace.
+func SweepAttachments(storage *postgres.Storage, blobs blob.Store, grace time.Duration, log *slog.Logger) {
+	const op = "cron.SweepAttachments"
+
+	log = log.With(slog.String("op", op))
+
+	if !sweepMu.TryLock() {
+		log.Warn("previous sweep is still running, skipping")
+		return
+	}
+	defer sweepMu.Unlock()
+
+	log.Info("sweeping attachments")
+	deleted, err := attachment.Sweep(context.Background(), blobs, storage, time.Now().Add(-grace))
+	if err != nil {
+		log.Error("failed to sweep attachments", slog.Int("deleted", deleted), sl.Error(err))
+		return
+	}
+
+	log.Info("attachments swept", slog.Int("deleted", deleted))
+}
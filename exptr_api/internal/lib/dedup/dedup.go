@@ -0,0 +1,295 @@
+// Package dedup finds operations that are probably recorded twice, such as a
+// purchase entered by hand and later imported from a bank statement, or the
+// same entry imported from two overlapping statements.
+package dedup
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultWindow    = 3 * 24 * time.Hour
+	DefaultThreshold = 0.75
+)
+
+// Reasons reported for a suspected pair.
+const (
+	ReasonReference  = "same bank reference"
+	ReasonAmount     = "same amount"
+	ReasonNearAmount = "similar amount"
+	ReasonSameDay    = "same day"
+	ReasonNearDate   = "close dates"
+	ReasonName       = "similar name"
+)
+
+// Weights of the signals that make up a score. Matching bank references
+// settle the question on their own.
+const (
+	weightAmount = 0.4
+	weightDate   = 0.3
+	weightName   = 0.3
+)
+
+// amountTolerance is the relative difference still treated as the same
+// amount, e.g. a card hold that settled a few cents off.
+const amountTolerance = 0.01
+
+// Key identifies an unordered pair of operations.
+type Key [2]uuid.UUID
+
+// PairKey returns the same key for (a, b) and (b, a).
+func PairKey(a, b uuid.UUID) Key {
+	if strings.Compare(a.String(), b.String()) > 0 {
+		a, b = b, a
+	}
+	return Key{a, b}
+}
+
+// Pair is a suspected duplicate. Keep is the operation suggested to survive
+// a merge: the one carrying a bank reference, or the older one.
+type Pair struct {
+	Keep    domain.Operation `json:"keep"`
+	Drop    domain.Operation `json:"drop"`
+	Score   float64          `json:"score"`
+	Reasons []string         `json:"reasons"`
+}
+
+// Options tune the search. Window is the largest date distance between two
+// duplicates and Threshold the lowest score reported. Dismissed pairs are
+// never reported again.
+type Options struct {
+	Window    time.Duration
+	Threshold float64
+	Dismissed map[Key]bool
+}
+
+func (o Options) withDefaults() Options {
+	if o.Window <= 0 {
+		o.Window = DefaultWindow
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultThreshold
+	}
+	return o
+}
+
+// Score rates how likely a and b are the same operation, from 0 to 1.
+// Operations of different type or currency, far apart in time, with clearly
+// different amounts or with different bank references never match; the same
+// reference and amount is a certain match.
+func Score(a, b domain.Operation, window time.Duration) (float64, []string) {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if a.Type != b.Type || !strings.EqualFold(a.Currency, b.Currency) {
+		return 0, nil
+	}
+
+	if refA, refB := reference(a.ExternalID), reference(b.ExternalID); refA != "" && refB != "" {
+		if refA != refB {
+			return 0, nil
+		}
+		if a.Amount == b.Amount {
+			return 1, []string{ReasonReference, ReasonAmount}
+		}
+	}
+
+	var score float64
+	var reasons []string
+
+	switch {
+	case a.Amount == b.Amount:
+		score += weightAmount
+		reasons = append(reasons, ReasonAmount)
+	case nearAmount(a.Amount, b.Amount):
+		score += weightAmount * 0.75
+		reasons = append(reasons, ReasonNearAmount)
+	default:
+		return 0, nil
+	}
+
+	days := math.Abs(day(a.CreatedAt).Sub(day(b.CreatedAt)).Hours() / 24)
+	span := window.Hours() / 24
+	if days > span {
+		return 0, nil
+	}
+	if days == 0 {
+		reasons = append(reasons, ReasonSameDay)
+	} else {
+		reasons = append(reasons, ReasonNearDate)
+	}
+	score += weightDate * (1 - days/(span+1))
+
+	if sim := NameSimilarity(a.Name, b.Name); sim > 0 {
+		score += weightName * sim
+		if sim >= 0.5 {
+			reasons = append(reasons, ReasonName)
+		}
+	}
+
+	return math.Round(score*100) / 100, reasons
+}
+
+// Find returns the suspected duplicate pairs among ops, best first.
+func Find(ops []domain.Operation, opts Options) []Pair {
+	opts = opts.withDefaults()
+
+	sorted := make([]domain.Operation, len(ops))
+	copy(sorted, ops)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	var pairs []Pair
+	for i := range sorted {
+		for j := i + 1; j < len(sorted); j++ {
+			if day(sorted[j].CreatedAt).Sub(day(sorted[i].CreatedAt)) > opts.Window {
+				break
+			}
+			if pair, ok := match(sorted[i], sorted[j], opts); ok {
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Score > pairs[j].Score
+	})
+
+	return pairs
+}
+
+// Best returns the existing operation that op most likely duplicates.
+func Best(op domain.Operation, existing []domain.Operation, opts Options) (Pair, bool) {
+	opts = opts.withDefaults()
+
+	var best Pair
+	var found bool
+	for _, other := range existing {
+		if other.ID == op.ID {
+			continue
+		}
+		if pair, ok := match(other, op, opts); ok && (!found || pair.Score > best.Score) {
+			best, found = pair, true
+		}
+	}
+
+	return best, found
+}
+
+func match(a, b domain.Operation, opts Options) (Pair, bool) {
+	if opts.Dismissed[PairKey(a.ID, b.ID)] {
+		return Pair{}, false
+	}
+
+	score, reasons := Score(a, b, opts.Window)
+	if score < opts.Threshold {
+		return Pair{}, false
+	}
+
+	keep, drop := a, b
+	if reference(keep.ExternalID) == "" && reference(drop.ExternalID) != "" {
+		keep, drop = drop, keep
+	}
+
+	return Pair{Keep: keep, Drop: drop, Score: score, Reasons: reasons}, true
+}
+
+// NameSimilarity compares two payee names by their words after
+// normalization, from 0 (nothing in common) to 1 (same words).
+func NameSimilarity(a, b string) float64 {
+	wa, wb := words(a), words(b)
+	if len(wa) == 0 || len(wb) == 0 {
+		return 0
+	}
+
+	na, nb := strings.Join(wa, " "), strings.Join(wb, " ")
+	if na == nb {
+		return 1
+	}
+	if strings.Contains(na, nb) || strings.Contains(nb, na) {
+		return 0.8
+	}
+
+	set := make(map[string]bool, len(wa))
+	for _, w := range wa {
+		set[w] = true
+	}
+	var common int
+	union := len(set)
+	for _, w := range unique(wb) {
+		if set[w] {
+			common++
+		} else {
+			union++
+		}
+	}
+
+	return float64(common) / float64(union)
+}
+
+// Normalize lowercases a payee name and drops digits, punctuation and
+// words too short to tell payees apart, so "LIDL SAGT DANKE 1234" and
+// "Lidl sagt danke" compare equal.
+func Normalize(name string) string {
+	return strings.Join(words(name), " ")
+}
+
+func words(name string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	result := fields[:0]
+	for _, f := range fields {
+		if len([]rune(f)) > 1 {
+			result = append(result, f)
+		}
+	}
+
+	return result
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := values[:0:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// reference strips the source prefix of an external id, so the same bank
+// reference read from CAMT.053 and MT940 compares equal. Fingerprints are
+// not references.
+func reference(externalID string) string {
+	if externalID == "" || strings.HasPrefix(externalID, "fp:") {
+		return ""
+	}
+	if i := strings.IndexByte(externalID, ':'); i >= 0 {
+		return externalID[i+1:]
+	}
+	return externalID
+}
+
+func nearAmount(a, b int) bool {
+	diff := math.Abs(float64(a - b))
+	largest := math.Max(math.Abs(float64(a)), math.Abs(float64(b)))
+	return largest > 0 && diff/largest <= amountTolerance
+}
+
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,98 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func operation(name string, amount int, date string, externalID string) domain.Operation {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return domain.Operation{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: t},
+		Name:       name,
+		Amount:     amount,
+		Currency:   "EUR",
+		Type:       "expense",
+		ExternalID: externalID,
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  domain.Operation
+		match bool
+	}{
+		{
+			name:  "manual entry and imported row",
+			a:     operation("Lidl", 1250, "2024-01-02", ""),
+			b:     operation("LIDL SAGT DANKE 4711", 1250, "2024-01-03", "camt:2024010200001"),
+			match: true,
+		},
+		{
+			name:  "same reference from two formats",
+			a:     operation("Lidl", 1250, "2024-01-02", "camt:2024010200001"),
+			b:     operation("LIDL SAGT DANKE", 1250, "2024-01-02", "mt940:2024010200001"),
+			match: true,
+		},
+		{
+			name:  "different references",
+			a:     operation("Coffee", 350, "2024-01-02", "ofx:1"),
+			b:     operation("Coffee", 350, "2024-01-02", "ofx:2"),
+			match: false,
+		},
+		{
+			name:  "same amount same day other payee",
+			a:     operation("Coffee", 350, "2024-01-02", ""),
+			b:     operation("Bakery", 350, "2024-01-02", ""),
+			match: false,
+		},
+		{
+			name:  "settled a few cents off",
+			a:     operation("Shell fuel", 6000, "2024-01-02", ""),
+			b:     operation("SHELL 0815", 6012, "2024-01-02", "fp:abc"),
+			match: true,
+		},
+		{
+			name:  "outside the window",
+			a:     operation("Netflix", 1299, "2024-01-02", ""),
+			b:     operation("Netflix", 1299, "2024-02-02", ""),
+			match: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, _ := Score(tt.a, tt.b, DefaultWindow)
+			require.Equal(t, tt.match, score >= DefaultThreshold, "score %.2f", score)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	manual := operation("Lidl", 1250, "2024-01-02", "")
+	imported := operation("LIDL SAGT DANKE", 1250, "2024-01-02", "camt:1")
+	other := operation("Rent", 90000, "2024-01-01", "camt:2")
+
+	pairs := Find([]domain.Operation{manual, other, imported}, Options{})
+	require.Len(t, pairs, 1)
+	require.Equal(t, imported.ID, pairs[0].Keep.ID, "the imported operation is kept")
+	require.Equal(t, manual.ID, pairs[0].Drop.ID)
+	require.Contains(t, pairs[0].Reasons, ReasonSameDay)
+
+	dismissed := map[Key]bool{PairKey(imported.ID, manual.ID): true}
+	require.Empty(t, Find([]domain.Operation{manual, imported}, Options{Dismissed: dismissed}))
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "lidl sagt danke", Normalize("LIDL SAGT DANKE 4711 / B"))
+	require.Equal(t, 1.0, NameSimilarity("Amazon.de*Marketplace", "AMAZON DE MARKETPLACE"))
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+)
+
+type csvWriter struct {
+	w    *csv.Writer
+	opts Options
+	rows int
+}
+
+// flushEvery bounds how many rows wait in the buffer before they are sent.
+const flushEvery = 500
+
+func newCSVWriter(w io.Writer, opts Options) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w), opts: opts}
+	if opts.Locale.Delimiter != 0 {
+		cw.w.Comma = opts.Locale.Delimiter
+	}
+	if err := cw.w.Write(titles(opts.Columns)); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write adds one record per split, so split operations show every category.
+func (cw *csvWriter) Write(op *domain.Operation) error {
+	for _, l := range lines(op) {
+		if err := cw.write(cells(op, l, cw.opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cw *csvWriter) write(row []cell) error {
+	record := make([]string, len(row))
+	for i, c := range row {
+		switch c.kind {
+		case kindText:
+			record[i] = c.text
+		case kindDate:
+			record[i] = c.date.Format(cw.opts.Locale.DateLayout)
+		case kindAmount:
+			record[i] = cw.opts.Locale.FormatAmount(c.amount, c.exponent)
+		}
+	}
+
+	if err := cw.w.Write(record); err != nil {
+		return err
+	}
+
+	cw.rows++
+	if cw.rows%flushEvery == 0 {
+		cw.w.Flush()
+		return cw.w.Error()
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
@@ -0,0 +1,227 @@
+// Package export writes operations as CSV or XLSX spreadsheets and as
+// ledger, hledger or beancount journals. Writers take one operation at a
+// time so large exports are streamed straight from the database to the
+// client.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+const (
+	ColumnDate            = "date"
+	ColumnType            = "type"
+	ColumnName            = "name"
+	ColumnCategory        = "category"
+	ColumnAmount          = "amount"
+	ColumnCurrency        = "currency"
+	ColumnSettledAmount   = "settled_amount"
+	ColumnSettledCurrency = "settled_currency"
+	ColumnAccount         = "account"
+	ColumnComment         = "comment"
+	ColumnExternalID      = "external_id"
+)
+
+var DefaultColumns = []string{
+	ColumnDate, ColumnType, ColumnName, ColumnCategory, ColumnAmount, ColumnCurrency, ColumnComment,
+}
+
+var columnTitles = map[string]string{
+	ColumnDate:            "Date",
+	ColumnType:            "Type",
+	ColumnName:            "Name",
+	ColumnCategory:        "Category",
+	ColumnAmount:          "Amount",
+	ColumnCurrency:        "Currency",
+	ColumnSettledAmount:   "Settled amount",
+	ColumnSettledCurrency: "Settled currency",
+	ColumnAccount:         "Account",
+	ColumnComment:         "Comment",
+	ColumnExternalID:      "External ID",
+}
+
+// Options describe the spreadsheet. Columns are written in the given order
+// and Categories resolves category ids to names.
+type Options struct {
+	Columns    []string
+	Locale     Locale
+	Categories map[uuid.UUID]string
+}
+
+// Writer writes operations as spreadsheet rows. Close must be called to
+// finish the file.
+type Writer interface {
+	Write(op *domain.Operation) error
+	Close() error
+}
+
+// NewWriter starts a spreadsheet in the format and writes its header row.
+func NewWriter(w io.Writer, format string, opts Options) (Writer, error) {
+	if len(opts.Columns) == 0 {
+		opts.Columns = DefaultColumns
+	}
+	if opts.Locale.DateLayout == "" {
+		opts.Locale = DefaultLocale
+	}
+	for _, col := range opts.Columns {
+		if _, ok := columnTitles[col]; !ok {
+			return nil, fmt.Errorf("unknown column %q", col)
+		}
+	}
+
+	switch format {
+	case FormatCSV, "":
+		return newCSVWriter(w, opts)
+	case FormatXLSX:
+		return newXLSXWriter(w, opts)
+	case FormatLedger, FormatHLedger:
+		return newJournalWriter(w, opts, false), nil
+	case FormatBeancount:
+		return newJournalWriter(w, opts, true), nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of the format.
+func ContentType(format string) string {
+	switch format {
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatLedger, FormatHLedger, FormatBeancount:
+		return "text/plain; charset=utf-8"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the usual file extension of the format.
+func Extension(format string) string {
+	if format == FormatHLedger {
+		return "journal"
+	}
+	return format
+}
+
+// ParseColumns splits a comma separated column list.
+func ParseColumns(value string) []string {
+	var columns []string
+	for _, col := range strings.Split(value, ",") {
+		if col = strings.TrimSpace(strings.ToLower(col)); col != "" {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// cell is a typed spreadsheet value. Amounts stay in minor units together
+// with the currency exponent so they can be formatted without rounding.
+type cell struct {
+	text     string
+	date     time.Time
+	amount   int
+	exponent int
+	kind     int
+}
+
+const (
+	kindText = iota
+	kindDate
+	kindAmount
+	kindEmpty
+)
+
+// line is the part of an operation booked on one category: the whole
+// operation, or one of its splits. settled is the line's share of the
+// settled amount.
+type line struct {
+	categoryID uuid.UUID
+	amount     int
+	settled    int
+	note       string
+}
+
+// lines expands a split operation into one line per split. The settled
+// amount is shared in proportion to the split amounts, with the rounding
+// remainder on the last split so the shares add up.
+func lines(op *domain.Operation) []line {
+	settled := 0
+	if op.SettledAmount != nil {
+		settled = *op.SettledAmount
+	}
+	if !op.Split() || op.Amount == 0 {
+		return []line{{categoryID: op.CategoryID, amount: op.Amount, settled: settled}}
+	}
+
+	result := make([]line, len(op.Splits))
+	rest := settled
+	for i, split := range op.Splits {
+		share := rest
+		if i < len(op.Splits)-1 {
+			share = int(int64(settled) * int64(split.Amount) / int64(op.Amount))
+		}
+		result[i] = line{categoryID: split.CategoryID, amount: split.Amount, settled: share, note: split.Note}
+		rest -= share
+	}
+	return result
+}
+
+func cells(op *domain.Operation, l line, opts Options) []cell {
+	row := make([]cell, 0, len(opts.Columns))
+	for _, col := range opts.Columns {
+		row = append(row, value(op, l, col, opts))
+	}
+	return row
+}
+
+func value(op *domain.Operation, l line, column string, opts Options) cell {
+	switch column {
+	case ColumnDate:
+		return cell{kind: kindDate, date: op.CreatedAt}
+	case ColumnType:
+		return cell{text: op.Type}
+	case ColumnName:
+		return cell{text: op.Name}
+	case ColumnCategory:
+		return cell{text: opts.Categories[l.categoryID]}
+	case ColumnAmount:
+		return cell{kind: kindAmount, amount: l.amount, exponent: currency.Exponent(op.Currency)}
+	case ColumnCurrency:
+		return cell{text: op.Currency}
+	case ColumnSettledAmount:
+		if op.SettledAmount == nil {
+			return cell{kind: kindEmpty}
+		}
+		return cell{kind: kindAmount, amount: l.settled, exponent: currency.Exponent(op.SettledCurrency)}
+	case ColumnSettledCurrency:
+		return cell{text: op.SettledCurrency}
+	case ColumnAccount:
+		return cell{text: op.Account}
+	case ColumnComment:
+		return cell{text: op.Comment}
+	case ColumnExternalID:
+		return cell{text: op.ExternalID}
+	}
+	return cell{kind: kindEmpty}
+}
+
+func titles(columns []string) []string {
+	result := make([]string, len(columns))
+	for i, col := range columns {
+		result[i] = columnTitles[col]
+	}
+	return result
+}
@@ -0,0 +1,198 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func operations() ([]domain.Operation, map[uuid.UUID]string) {
+	food := uuid.New()
+	return []domain.Operation{
+		{
+			BaseEntity: domain.BaseEntity{CreatedAt: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)},
+			CategoryID: food,
+			Name:       "Bakery; \"Müller\"",
+			Amount:     123450,
+			Currency:   "EUR",
+			Type:       "expense",
+		},
+		{
+			BaseEntity: domain.BaseEntity{CreatedAt: time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)},
+			Name:       "Ramen",
+			Amount:     1500,
+			Currency:   "JPY",
+			Type:       "expense",
+		},
+	}, map[uuid.UUID]string{food: "Food"}
+}
+
+func TestFormatAmount(t *testing.T) {
+	de, ok := LookupLocale("de_DE")
+	require.True(t, ok)
+
+	tests := []struct {
+		locale   Locale
+		amount   int
+		exponent int
+		want     string
+	}{
+		{DefaultLocale, 123450, 2, "1234.50"},
+		{de, 123450, 2, "1.234,50"},
+		{de, -5, 2, "-0,05"},
+		{locales["fr"], 123456789, 2, "1 234 567,89"},
+		{locales["en"], 1500, 0, "1,500"},
+		{locales["en"], 1, 3, "0.001"},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.want, tt.locale.FormatAmount(tt.amount, tt.exponent))
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	ops, categories := operations()
+	de, _ := LookupLocale("de")
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV, Options{
+		Columns:    []string{ColumnDate, ColumnName, ColumnCategory, ColumnAmount, ColumnCurrency},
+		Locale:     de,
+		Categories: categories,
+	})
+	require.NoError(t, err)
+	for i := range ops {
+		require.NoError(t, w.Write(&ops[i]))
+	}
+	require.NoError(t, w.Close())
+
+	require.Equal(t, strings.Join([]string{
+		"Date;Name;Category;Amount;Currency",
+		`05.03.2024;"Bakery; ""Müller""";Food;1.234,50;EUR`,
+		"06.03.2024;Ramen;;1.500;JPY",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	ops, categories := operations()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatXLSX, Options{Categories: categories})
+	require.NoError(t, err)
+	for i := range ops {
+		require.NoError(t, w.Write(&ops[i]))
+	}
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		body, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(body)
+	}
+
+	require.Contains(t, files, "[Content_Types].xml")
+	require.Contains(t, files, "xl/workbook.xml")
+	require.Contains(t, files["xl/styles.xml"], `formatCode="yyyy-mm-dd"`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	require.Contains(t, sheet, `<c r="A2" s="1"><v>45356</v></c>`)
+	require.Contains(t, sheet, `<t xml:space="preserve">Bakery; &#34;Müller&#34;</t>`)
+	require.Contains(t, sheet, `<c r="E2" s="4"><v>1234.50</v></c>`)
+	require.Contains(t, sheet, `<c r="E3" s="2"><v>1500</v></c>`)
+}
+
+func TestNewWriterRejectsUnknownColumns(t *testing.T) {
+	_, err := NewWriter(io.Discard, FormatCSV, Options{Columns: ParseColumns("date, balance")})
+	require.EqualError(t, err, `unknown column "balance"`)
+}
+
+func TestColumnName(t *testing.T) {
+	require.Equal(t, "A", columnName(0))
+	require.Equal(t, "Z", columnName(25))
+	require.Equal(t, "AA", columnName(26))
+	require.Equal(t, "AZ", columnName(51))
+}
+
+func splitOperation() (domain.Operation, map[uuid.UUID]string) {
+	food, home := uuid.New(), uuid.New()
+	settled := 1001
+	return domain.Operation{
+		BaseEntity:      domain.BaseEntity{CreatedAt: time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC)},
+		CategoryID:      food,
+		Name:            "Supermarket",
+		Amount:          1200,
+		Currency:        "USD",
+		SettledAmount:   &settled,
+		SettledCurrency: "EUR",
+		Type:            "expense",
+		Splits: []domain.OperationSplit{
+			{CategoryID: food, Amount: 800},
+			{CategoryID: home, Amount: 400},
+		},
+	}, map[uuid.UUID]string{food: "Food", home: "home & garden"}
+}
+
+func TestCSVWriterSplitsOperations(t *testing.T) {
+	op, categories := splitOperation()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatCSV, Options{
+		Columns:    []string{ColumnName, ColumnCategory, ColumnAmount, ColumnSettledAmount},
+		Categories: categories,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&op))
+	require.NoError(t, w.Close())
+
+	require.Equal(t, strings.Join([]string{
+		"Name,Category,Amount,Settled amount",
+		"Supermarket,Food,8.00,6.67",
+		"Supermarket,home & garden,4.00,3.34",
+		"",
+	}, "\n"), buf.String())
+}
+
+func TestJournalWriterSplitsOperations(t *testing.T) {
+	op, categories := splitOperation()
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, FormatLedger, Options{Categories: categories})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&op))
+	require.NoError(t, w.Close())
+
+	require.Equal(t, strings.Join([]string{
+		"2024-03-07 * Supermarket",
+		"    Expenses:Food  8.00 USD @@ 6.67 EUR",
+		"    Expenses:Home-Garden  4.00 USD @@ 3.34 EUR",
+		"      ; category: home & garden",
+		"    Assets:Unassigned  -10.01 EUR",
+		"",
+		"",
+	}, "\n"), buf.String())
+
+	buf.Reset()
+	w, err = NewWriter(&buf, FormatBeancount, Options{Categories: categories})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(&op))
+	require.NoError(t, w.Close())
+
+	require.Contains(t, buf.String(), "1970-01-01 open Expenses:Home-Garden\n")
+	require.Contains(t, buf.String(), "  Expenses:Home-Garden  4.00 USD @@ 3.34 EUR\n    category: \"home & garden\"\n")
+}
@@ -0,0 +1,234 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+const (
+	FormatLedger    = "ledger"
+	FormatHLedger   = "hledger"
+	FormatBeancount = "beancount"
+)
+
+// Top-level accounts of plain-text accounting journals. Operations without
+// an account are booked against UnassignedAccount.
+const (
+	AccountExpenses   = "Expenses"
+	AccountIncome     = "Income"
+	AccountAssets     = "Assets"
+	UnassignedAccount = AccountAssets + ":Unassigned"
+	Uncategorized     = "Uncategorized"
+)
+
+// Metadata keys that keep what account names cannot carry: category and
+// account names that do not survive AccountName, the comment (ledger has no
+// narration) and the external id. Split postings carry their own category
+// and note.
+const (
+	MetaCategory   = "category"
+	MetaAccount    = "account"
+	MetaComment    = "comment"
+	MetaExternalID = "external_id"
+	MetaNote       = "note"
+)
+
+// journalWriter renders operations as double-entry transactions: the
+// category posting (one per split) under Expenses or Income and the
+// balancing posting under Assets. Foreign-currency operations carry their
+// settled amount as a total price (@@) so the asset posting is in the
+// settled currency.
+type journalWriter struct {
+	w         *bufio.Writer
+	opts      Options
+	beancount bool
+	opened    map[string]bool
+}
+
+func newJournalWriter(w io.Writer, opts Options, beancount bool) *journalWriter {
+	return &journalWriter{
+		w:         bufio.NewWriter(w),
+		opts:      opts,
+		beancount: beancount,
+		opened:    make(map[string]bool),
+	}
+}
+
+func (jw *journalWriter) Write(op *domain.Operation) error {
+	root, sign := AccountExpenses, 1
+	if op.Type == "income" {
+		root, sign = AccountIncome, -1
+	}
+
+	assetAccount := UnassignedAccount
+	if op.Account != "" {
+		assetAccount = AccountAssets + ":" + AccountName(op.Account)
+	}
+
+	// A split operation gets one category posting per split, each carrying
+	// its share of the settled amount.
+	parts := lines(op)
+	categories := make([]string, len(parts))
+	accounts := make([]string, len(parts))
+	postings := make([]string, len(parts))
+	total := 0
+	for i, l := range parts {
+		categories[i] = jw.opts.Categories[l.categoryID]
+		accounts[i] = root + ":" + AccountName(categories[i])
+		amount := sign * l.amount
+		postings[i] = formatAmount(amount, op.Currency)
+		if !op.Settled() {
+			total += amount
+			continue
+		}
+		settled := l.settled
+		if amount < 0 {
+			settled = -settled
+		}
+		postings[i] += " @@ " + formatAmount(abs(settled), op.SettledCurrency)
+		total += settled
+	}
+	balance := formatAmount(-total, op.Currency)
+	if op.Settled() {
+		balance = formatAmount(-total, op.SettledCurrency)
+	}
+
+	if jw.beancount {
+		jw.open(append(accounts, assetAccount)...)
+		fmt.Fprintf(jw.w, "%s * %s %s\n", op.CreatedAt.Format("2006-01-02"), quote(op.Name), quote(op.Comment))
+	} else {
+		fmt.Fprintf(jw.w, "%s * %s\n", op.CreatedAt.Format("2006-01-02"), oneLine(op.Name))
+		if op.Comment != "" {
+			jw.meta(MetaComment, op.Comment)
+		}
+	}
+	split := len(parts) > 1
+	if !split && NameFromAccount(AccountName(categories[0])) != categories[0] {
+		jw.meta(MetaCategory, categories[0])
+	}
+	// The operation's own category is read back from the first split
+	// unless it says otherwise.
+	if category := jw.opts.Categories[op.CategoryID]; split && category != "" && category != categories[0] {
+		jw.meta(MetaCategory, category)
+	}
+	if op.Account != "" && NameFromAccount(AccountName(op.Account)) != op.Account {
+		jw.meta(MetaAccount, op.Account)
+	}
+	if op.ExternalID != "" {
+		jw.meta(MetaExternalID, op.ExternalID)
+	}
+
+	for i := range parts {
+		jw.posting(accounts[i], postings[i])
+		if split && NameFromAccount(AccountName(categories[i])) != categories[i] {
+			jw.postingMeta(MetaCategory, categories[i])
+		}
+		if parts[i].note != "" {
+			jw.postingMeta(MetaNote, parts[i].note)
+		}
+	}
+	jw.posting(assetAccount, balance)
+	_, err := jw.w.WriteString("\n")
+	return err
+}
+
+func (jw *journalWriter) Close() error {
+	return jw.w.Flush()
+}
+
+// open declares accounts the first time they are used. Beancount sorts
+// directives by date, so opening them at the epoch is valid anywhere in the
+// file.
+func (jw *journalWriter) open(accounts ...string) {
+	for _, account := range accounts {
+		if !jw.opened[account] {
+			jw.opened[account] = true
+			fmt.Fprintf(jw.w, "1970-01-01 open %s\n\n", account)
+		}
+	}
+}
+
+func (jw *journalWriter) meta(key, value string) {
+	if jw.beancount {
+		fmt.Fprintf(jw.w, "  %s: %s\n", key, quote(value))
+		return
+	}
+	fmt.Fprintf(jw.w, "    ; %s: %s\n", key, escape(value))
+}
+
+// postingMeta attaches metadata to the posting written just before it.
+func (jw *journalWriter) postingMeta(key, value string) {
+	if jw.beancount {
+		fmt.Fprintf(jw.w, "    %s: %s\n", key, quote(value))
+		return
+	}
+	fmt.Fprintf(jw.w, "      ; %s: %s\n", key, escape(value))
+}
+
+func (jw *journalWriter) posting(account, amount string) {
+	indent := "    "
+	if jw.beancount {
+		indent = "  "
+	}
+	fmt.Fprintf(jw.w, "%s%s  %s\n", indent, account, amount)
+}
+
+// AccountName turns a category or account name into journal account
+// components: every word is capitalized and joined with dashes, so
+// "food & drinks" becomes "Food-Drinks". Colons keep separating levels.
+func AccountName(name string) string {
+	var parts []string
+	for _, level := range strings.Split(name, ":") {
+		words := strings.FieldsFunc(level, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for i, w := range words {
+			runes := []rune(w)
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+		if len(words) > 0 {
+			parts = append(parts, strings.Join(words, "-"))
+		}
+	}
+	if len(parts) == 0 {
+		return Uncategorized
+	}
+	return strings.Join(parts, ":")
+}
+
+// NameFromAccount reads a category or account name back from journal
+// account components, turning dashes into spaces.
+func NameFromAccount(account string) string {
+	return strings.ReplaceAll(account, "-", " ")
+}
+
+func formatAmount(amount int, code string) string {
+	return DefaultLocale.FormatAmount(amount, currency.Exponent(code)) + " " + code
+}
+
+// escape keeps a metadata value on one line; the importer reverses it.
+func escape(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", "").Replace(value)
+}
+
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "").Replace(value) + `"`
+}
+
+func oneLine(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Locale controls how numbers and dates are written to CSV. XLSX stores
+// numbers and dates natively and only takes the date layout from it.
+type Locale struct {
+	Decimal    string
+	Thousands  string
+	DateLayout string
+	Delimiter  rune
+}
+
+var DefaultLocale = Locale{Decimal: ".", DateLayout: "2006-01-02", Delimiter: ','}
+
+// Locales that use a decimal comma get a semicolon delimiter, as spreadsheet
+// programs in those regions expect.
+var locales = map[string]Locale{
+	"en":    {Decimal: ".", Thousands: ",", DateLayout: "2006-01-02", Delimiter: ','},
+	"en-us": {Decimal: ".", Thousands: ",", DateLayout: "01/02/2006", Delimiter: ','},
+	"en-gb": {Decimal: ".", Thousands: ",", DateLayout: "02/01/2006", Delimiter: ','},
+	"de":    {Decimal: ",", Thousands: ".", DateLayout: "02.01.2006", Delimiter: ';'},
+	"fr":    {Decimal: ",", Thousands: " ", DateLayout: "02/01/2006", Delimiter: ';'},
+	"es":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Delimiter: ';'},
+	"it":    {Decimal: ",", Thousands: ".", DateLayout: "02/01/2006", Delimiter: ';'},
+	"ru":    {Decimal: ",", Thousands: " ", DateLayout: "02.01.2006", Delimiter: ';'},
+}
+
+// LookupLocale finds a locale by a tag such as "de", "de-DE" or "en_US",
+// falling back to the language alone.
+func LookupLocale(tag string) (Locale, bool) {
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if l, ok := locales[tag]; ok {
+		return l, true
+	}
+	if i := strings.IndexByte(tag, '-'); i > 0 {
+		l, ok := locales[tag[:i]]
+		return l, ok
+	}
+	return Locale{}, false
+}
+
+// FormatAmount writes an amount in minor units as a decimal number with the
+// locale's separators, e.g. 123450 with exponent 2 as "1.234,50" in German.
+func (l Locale) FormatAmount(amount, exponent int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-exponent], digits[len(digits)-exponent:]
+
+	if l.Thousands != "" && len(whole) > 3 {
+		var b strings.Builder
+		for i, r := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteString(l.Thousands)
+			}
+			b.WriteRune(r)
+		}
+		whole = b.String()
+	}
+
+	if fraction == "" {
+		return sign + whole
+	}
+	return sign + whole + l.Decimal + fraction
+}
@@ -0,0 +1,195 @@
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+)
+
+// maxExponent is the largest currency exponent with its own number style.
+const maxExponent = 4
+
+// Cell styles defined in styles.xml: 0 is the default, 1 the date and 2+n
+// an amount with n decimals.
+const (
+	styleDate   = 1
+	styleAmount = 2
+)
+
+// xlsxWriter writes a single-sheet workbook. The sheet is the only part that
+// grows with the data, so it is written first and streamed row by row; the
+// small fixed parts follow on Close.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+	opts  Options
+	row   int
+}
+
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+func newXLSXWriter(w io.Writer, opts Options) (*xlsxWriter, error) {
+	zw := zip.NewWriter(w)
+	part, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	xw := &xlsxWriter{zip: zw, sheet: bufio.NewWriter(part), opts: opts}
+	xw.sheet.WriteString(xml.Header)
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]cell, len(opts.Columns))
+	for i, title := range titles(opts.Columns) {
+		header[i] = cell{text: title}
+	}
+	if err := xw.writeRow(header); err != nil {
+		return nil, err
+	}
+
+	return xw, nil
+}
+
+// Write adds one row per split, so split operations show every category.
+func (xw *xlsxWriter) Write(op *domain.Operation) error {
+	for _, l := range lines(op) {
+		if err := xw.writeRow(cells(op, l, xw.opts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (xw *xlsxWriter) writeRow(row []cell) error {
+	xw.row++
+	fmt.Fprintf(xw.sheet, `<row r="%d">`, xw.row)
+	for i, c := range row {
+		ref := columnName(i) + strconv.Itoa(xw.row)
+		switch c.kind {
+		case kindText:
+			if c.text == "" {
+				continue
+			}
+			fmt.Fprintf(xw.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(xw.sheet, []byte(c.text)); err != nil {
+				return err
+			}
+			xw.sheet.WriteString(`</t></is></c>`)
+		case kindDate:
+			days := float64(c.date.Sub(xlsxEpoch)) / float64(24*time.Hour)
+			fmt.Fprintf(xw.sheet, `<c r="%s" s="%d"><v>%s</v></c>`,
+				ref, styleDate, strconv.FormatFloat(days, 'f', -1, 64))
+		case kindAmount:
+			exponent := min(c.exponent, maxExponent)
+			fmt.Fprintf(xw.sheet, `<c r="%s" s="%d"><v>%s</v></c>`,
+				ref, styleAmount+exponent, DefaultLocale.FormatAmount(c.amount, c.exponent))
+		}
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxWriter) Close() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+
+	parts := []struct {
+		name, body string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles(excelDateFormat(xw.opts.Locale.DateLayout))},
+	}
+	for _, p := range parts {
+		f, err := xw.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return err
+		}
+	}
+
+	return xw.zip.Close()
+}
+
+// columnName turns a zero-based column index into A, B, ..., Z, AA, ...
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+var excelDateTokens = strings.NewReplacer("2006", "yyyy", "06", "yy", "01", "mm", "02", "dd")
+
+// excelDateFormat turns a Go date layout into an Excel number format.
+func excelDateFormat(layout string) string {
+	return excelDateTokens.Replace(layout)
+}
+
+func xlsxStyles(dateFormat string) string {
+	var numFmts, xfs strings.Builder
+
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(dateFormat))
+	fmt.Fprintf(&numFmts, `<numFmt numFmtId="164" formatCode="%s"/>`, escaped.String())
+	xfs.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	xfs.WriteString(`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`)
+
+	for exp := 0; exp <= maxExponent; exp++ {
+		code := "#,##0"
+		if exp > 0 {
+			code += "." + strings.Repeat("0", exp)
+		}
+		fmt.Fprintf(&numFmts, `<numFmt numFmtId="%d" formatCode="%s"/>`, 165+exp, code)
+		fmt.Fprintf(&xfs, `<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`, 165+exp)
+	}
+
+	return xml.Header +
+		`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		fmt.Sprintf(`<numFmts count="%d">%s</numFmts>`, maxExponent+2, numFmts.String()) +
+		`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		fmt.Sprintf(`<cellXfs count="%d">%s</cellXfs>`, maxExponent+3, xfs.String()) +
+		`</styleSheet>`
+}
+
+const xlsxContentTypes = xml.Header +
+	`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = xml.Header +
+	`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Operations" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = xml.Header +
+	`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
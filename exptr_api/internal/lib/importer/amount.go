@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var errEmptyAmount = errors.New("empty amount")
+
+// ParseAmount parses a localized decimal number into minor units with the
+// given number of fraction digits. It understands thousands separators,
+// currency symbols, leading or trailing minus signs and accounting-style
+// parentheses. Extra fraction digits are rounded half away from zero.
+func ParseAmount(s, decimalSep, thousandsSep string, exponent int) (int, error) {
+	if decimalSep == "" {
+		decimalSep = "."
+	}
+
+	raw := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errEmptyAmount
+	}
+
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	if thousandsSep != "" {
+		s = strings.ReplaceAll(s, thousandsSep, "")
+	}
+	if decimalSep != "." {
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.ReplaceAll(s, decimalSep, ".")
+	}
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '.':
+			b.WriteRune(r)
+		case r == '-' || r == '−':
+			negative = !negative
+		case r == '+', unicode.IsSpace(r), unicode.IsLetter(r), unicode.Is(unicode.Sc, r), r == ',' || r == '\'':
+			// currency codes, symbols and stray separators
+		default:
+			return 0, fmt.Errorf("invalid amount %q", raw)
+		}
+	}
+
+	digits := b.String()
+	if digits == "" {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+
+	whole, frac, _ := strings.Cut(digits, ".")
+	if strings.Contains(frac, ".") {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+
+	roundUp := false
+	if len(frac) > exponent {
+		roundUp = frac[exponent] >= '5'
+		frac = frac[:exponent]
+	}
+	frac += strings.Repeat("0", exponent-len(frac))
+
+	value, err := strconv.Atoi(whole + frac)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if roundUp {
+		value++
+	}
+	if negative {
+		value = -value
+	}
+
+	return value, nil
+}
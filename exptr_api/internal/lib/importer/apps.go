@@ -0,0 +1,362 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// Personal-finance apps whose CSV exports AppParser reads.
+const (
+	AppYNAB         = "ynab"
+	AppMint         = "mint"
+	AppZenMoney     = "zenmoney"
+	AppMoneyManager = "moneymanager"
+	AppFirefly      = "firefly"
+)
+
+// AppParser reads the transaction export of another personal-finance app.
+// Columns are found by their header names, the delimiter is detected from
+// the header row, and transfers between the user's own accounts are
+// skipped since they are neither income nor expense. DateFormat overrides
+// the app's usual date pattern; US apps default to month/day/year with or
+// without leading zeros.
+type AppParser struct {
+	App        string
+	DateFormat string
+}
+
+// appRow is a CSV row addressed by lowercased header names.
+type appRow struct {
+	line   int
+	values []string
+	header map[string]int
+}
+
+// get returns the first non-empty value among alternative column names.
+func (r appRow) get(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.header[name]; ok && i < len(r.values) {
+			if v := strings.TrimSpace(r.values[i]); v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+type appAdapter struct {
+	dateFormat string
+	required   []string
+	// record converts a row, returning false for rows to skip.
+	record func(r appRow, layout string) (Record, bool)
+}
+
+var appAdapters = map[string]appAdapter{
+	AppYNAB: {
+		dateFormat: "1/2/2006",
+		required:   []string{"date", "payee", "outflow", "inflow"},
+		record:     ynabRecord,
+	},
+	AppMint: {
+		dateFormat: "1/2/2006",
+		required:   []string{"date", "description", "amount", "transaction type"},
+		record:     mintRecord,
+	},
+	AppZenMoney: {
+		dateFormat: "YYYY-MM-DD",
+		required:   []string{"date", "outcome", "income"},
+		record:     zenMoneyRecord,
+	},
+	AppMoneyManager: {
+		dateFormat: "1/2/2006",
+		required:   []string{"amount", "income/expense"},
+		record:     moneyManagerRecord,
+	},
+	AppFirefly: {
+		dateFormat: "YYYY-MM-DD",
+		required:   []string{"type", "amount", "date", "description"},
+		record:     fireflyRecord,
+	},
+}
+
+// IsApp reports whether AppParser has an adapter for the app.
+func IsApp(app string) bool {
+	_, ok := appAdapters[app]
+	return ok
+}
+
+func (p AppParser) Parse(r io.Reader) ([]Record, error) {
+	const fn = "importer.AppParser.Parse"
+
+	adapter, ok := appAdapters[p.App]
+	if !ok {
+		return nil, fmt.Errorf("%s: unsupported app %q", fn, p.App)
+	}
+
+	r, err := decode(r, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = sniffDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	head, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: missing header row", fn)
+	}
+	header := make(map[string]int, len(head))
+	for i, name := range head {
+		header[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range adapter.required {
+		if _, ok := header[name]; !ok {
+			return nil, fmt.Errorf("%s: not a %s export: column %q not found", fn, p.App, name)
+		}
+	}
+
+	pattern := p.DateFormat
+	if pattern == "" {
+		pattern = adapter.dateFormat
+	}
+	layout := DateLayout(pattern)
+
+	var records []Record
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		if blank(values) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+		if rec, ok := adapter.record(appRow{line: line, values: values, header: header}, layout); ok {
+			records = append(records, rec)
+		}
+	}
+
+	return records, nil
+}
+
+func ynabRecord(r appRow, layout string) (Record, bool) {
+	payee := r.get("payee")
+	if strings.HasPrefix(payee, "Transfer : ") {
+		return Record{}, false
+	}
+
+	rec := Record{Line: r.line, CategoryName: r.get("category", "sub category")}
+	rec.Operation.Name = payee
+	rec.Operation.Comment = r.get("memo")
+	rec.Operation.Account = r.get("account")
+
+	outflow, inflow, err := appAmounts(r.get("outflow"), r.get("inflow"), "")
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+	rec.Operation.Amount, rec.Operation.Type = signed(inflow - outflow)
+
+	return appDate(rec, r.get("date"), layout), true
+}
+
+func mintRecord(r appRow, layout string) (Record, bool) {
+	category := r.get("category")
+	if strings.EqualFold(category, "Transfer") || strings.EqualFold(category, "Credit Card Payment") {
+		return Record{}, false
+	}
+
+	rec := Record{Line: r.line, CategoryName: category}
+	rec.Operation.Name = r.get("description", "original description")
+	rec.Operation.Comment = r.get("notes")
+	rec.Operation.Account = r.get("account name")
+
+	amount, err := looseAmount(r.get("amount"), "")
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+	rec.Operation.Amount = abs(amount)
+	rec.Operation.Type = TypeExpense
+	if strings.EqualFold(r.get("transaction type"), "credit") {
+		rec.Operation.Type = TypeIncome
+	}
+
+	return appDate(rec, r.get("date"), layout), true
+}
+
+func zenMoneyRecord(r appRow, layout string) (Record, bool) {
+	rec := Record{Line: r.line, CategoryName: r.get("categoryname")}
+	rec.Operation.Name = r.get("payee", "comment")
+	rec.Operation.Comment = r.get("comment")
+
+	outcomeCurrency := r.get("outcomecurrencyshorttitle")
+	incomeCurrency := r.get("incomecurrencyshorttitle")
+	outcome, err := looseAmount(r.get("outcome"), outcomeCurrency)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+	income, err := looseAmount(r.get("income"), incomeCurrency)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+
+	switch {
+	case outcome != 0 && income != 0:
+		// Money moved between two accounts of the user.
+		return Record{}, false
+	case outcome != 0:
+		rec.Operation.Amount, rec.Operation.Type = abs(outcome), TypeExpense
+		rec.Operation.Currency = outcomeCurrency
+		rec.Operation.Account = r.get("outcomeaccountname")
+	default:
+		rec.Operation.Amount, rec.Operation.Type = abs(income), TypeIncome
+		rec.Operation.Currency = incomeCurrency
+		rec.Operation.Account = r.get("incomeaccountname")
+	}
+
+	return appDate(rec, r.get("date"), layout), true
+}
+
+func moneyManagerRecord(r appRow, layout string) (Record, bool) {
+	kind := strings.ToLower(r.get("income/expense"))
+	if strings.HasPrefix(kind, "transfer") {
+		return Record{}, false
+	}
+
+	rec := Record{Line: r.line, CategoryName: r.get("category")}
+	rec.Operation.Name = r.get("note", "description")
+	rec.Operation.Comment = r.get("description")
+	if rec.Operation.Comment == rec.Operation.Name {
+		rec.Operation.Comment = ""
+	}
+	rec.Operation.Account = r.get("account", "accounts")
+	rec.Operation.Currency = r.get("currency")
+
+	amount, err := looseAmount(r.get("amount"), rec.Operation.Currency)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+	rec.Operation.Amount = abs(amount)
+	rec.Operation.Type = TypeExpense
+	if strings.HasPrefix(kind, "income") {
+		rec.Operation.Type = TypeIncome
+	}
+
+	// Dates come with a time of day, e.g. "01/02/2024 12:30:00".
+	date, _, _ := strings.Cut(r.get("date", "period"), " ")
+	return appDate(rec, date, layout), true
+}
+
+func fireflyRecord(r appRow, layout string) (Record, bool) {
+	kind := strings.ToLower(r.get("type"))
+	if kind != "withdrawal" && kind != "deposit" {
+		// Transfers, opening balances and reconciliations.
+		return Record{}, false
+	}
+
+	rec := Record{Line: r.line, CategoryName: r.get("category")}
+	if id := r.get("journal_id", "transaction_journal_id"); id != "" {
+		rec.ExternalID = "firefly:" + id
+	}
+	op := &rec.Operation
+	op.Name = r.get("description")
+	op.Comment = r.get("notes")
+	op.Currency = r.get("currency_code")
+	op.Type = TypeExpense
+	op.Account = r.get("source_name")
+	if kind == "deposit" {
+		op.Type = TypeIncome
+		op.Account = r.get("destination_name")
+	}
+
+	amount, err := looseAmount(r.get("amount"), op.Currency)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec, true
+	}
+	op.Amount = abs(amount)
+
+	// The foreign amount is what was originally charged; amount is what
+	// the account was debited in its own currency.
+	if foreignCurrency := r.get("foreign_currency_code"); foreignCurrency != "" {
+		foreign, err := looseAmount(r.get("foreign_amount"), foreignCurrency)
+		if err == nil && foreign != 0 {
+			settled := op.Amount
+			op.SettledAmount, op.SettledCurrency = &settled, op.Currency
+			op.Amount, op.Currency = abs(foreign), foreignCurrency
+		}
+	}
+
+	// Firefly writes ISO 8601 timestamps such as 2024-01-02T00:00:00+01:00.
+	date, _, _ := strings.Cut(r.get("date"), "T")
+	return appDate(rec, date, layout), true
+}
+
+func appDate(rec Record, value, layout string) Record {
+	date, err := ParseDate(value, layout)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	rec.Operation.CreatedAt = date
+	return rec
+}
+
+// appAmounts parses an outflow/inflow column pair; either may be empty.
+func appAmounts(outflow, inflow, code string) (int, int, error) {
+	out, err := looseAmount(outflow, code)
+	if err != nil {
+		return 0, 0, err
+	}
+	in, err := looseAmount(inflow, code)
+	if err != nil {
+		return 0, 0, err
+	}
+	return abs(out), abs(in), nil
+}
+
+// looseAmount parses amounts written with either decimal separator: a
+// comma is taken as the decimal separator when the value has no point.
+// Empty values are zero.
+func looseAmount(value, code string) (int, error) {
+	if strings.TrimSpace(value) == "" {
+		return 0, nil
+	}
+	exponent := currency.Exponent(code)
+	if strings.Contains(value, ",") && !strings.Contains(value, ".") {
+		return ParseAmount(strings.ReplaceAll(value, " ", ""), ",", "", exponent)
+	}
+	return ParseAmount(value, ".", ",", exponent)
+}
+
+// sniffDelimiter picks the most frequent of comma, semicolon and tab in the
+// header row.
+func sniffDelimiter(data []byte) rune {
+	first, _, _ := bytes.Cut(data, []byte("\n"))
+	best, count := ',', bytes.Count(first, []byte(","))
+	for _, d := range []rune{';', '\t'} {
+		if n := bytes.Count(first, []byte(string(d))); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
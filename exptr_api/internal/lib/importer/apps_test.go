@@ -0,0 +1,184 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppParser(t *testing.T) {
+	tests := []struct {
+		app  string
+		file string
+		want []Record
+	}{
+		{
+			app:  AppYNAB,
+			file: "testdata/ynab.csv",
+			want: []Record{
+				{CategoryName: "Groceries", Operation: appOp("Lidl", "weekly shop", 1250, "", TypeExpense, "Checking", "2024-01-02")},
+				{CategoryName: "Ready to Assign", Operation: appOp("ACME", "", 250000, "", TypeIncome, "Checking", "2024-01-15")},
+			},
+		},
+		{
+			app:  AppMint,
+			file: "testdata/mint.csv",
+			want: []Record{
+				{CategoryName: "Coffee Shops", Operation: appOp("Starbucks", "", 475, "", TypeExpense, "Visa", "2024-01-02")},
+				{CategoryName: "Paycheck", Operation: appOp("Paycheck", "January", 250000, "", TypeIncome, "Checking", "2024-01-15")},
+			},
+		},
+		{
+			app:  AppZenMoney,
+			file: "testdata/zenmoney.csv",
+			want: []Record{
+				{CategoryName: "Продукты", Operation: appOp("Пятёрочка", "", 125050, "RUB", TypeExpense, "Наличные", "2024-01-02")},
+				{CategoryName: "Зарплата", Operation: appOp("ООО Ромашка", "аванс", 5000000, "RUB", TypeIncome, "Карта", "2024-01-10")},
+			},
+		},
+		{
+			app:  AppMoneyManager,
+			file: "testdata/moneymanager.csv",
+			want: []Record{
+				{CategoryName: "Food", Operation: appOp("Burger place", "", 890, "EUR", TypeExpense, "Cash", "2024-01-02")},
+				{CategoryName: "Salary", Operation: appOp("Employer", "January salary", 200000, "EUR", TypeIncome, "Bank", "2024-01-15")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.app, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			records, err := AppParser{App: tt.app}.Parse(f)
+			require.NoError(t, err)
+			require.Len(t, records, len(tt.want), "transfers are skipped")
+
+			for i, want := range tt.want {
+				require.Empty(t, records[i].Error)
+				require.Equal(t, want.CategoryName, records[i].CategoryName)
+				require.Equal(t, want.Operation, records[i].Operation)
+			}
+		})
+	}
+}
+
+func TestAppParserFirefly(t *testing.T) {
+	f, err := os.Open("testdata/firefly.csv")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := AppParser{App: AppFirefly}.Parse(f)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	hotel := records[0]
+	require.Equal(t, "firefly:101", hotel.ExternalID)
+	require.Equal(t, "Travel", hotel.CategoryName)
+	require.Equal(t, 1250, hotel.Operation.Amount)
+	require.Equal(t, "USD", hotel.Operation.Currency)
+	require.Equal(t, 1163, *hotel.Operation.SettledAmount)
+	require.Equal(t, "EUR", hotel.Operation.SettledCurrency)
+	require.Equal(t, "paid by card", hotel.Operation.Comment)
+	require.Equal(t, "Checking", hotel.Operation.Account)
+
+	require.Equal(t, TypeIncome, records[1].Operation.Type)
+	require.Equal(t, "Checking", records[1].Operation.Account)
+}
+
+func TestAppParserRejectsOtherFiles(t *testing.T) {
+	f, err := os.Open("testdata/mint.csv")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = AppParser{App: AppYNAB}.Parse(f)
+	require.ErrorContains(t, err, "not a ynab export")
+}
+
+func TestMapCategories(t *testing.T) {
+	userID := uuid.New()
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food", Type: TypeExpense}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Line: 1, CategoryName: "food", Operation: opRequest("Bakery", 300, day)},
+		{Line: 2, CategoryName: "Groceries", Operation: opRequest("Lidl", 1250, day)},
+		{Line: 3, CategoryName: "Paycheck", Operation: income(opRequest("ACME", 250000, day))},
+		{Line: 4, CategoryName: "Eating out", Operation: opRequest("Cafe", 450, day)},
+	}
+	opts := Options{UserID: userID, DefaultCurrency: "EUR", Categories: []domain.Category{food}}
+	overrides := map[string]uuid.UUID{"Eating Out": food.ID}
+
+	preview, created := MapCategories(records, opts, overrides, false)
+	require.Empty(t, created)
+	require.Len(t, preview, 4)
+
+	mapping, created := MapCategories(records, opts, overrides, true)
+	require.Len(t, created, 2)
+	require.Equal(t, "Eating out", mapping[0].Name)
+	require.Equal(t, food.ID, *mapping[0].CategoryID, "mapped by the override")
+	require.Equal(t, "food", mapping[1].Name)
+	require.False(t, mapping[1].Create)
+	require.Equal(t, "Groceries", mapping[2].Name)
+	require.True(t, mapping[2].Create)
+	require.Equal(t, TypeExpense, created[0].Type)
+	require.Equal(t, "Paycheck", mapping[3].Name)
+	require.Equal(t, TypeIncome, created[1].Type)
+	require.Equal(t, userID, created[1].UserID)
+
+	ApplyCategoryMapping(records, mapping)
+	opts.Categories = append(opts.Categories, created...)
+	rows := Prepare(records, opts)
+	require.Len(t, Ready(rows), 4)
+	require.Equal(t, created[0].ID, rows[1].Operation.CategoryID)
+}
+
+func TestMapCategoriesCoversSplits(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	op := opRequest("Drugstore", 1000, day)
+	op.Splits = []models.SplitRequest{{Amount: 600}, {Amount: 400}}
+	records := []Record{{Line: 1, CategoryName: "Household", SplitCategories: []string{"Household", "Pharmacy"}, Operation: op}}
+	opts := Options{UserID: uuid.New(), DefaultCurrency: "EUR"}
+
+	mapping, created := MapCategories(records, opts, nil, true)
+	require.Len(t, created, 2)
+	require.Equal(t, 1, mapping[0].Operations)
+
+	ApplyCategoryMapping(records, mapping)
+	opts.Categories = created
+	rows := Prepare(records, opts)
+	require.Len(t, Ready(rows), 1)
+	splits := rows[0].Operation.Splits
+	require.Equal(t, created[0].ID, rows[0].Operation.CategoryID)
+	require.Equal(t, created[0].ID, splits[0].CategoryID)
+	require.Equal(t, created[1].ID, splits[1].CategoryID)
+}
+
+func appOp(name, comment string, amount int, code, kind, account, date string) models.OperationRequest {
+	t, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		panic(err)
+	}
+	return models.OperationRequest{
+		Name:      name,
+		Comment:   comment,
+		Amount:    amount,
+		Currency:  code,
+		Type:      kind,
+		Account:   account,
+		CreatedAt: t,
+	}
+}
+
+func income(op models.OperationRequest) models.OperationRequest {
+	op.Type = TypeIncome
+	return op
+}
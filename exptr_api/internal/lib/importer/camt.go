@@ -0,0 +1,187 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// CAMTParser reads ISO 20022 camt.053 bank-to-customer statements. Only
+// booked entries are imported; the counterparty becomes the operation name,
+// the remittance information its comment and the servicer reference its
+// external id.
+type CAMTParser struct{}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+// camtStatus is plain text before camt.053.001.08 and a Cd element after.
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+type camtDocument struct {
+	Statements []struct {
+		ID       string `xml:"Id"`
+		Currency string `xml:"Acct>Ccy"`
+		Balances []struct {
+			Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+			Amount    camtAmount `xml:"Amt"`
+			Indicator string     `xml:"CdtDbtInd"`
+		} `xml:"Bal"`
+		Entries []struct {
+			Amount      camtAmount `xml:"Amt"`
+			Indicator   string     `xml:"CdtDbtInd"`
+			Status      camtStatus `xml:"Sts"`
+			BookingDate camtDate   `xml:"BookgDt"`
+			ValueDate   camtDate   `xml:"ValDt"`
+			Reference   string     `xml:"AcctSvcrRef"`
+			EntryRef    string     `xml:"NtryRef"`
+			Info        string     `xml:"AddtlNtryInf"`
+			Details     []struct {
+				Reference    string    `xml:"Refs>AcctSvcrRef"`
+				EndToEndID   string    `xml:"Refs>EndToEndId"`
+				Creditor     camtParty `xml:"RltdPties>Cdtr"`
+				Debtor       camtParty `xml:"RltdPties>Dbtr"`
+				Unstructured []string  `xml:"RmtInf>Ustrd"`
+				Structured   []string  `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+				Info         string    `xml:"AddtlTxInf"`
+			} `xml:"NtryDtls>TxDtls"`
+		} `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+func (p CAMTParser) Parse(r io.Reader) ([]Record, error) {
+	statements, err := p.ParseStatements(r)
+	if err != nil {
+		return nil, err
+	}
+	return flatten(statements), nil
+}
+
+func (p CAMTParser) ParseStatements(r io.Reader) ([]Statement, error) {
+	const fn = "importer.CAMTParser.ParseStatements"
+
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if len(doc.Statements) == 0 {
+		return nil, fmt.Errorf("%s: no camt.053 statements found", fn)
+	}
+
+	var (
+		statements []Statement
+		line       int
+	)
+	for _, stmt := range doc.Statements {
+		s := Statement{ID: stmt.ID, Currency: stmt.Currency}
+
+		for _, bal := range stmt.Balances {
+			if s.Currency == "" {
+				s.Currency = bal.Amount.Currency
+			}
+			amount, err := ParseAmount(bal.Amount.Value, ".", "", currency.Exponent(bal.Amount.Currency))
+			if err != nil {
+				return nil, fmt.Errorf("%s: balance: %w", fn, err)
+			}
+			amount = signedBalance(amount, bal.Indicator == "DBIT")
+			switch bal.Code {
+			case "OPBD", "PRCD":
+				s.Opening = amount
+			case "CLBD":
+				s.Closing = amount
+			}
+		}
+
+		for _, ntry := range stmt.Entries {
+			status := firstNonEmpty(ntry.Status.Code, ntry.Status.Value)
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			line++
+			rec := Record{Line: line}
+			op := &rec.Operation
+			op.Currency = ntry.Amount.Currency
+
+			var remittance []string
+			var reference string
+			debit := ntry.Indicator == "DBIT"
+			for _, d := range ntry.Details {
+				party := d.Debtor
+				if debit {
+					party = d.Creditor
+				}
+				if op.Name == "" {
+					op.Name = firstNonEmpty(party.Name, party.PartyName)
+				}
+				remittance = append(remittance, d.Unstructured...)
+				remittance = append(remittance, d.Structured...)
+				if reference == "" {
+					reference = firstNonEmpty(d.Reference, d.EndToEndID)
+				}
+			}
+			op.Comment = strings.Join(remittance, " ")
+			if op.Name == "" {
+				op.Name = firstNonEmpty(ntry.Info, op.Comment)
+			}
+
+			reference = firstNonEmpty(ntry.Reference, reference, ntry.EntryRef)
+			if reference != "" && reference != "NOTPROVIDED" {
+				rec.ExternalID = "camt:" + reference
+			}
+
+			date, err := camtParseDate(ntry.BookingDate, ntry.ValueDate)
+			if err != nil {
+				rec.Error = err.Error()
+				s.Records = append(s.Records, rec)
+				continue
+			}
+			op.CreatedAt = date
+
+			amount, err := ParseAmount(ntry.Amount.Value, ".", "", currency.Exponent(op.Currency))
+			if err != nil {
+				rec.Error = err.Error()
+				s.Records = append(s.Records, rec)
+				continue
+			}
+			op.Amount, op.Type = signed(signedBalance(amount, debit))
+
+			s.Records = append(s.Records, rec)
+		}
+
+		statements = append(statements, s)
+	}
+
+	return statements, nil
+}
+
+func camtParseDate(dates ...camtDate) (time.Time, error) {
+	for _, d := range dates {
+		if d.Date != "" {
+			return time.Parse("2006-01-02", strings.TrimSpace(d.Date))
+		}
+		if d.DateTime != "" {
+			return time.Parse(time.RFC3339, strings.TrimSpace(d.DateTime))
+		}
+	}
+	return time.Time{}, fmt.Errorf("missing booking date")
+}
@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCAMTParser(t *testing.T) {
+	f, err := os.Open("testdata/statement.camt053.xml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	statements, err := CAMTParser{}.ParseStatements(f)
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	s := statements[0]
+	require.Equal(t, "EUR", s.Currency)
+	require.Len(t, s.Records, 2, "pending entries are skipped")
+
+	debit := s.Records[0].Operation
+	require.Equal(t, "LIDL SAGT DANKE", debit.Name)
+	require.Equal(t, "Einkauf Filiale 45 Berlin", debit.Comment)
+	require.Equal(t, 1250, debit.Amount)
+	require.Equal(t, TypeExpense, debit.Type)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), debit.CreatedAt)
+	require.Equal(t, "camt:2024010200001", s.Records[0].ExternalID)
+
+	credit := s.Records[1].Operation
+	require.Equal(t, "ACME GmbH", credit.Name)
+	require.Equal(t, TypeIncome, credit.Type)
+
+	check := s.Check()
+	require.True(t, check.Balanced)
+	require.Equal(t, 248750, check.Net)
+}
+
+func TestMT940Parser(t *testing.T) {
+	f, err := os.Open("testdata/statement.mt940")
+	require.NoError(t, err)
+	defer f.Close()
+
+	statements, err := MT940Parser{}.ParseStatements(f)
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+
+	s := statements[0]
+	require.Equal(t, "STARTUMSE", s.ID)
+	require.Equal(t, 100000, s.Opening)
+	require.Equal(t, 348750, s.Closing)
+	require.Len(t, s.Records, 2)
+
+	debit := s.Records[0]
+	require.Empty(t, debit.Error)
+	require.Equal(t, "LIDL SAGT DANKE", debit.Operation.Name)
+	require.Equal(t, "Einkauf Filiale 45 Berlin", debit.Operation.Comment)
+	require.Equal(t, 1250, debit.Operation.Amount)
+	require.Equal(t, TypeExpense, debit.Operation.Type)
+	require.Equal(t, "mt940:2024010200001", debit.ExternalID)
+
+	credit := s.Records[1]
+	require.Equal(t, "ACME GmbH", credit.Operation.Name)
+	require.Equal(t, "Gehalt Januar", credit.Operation.Comment)
+	require.Equal(t, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), credit.Operation.CreatedAt)
+
+	require.True(t, s.Check().Balanced)
+
+	s.Closing += 100
+	check := s.Check()
+	require.False(t, check.Balanced)
+	require.Equal(t, 100, check.Difference)
+}
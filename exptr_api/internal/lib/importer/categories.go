@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"sort"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// MapCategories matches the category names found in records to the user's
+// categories. Names listed in overrides map to the given category. Other
+// unknown names get a new category when create is set; it is returned with
+// its id already assigned so a preview and the following import agree.
+func MapCategories(records []Record, opts Options, overrides map[string]uuid.UUID, create bool) ([]models.CategoryMapping, []domain.Category) {
+	existing := make(map[string]domain.Category, len(opts.Categories))
+	byID := make(map[uuid.UUID]domain.Category, len(opts.Categories))
+	for _, c := range opts.Categories {
+		existing[categoryKey(c.Name)] = c
+		byID[c.ID] = c
+	}
+	mapped := make(map[string]uuid.UUID, len(overrides))
+	for name, id := range overrides {
+		mapped[categoryKey(name)] = id
+	}
+
+	type usage struct {
+		name           string
+		count, incomes int
+	}
+	used := map[string]*usage{}
+	var order []string
+	for _, rec := range records {
+		if rec.Error != "" {
+			continue
+		}
+		counted := map[string]bool{}
+		for _, name := range append([]string{rec.CategoryName}, rec.SplitCategories...) {
+			key := categoryKey(name)
+			if key == "" || counted[key] {
+				continue
+			}
+			counted[key] = true
+			u, ok := used[key]
+			if !ok {
+				u = &usage{name: strings.TrimSpace(name)}
+				used[key] = u
+				order = append(order, key)
+			}
+			u.count++
+			if rec.Operation.Type == TypeIncome {
+				u.incomes++
+			}
+		}
+	}
+	sort.Strings(order)
+
+	var mapping []models.CategoryMapping
+	var created []domain.Category
+	for _, key := range order {
+		u := used[key]
+		m := models.CategoryMapping{Name: u.name, Operations: u.count}
+
+		if id, ok := mapped[key]; ok {
+			if c, ok := byID[id]; ok {
+				m.CategoryID, m.Category = &c.ID, c.Name
+			}
+		} else if c, ok := existing[key]; ok {
+			m.CategoryID, m.Category = &c.ID, c.Name
+		} else if create {
+			c := domain.Category{
+				BaseEntity: domain.BaseEntity{ID: uuid.New()},
+				UserID:     opts.UserID,
+				Name:       u.name,
+				Type:       TypeExpense,
+			}
+			if u.incomes*2 > u.count {
+				c.Type = TypeIncome
+			}
+			created = append(created, c)
+			m.CategoryID, m.Category, m.Create = &c.ID, c.Name, true
+		}
+
+		mapping = append(mapping, m)
+	}
+
+	return mapping, created
+}
+
+// ApplyCategoryMapping sets the category of records whose category name
+// was mapped, ahead of Prepare's own lookup by name.
+func ApplyCategoryMapping(records []Record, mapping []models.CategoryMapping) {
+	ids := make(map[string]uuid.UUID, len(mapping))
+	for _, m := range mapping {
+		if m.CategoryID != nil {
+			ids[categoryKey(m.Name)] = *m.CategoryID
+		}
+	}
+	for i := range records {
+		rec := &records[i]
+		if id, ok := ids[categoryKey(rec.CategoryName)]; ok && rec.Operation.CategoryID == uuid.Nil {
+			rec.Operation.CategoryID = id
+		}
+		for j, name := range rec.SplitCategories {
+			if id, ok := ids[categoryKey(name)]; ok && j < len(rec.Operation.Splits) && rec.Operation.Splits[j].CategoryID == uuid.Nil {
+				rec.Operation.Splits[j].CategoryID = id
+			}
+		}
+	}
+}
+
+func categoryKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
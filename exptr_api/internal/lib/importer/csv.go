@@ -0,0 +1,273 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// CSVParser reads a CSV statement described by a mapping profile.
+type CSVParser struct {
+	Mapping domain.CSVMapping
+}
+
+func (p CSVParser) Parse(r io.Reader) ([]Record, error) {
+	const fn = "importer.CSVParser.Parse"
+
+	m := p.Mapping
+
+	r, err := decode(r, m.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	if m.Delimiter != "" {
+		delimiter, _ := utf8.DecodeRuneInString(m.Delimiter)
+		if m.Delimiter == `\t` {
+			delimiter = '\t'
+		}
+		reader.Comma = delimiter
+	}
+
+	var rows [][]string
+	var lines []int
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+
+	if m.SkipRows > len(rows) {
+		return nil, fmt.Errorf("%s: file has fewer than %d rows", fn, m.SkipRows)
+	}
+	rows, lines = rows[m.SkipRows:], lines[m.SkipRows:]
+
+	var header []string
+	if !m.NoHeader {
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("%s: missing header row", fn)
+		}
+		header = rows[0]
+		rows, lines = rows[1:], lines[1:]
+	}
+
+	cols, err := resolveColumns(m, header)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	layout := DateLayout(m.DateFormat)
+
+	records := make([]Record, 0, len(rows))
+	for i, row := range rows {
+		if blank(row) {
+			continue
+		}
+		records = append(records, p.record(lines[i], row, cols, layout))
+	}
+
+	return records, nil
+}
+
+type columnIndex map[string]int
+
+func (c columnIndex) get(row []string, field string) string {
+	i, ok := c[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func resolveColumns(m domain.CSVMapping, header []string) (columnIndex, error) {
+	fields := map[string]string{
+		"date":      m.Columns.Date,
+		"amount":    m.Columns.Amount,
+		"debit":     m.Columns.Debit,
+		"credit":    m.Columns.Credit,
+		"currency":  m.Columns.Currency,
+		"name":      m.Columns.Name,
+		"comment":   m.Columns.Comment,
+		"type":      m.Columns.Type,
+		"category":  m.Columns.Category,
+		"reference": m.Columns.Reference,
+	}
+
+	cols := make(columnIndex)
+	for field, ref := range fields {
+		if ref == "" {
+			continue
+		}
+		i, err := columnNumber(ref, header)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", field, err)
+		}
+		cols[field] = i
+	}
+
+	if _, ok := cols["date"]; !ok {
+		return nil, errors.New("date column is required")
+	}
+	if m.SignConvention == domain.SignDebitCredit {
+		_, debit := cols["debit"]
+		_, credit := cols["credit"]
+		if !debit && !credit {
+			return nil, errors.New("debit or credit column is required")
+		}
+	} else if _, ok := cols["amount"]; !ok {
+		return nil, errors.New("amount column is required")
+	}
+	if m.SignConvention == domain.SignTypeColumn {
+		if _, ok := cols["type"]; !ok {
+			return nil, errors.New("type column is required")
+		}
+	}
+
+	return cols, nil
+}
+
+func columnNumber(ref string, header []string) (int, error) {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(ref)) {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n > 0 {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("%q not found", ref)
+}
+
+func (p CSVParser) record(line int, row []string, cols columnIndex, layout string) Record {
+	m := p.Mapping
+	rec := Record{
+		Line:         line,
+		CategoryName: cols.get(row, "category"),
+		ExternalID:   cols.get(row, "reference"),
+	}
+	op := &rec.Operation
+
+	op.Name = cols.get(row, "name")
+	op.Comment = cols.get(row, "comment")
+	op.Currency = cols.get(row, "currency")
+	if op.Currency == "" {
+		op.Currency = m.DefaultCurrency
+	}
+
+	date, err := ParseDate(cols.get(row, "date"), layout)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.CreatedAt = date
+
+	exponent := currency.Exponent(op.Currency)
+	parse := func(field string) (int, error) {
+		return ParseAmount(cols.get(row, field), m.DecimalSeparator, m.ThousandsSeparator, exponent)
+	}
+
+	var amount int
+	switch m.SignConvention {
+	case domain.SignDebitCredit:
+		debit, errDebit := parse("debit")
+		credit, errCredit := parse("credit")
+		if errDebit != nil && !errors.Is(errDebit, errEmptyAmount) {
+			rec.Error = errDebit.Error()
+			return rec
+		}
+		if errCredit != nil && !errors.Is(errCredit, errEmptyAmount) {
+			rec.Error = errCredit.Error()
+			return rec
+		}
+		amount = abs(credit) - abs(debit)
+	default:
+		amount, err = parse("amount")
+		if err != nil {
+			rec.Error = err.Error()
+			return rec
+		}
+	}
+
+	switch m.SignConvention {
+	case domain.SignPositiveExpense:
+		amount = -amount
+	case domain.SignTypeColumn:
+		amount = abs(amount)
+		if !p.isIncome(cols.get(row, "type")) {
+			amount = -amount
+		}
+	}
+
+	op.Amount, op.Type = signed(amount)
+
+	return rec
+}
+
+func (p CSVParser) isIncome(value string) bool {
+	values := p.Mapping.IncomeValues
+	if len(values) == 0 {
+		values = []string{TypeIncome, "credit", "cr", "+"}
+	}
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(value), v) {
+			return true
+		}
+	}
+	return false
+}
+
+// decode converts the input into UTF-8 and strips a byte order mark.
+func decode(r io.Reader, encoding string) (io.Reader, error) {
+	if encoding != "" && !strings.EqualFold(encoding, "utf-8") && !strings.EqualFold(encoding, "utf8") {
+		enc, err := htmlindex.Get(encoding)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported encoding %q", encoding)
+		}
+		r = enc.NewDecoder().Reader(r)
+	}
+
+	br := bufio.NewReader(r)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = br.Discard(3)
+	}
+
+	return br, nil
+}
+
+func blank(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,254 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		in        string
+		dec, thou string
+		exp       int
+		want      int
+		wantErr   bool
+	}{
+		{in: "12.50", exp: 2, want: 1250},
+		{in: "-1,234.5", thou: ",", exp: 2, want: -123450},
+		{in: "1.234,56 €", dec: ",", thou: ".", exp: 2, want: 123456},
+		{in: "1 234,56", dec: ",", thou: " ", exp: 2, want: 123456},
+		{in: "(45.10)", exp: 2, want: -4510},
+		{in: "45.10-", exp: 2, want: -4510},
+		{in: "0.125", exp: 2, want: 13},
+		{in: "1500", exp: 0, want: 1500},
+		{in: "USD 7", exp: 2, want: 700},
+		{in: "", exp: 2, wantErr: true},
+		{in: "1.2.3", exp: 2, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParseAmount(tc.in, tc.dec, tc.thou, tc.exp)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestCSVParserSignedAmounts(t *testing.T) {
+	input := "Booking date;Payee;Purpose;Amount;Currency\n" +
+		"02.01.2024;LIDL;Groceries;-12,50;EUR\n" +
+		"03.01.2024;ACME GmbH;Salary;3.000,00;EUR\n" +
+		"\n" +
+		"bad date;X;;1,00;EUR\n"
+
+	parser := CSVParser{Mapping: domain.CSVMapping{
+		Delimiter:          ";",
+		DateFormat:         "DD.MM.YYYY",
+		DecimalSeparator:   ",",
+		ThousandsSeparator: ".",
+		Columns: domain.CSVColumns{
+			Date:     "Booking date",
+			Name:     "Payee",
+			Comment:  "Purpose",
+			Amount:   "Amount",
+			Currency: "Currency",
+		},
+	}}
+
+	records, err := parser.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	require.Equal(t, 2, records[0].Line)
+	require.Equal(t, "LIDL", records[0].Operation.Name)
+	require.Equal(t, 1250, records[0].Operation.Amount)
+	require.Equal(t, TypeExpense, records[0].Operation.Type)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), records[0].Operation.CreatedAt)
+
+	require.Equal(t, 300000, records[1].Operation.Amount)
+	require.Equal(t, TypeIncome, records[1].Operation.Type)
+
+	require.Equal(t, 5, records[2].Line)
+	require.NotEmpty(t, records[2].Error)
+}
+
+func TestCSVParserDebitCreditWithEncoding(t *testing.T) {
+	input := "Дата,Описание,Списание,Зачисление\n" +
+		"2024-01-05,Кофе,250.00,\n" +
+		"2024-01-06,Зарплата,,100000.00\n"
+
+	encoded, err := charmap.Windows1251.NewEncoder().Bytes([]byte(input))
+	require.NoError(t, err)
+
+	parser := CSVParser{Mapping: domain.CSVMapping{
+		Encoding:        "windows-1251",
+		SignConvention:  domain.SignDebitCredit,
+		DefaultCurrency: "RUB",
+		Columns: domain.CSVColumns{
+			Date:   "Дата",
+			Name:   "Описание",
+			Debit:  "Списание",
+			Credit: "Зачисление",
+		},
+	}}
+
+	records, err := parser.Parse(bytes.NewReader(encoded))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "Кофе", records[0].Operation.Name)
+	require.Equal(t, 25000, records[0].Operation.Amount)
+	require.Equal(t, TypeExpense, records[0].Operation.Type)
+	require.Equal(t, "RUB", records[0].Operation.Currency)
+	require.Equal(t, TypeIncome, records[1].Operation.Type)
+}
+
+func TestPrepareResolvesCategories(t *testing.T) {
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food"}
+	fallback := uuid.New()
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Line: 1, CategoryName: "food", Operation: opRequest("Bakery", 300, day)},
+		{Line: 2, Operation: opRequest("Unknown", 100, day)},
+		{Line: 3, Operation: opRequest("Zero", 0, day)},
+		{Line: 4, Error: "invalid date"},
+	}
+
+	results := Prepare(records, Options{
+		UserID:            uuid.New(),
+		DefaultCurrency:   "eur",
+		DefaultCategoryID: &fallback,
+		Categories:        []domain.Category{food},
+	})
+
+	require.Equal(t, StatusReady, results[0].Status)
+	require.Equal(t, food.ID, results[0].Operation.CategoryID)
+	require.Equal(t, "EUR", results[0].Operation.Currency)
+	require.Equal(t, fallback, results[1].Operation.CategoryID)
+	require.Equal(t, StatusError, results[2].Status)
+	require.Equal(t, "amount is required", results[2].Error)
+	require.Equal(t, "invalid date", results[3].Error)
+	require.Len(t, Ready(results), 2)
+}
+
+func TestPrepareTruncatesLongNamesByCharacter(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	name := strings.Repeat("Оплата ", 60)
+
+	results := Prepare([]Record{{Line: 1, Operation: opRequest(name, 100, day)}}, Options{
+		UserID:            uuid.New(),
+		DefaultCurrency:   "RUB",
+		DefaultCategoryID: uuidPtr(),
+	})
+
+	require.Equal(t, StatusReady, results[0].Status)
+	got := results[0].Operation.Name
+	require.True(t, utf8.ValidString(got))
+	require.Equal(t, 255, utf8.RuneCountInString(got))
+	require.True(t, strings.HasPrefix(strings.TrimSpace(name), got))
+}
+
+func opRequest(name string, amount int, date time.Time) models.OperationRequest {
+	return models.OperationRequest{Name: name, Amount: amount, Type: TypeExpense, CreatedAt: date}
+}
+
+func uuidPtr() *uuid.UUID {
+	id := uuid.New()
+	return &id
+}
+
+func TestPrepareRunsRules(t *testing.T) {
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food"}
+	music := uuid.New()
+	fallback := uuid.New()
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	set, err := rules.Compile([]domain.Rule{{
+		BaseEntity: domain.BaseEntity{ID: uuid.New()},
+		Enabled:    true,
+		Conditions: domain.RuleConditions{Name: &domain.TextCondition{Contains: "spotify"}},
+		Actions:    domain.RuleActions{CategoryID: &music, Tags: []string{"subscription"}, Name: "Spotify"},
+	}})
+	require.NoError(t, err)
+
+	records := []Record{
+		{Line: 1, Operation: opRequest("SPOTIFY P1234", 999, day)},
+		{Line: 2, CategoryName: "food", Operation: opRequest("Spotify gift card", 2500, day)},
+		{Line: 3, Operation: opRequest("Bakery", 300, day)},
+	}
+
+	results := Prepare(records, Options{
+		UserID:            uuid.New(),
+		DefaultCurrency:   "EUR",
+		DefaultCategoryID: &fallback,
+		Categories:        []domain.Category{food},
+		Rules:             set,
+	})
+
+	require.Equal(t, music, results[0].Operation.CategoryID)
+	require.Equal(t, "Spotify", results[0].Operation.Name)
+	require.Equal(t, []string{"subscription"}, results[0].Operation.Tags)
+	require.Equal(t, food.ID, results[1].Operation.CategoryID)
+	require.Equal(t, fallback, results[2].Operation.CategoryID)
+}
+
+type fixedSuggester struct {
+	suggestion models.CategorySuggestion
+}
+
+func (s fixedSuggester) Suggest(op models.OperationRequest) (models.CategorySuggestion, bool) {
+	return s.suggestion, op.Name != "Unknown"
+}
+
+func (s fixedSuggester) Confident(suggestion models.CategorySuggestion) bool {
+	return suggestion.Confidence >= 0.6
+}
+
+func TestPrepareSuggestsCategories(t *testing.T) {
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food"}
+	suggested := uuid.New()
+	fallback := uuid.New()
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	records := []Record{
+		{Line: 1, Operation: opRequest("Bakery", 300, day)},
+		{Line: 2, CategoryName: "food", Operation: opRequest("Bakery", 300, day)},
+		{Line: 3, Operation: opRequest("Unknown", 300, day)},
+	}
+	opts := Options{
+		UserID:            uuid.New(),
+		DefaultCurrency:   "EUR",
+		DefaultCategoryID: &fallback,
+		Categories:        []domain.Category{food},
+		Suggester:         fixedSuggester{models.CategorySuggestion{CategoryID: suggested, Confidence: 0.9}},
+	}
+
+	results := Prepare(records, opts)
+	require.Equal(t, suggested, results[0].Operation.CategoryID)
+	require.NotNil(t, results[0].Suggestion)
+	require.Equal(t, food.ID, results[1].Operation.CategoryID)
+	require.Nil(t, results[1].Suggestion)
+	require.Equal(t, fallback, results[2].Operation.CategoryID)
+
+	// An unsure suggestion is reported but the default category is used.
+	opts.Suggester = fixedSuggester{models.CategorySuggestion{CategoryID: suggested, Confidence: 0.3}}
+	results = Prepare(records[:1], opts)
+	require.Equal(t, fallback, results[0].Operation.CategoryID)
+	require.Equal(t, suggested, results[0].Suggestion.CategoryID)
+}
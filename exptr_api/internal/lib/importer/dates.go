@@ -0,0 +1,43 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var dateTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// DateLayout turns a pattern such as DD.MM.YYYY into a Go time layout.
+// Patterns that already are Go layouts are returned unchanged.
+func DateLayout(pattern string) string {
+	if pattern == "" {
+		return "2006-01-02"
+	}
+	if strings.Contains(pattern, "2006") || strings.Contains(pattern, "06") && strings.Contains(pattern, "01") {
+		return pattern
+	}
+	return dateTokens.Replace(pattern)
+}
+
+// ParseDate parses a date with the layout, falling back to ISO 8601.
+func ParseDate(value, layout string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(layout, value); err == nil {
+		return t, nil
+	}
+	for _, fallback := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(fallback, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
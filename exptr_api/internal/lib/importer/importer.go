@@ -0,0 +1,293 @@
+// Package importer turns bank statements and exports of other finance apps
+// into operation requests. Every format produces Records which go through
+// the same Prepare step before they are previewed or stored.
+package importer
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeIncome  = "income"
+	TypeExpense = "expense"
+)
+
+const (
+	StatusReady     = "ready"
+	StatusImported  = "imported"
+	StatusDuplicate = "duplicate"
+	StatusSuspected = "suspected"
+	StatusError     = "error"
+)
+
+// Record is a single parsed statement row. Parsers set Error instead of
+// failing the whole file when one row is malformed. SplitCategories names
+// the categories of Operation.Splits, in order.
+type Record struct {
+	Line            int
+	Operation       models.OperationRequest
+	CategoryName    string
+	SplitCategories []string
+	ExternalID      string
+	Error           string
+}
+
+type Parser interface {
+	Parse(r io.Reader) ([]Record, error)
+}
+
+// Options of Prepare. Rules run on every row; a category they set beats
+// DefaultCategoryID but not a category named by the statement. Rows still
+// without a category are then offered to Suggester, whose confident
+// suggestions beat DefaultCategoryID too.
+type Options struct {
+	UserID            uuid.UUID
+	DefaultCurrency   string
+	DefaultCategoryID *uuid.UUID
+	Categories        []domain.Category
+	Rules             *rules.Set
+	Suggester         Suggester
+}
+
+// Suggester proposes a category for an operation. Confident tells whether a
+// suggestion may be applied or only shown.
+type Suggester interface {
+	Suggest(op models.OperationRequest) (models.CategorySuggestion, bool)
+	Confident(suggestion models.CategorySuggestion) bool
+}
+
+// Prepare validates records and resolves their currency and category.
+// Records without an external id get a fingerprint of payee, date and amount.
+func Prepare(records []Record, opts Options) []models.ImportRow {
+	byName := make(map[string]uuid.UUID, len(opts.Categories))
+	for _, c := range opts.Categories {
+		byName[strings.ToLower(strings.TrimSpace(c.Name))] = c.ID
+	}
+	seen := make(map[string]int)
+
+	results := make([]models.ImportRow, 0, len(records))
+	for _, rec := range records {
+		res := models.ImportRow{
+			Line:       rec.Line,
+			Status:     StatusReady,
+			ExternalID: rec.ExternalID,
+			Operation:  rec.Operation,
+		}
+		if rec.Error == "" {
+			rec.Error = prepare(&res, rec, byName, opts)
+		}
+		if rec.Error != "" {
+			res.Status = StatusError
+			res.Error = rec.Error
+		} else if res.ExternalID == "" {
+			res.ExternalID = fingerprint(res.Operation, seen)
+		}
+		res.Operation.ExternalID = res.ExternalID
+		results = append(results, res)
+	}
+
+	return results
+}
+
+func prepare(res *models.ImportRow, rec Record, byName map[string]uuid.UUID, opts Options) string {
+	op := &res.Operation
+	categoryName := rec.CategoryName
+	op.UserID = opts.UserID
+
+	if op.Currency == "" {
+		op.Currency = opts.DefaultCurrency
+	}
+	op.Currency = currency.Normalize(op.Currency)
+	if !currency.IsValid(op.Currency) {
+		return fmt.Sprintf("unknown currency %q", op.Currency)
+	}
+
+	if op.CategoryID == uuid.Nil && categoryName != "" {
+		if id, ok := byName[strings.ToLower(strings.TrimSpace(categoryName))]; ok {
+			op.CategoryID = id
+		}
+	}
+	if opts.Rules != nil {
+		opts.Rules.Evaluate(rules.FromRequest(op)).Apply(op)
+	}
+	if op.CategoryID == uuid.Nil && opts.Suggester != nil {
+		if suggestion, ok := opts.Suggester.Suggest(*op); ok {
+			res.Suggestion = &suggestion
+			if opts.Suggester.Confident(suggestion) {
+				op.CategoryID = suggestion.CategoryID
+			}
+		}
+	}
+	if op.CategoryID == uuid.Nil && opts.DefaultCategoryID != nil {
+		op.CategoryID = *opts.DefaultCategoryID
+	}
+	if op.CategoryID == uuid.Nil {
+		if categoryName != "" {
+			return fmt.Sprintf("category %q not found", categoryName)
+		}
+		return "category is required"
+	}
+
+	total := 0
+	for i := range op.Splits {
+		split := &op.Splits[i]
+		if split.CategoryID == uuid.Nil && i < len(rec.SplitCategories) {
+			name := rec.SplitCategories[i]
+			id, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return fmt.Sprintf("category %q not found", name)
+			}
+			split.CategoryID = id
+		}
+		if split.CategoryID == uuid.Nil {
+			return "split category is required"
+		}
+		total += split.Amount
+	}
+	if len(op.Splits) > 0 && total != op.Amount {
+		return "splits do not add up to the amount"
+	}
+
+	op.Name = strings.TrimSpace(op.Name)
+	if op.Name == "" {
+		op.Name = strings.TrimSpace(op.Comment)
+	}
+	if op.Name == "" {
+		return "name is required"
+	}
+	// The column holds 255 characters; cutting bytes could split a rune.
+	if name := []rune(op.Name); len(name) > 255 {
+		op.Name = string(name[:255])
+	}
+
+	if op.Amount == 0 {
+		return "amount is required"
+	}
+	if op.CreatedAt.IsZero() {
+		return "date is required"
+	}
+	if op.Type != TypeIncome && op.Type != TypeExpense {
+		return fmt.Sprintf("unknown operation type %q", op.Type)
+	}
+
+	return ""
+}
+
+// Ready returns the operations of rows that passed preparation.
+func Ready(results []models.ImportRow) []models.OperationRequest {
+	var ops []models.OperationRequest
+	for _, res := range results {
+		if res.Status == StatusReady {
+			ops = append(ops, res.Operation)
+		}
+	}
+	return ops
+}
+
+// ExternalIDs returns the external ids of rows that passed preparation.
+func ExternalIDs(results []models.ImportRow) []string {
+	var ids []string
+	for _, res := range results {
+		if res.Status == StatusReady && res.ExternalID != "" {
+			ids = append(ids, res.ExternalID)
+		}
+	}
+	return ids
+}
+
+// MarkDuplicates flags ready rows whose external id was imported before.
+func MarkDuplicates(results []models.ImportRow, existing []string) {
+	known := make(map[string]bool, len(existing))
+	for _, id := range existing {
+		known[id] = true
+	}
+	for i := range results {
+		if results[i].Status == StatusReady && known[results[i].ExternalID] {
+			results[i].Status = StatusDuplicate
+			results[i].Error = "already imported"
+		}
+	}
+}
+
+// MarkSuspected flags ready rows that look like an operation already stored,
+// typically one entered by hand before the statement was imported.
+func MarkSuspected(results []models.ImportRow, existing []domain.Operation, opts dedup.Options) {
+	for i := range results {
+		if results[i].Status != StatusReady {
+			continue
+		}
+		pair, ok := dedup.Best(candidate(results[i].Operation), existing, opts)
+		if !ok {
+			continue
+		}
+		stored := pair.Keep
+		if stored.ID == uuid.Nil {
+			stored = pair.Drop
+		}
+		results[i].Status = StatusSuspected
+		results[i].Error = fmt.Sprintf("possible duplicate of %q on %s",
+			stored.Name, stored.CreatedAt.Format("2006-01-02"))
+		results[i].DuplicateOf = &stored.ID
+		results[i].Score = pair.Score
+	}
+}
+
+// Span returns the earliest and latest dates of ready rows.
+func Span(results []models.ImportRow) (from, to time.Time) {
+	for _, res := range results {
+		if res.Status != StatusReady {
+			continue
+		}
+		date := res.Operation.CreatedAt
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if date.After(to) {
+			to = date
+		}
+	}
+	return from, to
+}
+
+func candidate(op models.OperationRequest) domain.Operation {
+	return domain.Operation{
+		BaseEntity: domain.BaseEntity{CreatedAt: op.CreatedAt},
+		Amount:     op.Amount,
+		Currency:   op.Currency,
+		Name:       op.Name,
+		Type:       op.Type,
+		ExternalID: op.ExternalID,
+	}
+}
+
+// fingerprint identifies a record by payee, date and amount. Identical
+// records within one statement are told apart by their occurrence number so
+// two equal purchases on the same day are both kept.
+func fingerprint(op models.OperationRequest, seen map[string]int) string {
+	key := fmt.Sprintf("%s|%s|%d|%s|%s",
+		strings.ToLower(op.Name), op.CreatedAt.Format("2006-01-02"), op.Amount, op.Currency, op.Type)
+	seen[key]++
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", key, seen[key])))
+	return "fp:" + hex.EncodeToString(sum[:10])
+}
+
+// signed splits a signed amount into its absolute value and operation type.
+func signed(amount int) (int, string) {
+	if amount < 0 {
+		return -amount, TypeExpense
+	}
+	return amount, TypeIncome
+}
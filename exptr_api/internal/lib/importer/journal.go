@@ -0,0 +1,531 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/lib/export"
+	"alex_gorbunov_exptr_api/internal/models"
+)
+
+// JournalParser reads ledger, hledger and beancount journals, including the
+// ones written by the export package. Every transaction becomes one
+// operation: its Expenses or Income posting gives the category, amount and
+// type, and its Assets posting gives the account. Several Expenses or Income
+// postings become the operation's splits. Other directives (open, price,
+// balance, commodity, ...) are skipped.
+type JournalParser struct {
+	Beancount bool
+}
+
+type journalPosting struct {
+	account  string
+	amount   *int
+	currency string
+	// total is the settled amount from a @@ total or @ unit price.
+	total         *int
+	totalCurrency string
+	meta          map[string]string
+}
+
+type journalTransaction struct {
+	line      int
+	date      time.Time
+	payee     string
+	narration string
+	notes     []string
+	meta      map[string]string
+	postings  []journalPosting
+	err       string
+}
+
+var (
+	journalMeta     = regexp.MustCompile(`^([A-Za-z_][\w-]*):\s*(.*)$`)
+	beancountMeta   = regexp.MustCompile(`^([a-z][\w-]*):\s+(.*)$`)
+	postingSplit    = regexp.MustCompile(`\s{2,}|\t`)
+	beancountSkip   = map[string]bool{"open": true, "close": true, "balance": true, "pad": true, "price": true, "commodity": true, "note": true, "event": true, "document": true, "query": true, "custom": true}
+	currencySymbols = map[string]string{"$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "₽": "RUB"}
+)
+
+func (p JournalParser) Parse(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var records []Record
+	var txn *journalTransaction
+	skipping := false
+	line := 0
+
+	flush := func() {
+		if txn != nil {
+			records = append(records, txn.record())
+			txn = nil
+		}
+	}
+
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff")
+		}
+
+		if text == "" {
+			flush()
+			skipping = false
+			continue
+		}
+
+		if text[0] == ' ' || text[0] == '\t' {
+			if txn == nil || skipping {
+				continue
+			}
+			p.parseIndented(txn, strings.TrimSpace(text))
+			continue
+		}
+
+		flush()
+		skipping = false
+
+		if !unicode.IsDigit(rune(text[0])) {
+			// Comments and non-transaction directives of either dialect.
+			skipping = true
+			continue
+		}
+
+		txn = p.parseHeader(line, text)
+		if txn == nil {
+			skipping = true
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// parseHeader reads the first line of a transaction. It returns nil for
+// dated directives that are not transactions.
+func (p JournalParser) parseHeader(line int, text string) *journalTransaction {
+	txn := &journalTransaction{line: line, meta: map[string]string{}}
+
+	dateText, rest, _ := strings.Cut(text, " ")
+	dateText, _, _ = strings.Cut(dateText, "=")
+	date, err := time.Parse("2006-01-02", strings.NewReplacer("/", "-", ".", "-").Replace(dateText))
+	if err != nil {
+		txn.err = fmt.Sprintf("invalid date %q", dateText)
+		return txn
+	}
+	txn.date = date
+	rest = strings.TrimSpace(rest)
+
+	if p.Beancount {
+		keyword, after, _ := strings.Cut(rest, " ")
+		if beancountSkip[keyword] {
+			return nil
+		}
+		if keyword == "*" || keyword == "!" || keyword == "txn" {
+			rest = strings.TrimSpace(after)
+		}
+
+		var texts []string
+		for strings.HasPrefix(rest, `"`) {
+			value, remainder, ok := unquote(rest)
+			if !ok {
+				txn.err = "unterminated string"
+				return txn
+			}
+			texts = append(texts, value)
+			rest = strings.TrimSpace(remainder)
+		}
+		switch len(texts) {
+		case 1:
+			txn.narration = texts[0]
+		case 2:
+			txn.payee, txn.narration = texts[0], texts[1]
+		}
+		return txn
+	}
+
+	if strings.HasPrefix(rest, "* ") || strings.HasPrefix(rest, "! ") {
+		rest = strings.TrimSpace(rest[2:])
+	}
+	if strings.HasPrefix(rest, "(") {
+		if end := strings.IndexByte(rest, ')'); end > 0 {
+			rest = strings.TrimSpace(rest[end+1:])
+		}
+	}
+	if i := strings.Index(rest, "  ;"); i >= 0 {
+		txn.notes = append(txn.notes, strings.TrimSpace(rest[i+3:]))
+		rest = strings.TrimSpace(rest[:i])
+	}
+	txn.payee = rest
+
+	return txn
+}
+
+func (p JournalParser) parseIndented(txn *journalTransaction, text string) {
+	if text[0] == ';' || text[0] == '#' {
+		if p.Beancount {
+			return
+		}
+		comment := strings.TrimSpace(text[1:])
+		if m := journalMeta.FindStringSubmatch(comment); m != nil {
+			txn.setMeta(strings.ToLower(m[1]), unescape(m[2]))
+		} else if comment != "" {
+			txn.notes = append(txn.notes, comment)
+		}
+		return
+	}
+
+	if p.Beancount {
+		if m := beancountMeta.FindStringSubmatch(text); m != nil {
+			value := m[2]
+			if unquoted, _, ok := unquote(value); ok {
+				value = unquoted
+			}
+			txn.setMeta(m[1], value)
+			return
+		}
+	}
+
+	posting, err := parsePosting(text)
+	if err != nil {
+		if txn.err == "" {
+			txn.err = err.Error()
+		}
+		return
+	}
+	txn.postings = append(txn.postings, posting)
+}
+
+func parsePosting(text string) (journalPosting, error) {
+	if i := strings.IndexByte(text, ';'); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+
+	parts := postingSplit.Split(text, 2)
+	posting := journalPosting{account: strings.TrimSpace(parts[0])}
+	if len(parts) == 1 {
+		return posting, nil
+	}
+
+	amountText, priceText, total := strings.TrimSpace(parts[1]), "", false
+	if before, after, ok := strings.Cut(amountText, "@@"); ok {
+		amountText, priceText, total = strings.TrimSpace(before), strings.TrimSpace(after), true
+	} else if before, after, ok := strings.Cut(amountText, "@"); ok {
+		amountText, priceText = strings.TrimSpace(before), strings.TrimSpace(after)
+	}
+
+	amount, code, err := parseCommodity(amountText)
+	if err != nil {
+		return posting, err
+	}
+	posting.amount, posting.currency = &amount, code
+
+	if priceText != "" {
+		var price int
+		var priceCode string
+		if total {
+			price, priceCode, err = parseCommodity(priceText)
+		} else {
+			price, priceCode, err = parseUnitPrice(abs(amount), code, priceText)
+		}
+		if err != nil {
+			return posting, err
+		}
+		price = abs(price)
+		posting.total, posting.totalCurrency = &price, priceCode
+	}
+
+	return posting, nil
+}
+
+// parseCommodity reads amounts such as "12.50 EUR", "EUR 12.50", "$12.50"
+// or "-1,234.50 USD" into minor units.
+func parseCommodity(text string) (int, string, error) {
+	number, code, err := splitCommodity(text)
+	if err != nil {
+		return 0, "", err
+	}
+
+	negative := strings.HasPrefix(number, "-")
+	amount, err := ParseAmount(strings.TrimPrefix(number, "-"), ".", ",", currency.Exponent(code))
+	if err != nil {
+		return 0, "", err
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return amount, code, nil
+}
+
+// parseUnitPrice converts an amount at a per-unit @ price into the total in
+// the price currency.
+func parseUnitPrice(amount int, code, text string) (int, string, error) {
+	number, priceCode, err := splitCommodity(text)
+	if err != nil {
+		return 0, "", err
+	}
+
+	unit, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid price %q", text)
+	}
+
+	return currency.FromMajor(currency.ToMajor(amount, code)*unit, priceCode), priceCode, nil
+}
+
+// splitCommodity separates the signed number and the currency code of an
+// amount written with a code before or after it, or with a symbol.
+func splitCommodity(text string) (string, string, error) {
+	text = strings.TrimSpace(text)
+	negative := strings.HasPrefix(text, "-")
+	if negative {
+		text = strings.TrimSpace(text[1:])
+	}
+
+	var number, code string
+	for symbol, c := range currencySymbols {
+		if strings.HasPrefix(text, symbol) {
+			number, code = strings.TrimSpace(strings.TrimPrefix(text, symbol)), c
+			break
+		}
+	}
+
+	if code == "" {
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return "", "", fmt.Errorf("invalid amount %q", text)
+		}
+		number, code = fields[0], fields[1]
+		if isCommodity(fields[0]) {
+			number, code = fields[1], fields[0]
+		}
+		code = strings.Trim(code, `"`)
+	}
+
+	if strings.HasPrefix(number, "-") {
+		negative = !negative
+		number = number[1:]
+	}
+	if negative {
+		number = "-" + number
+	}
+
+	return number, currency.Normalize(code), nil
+}
+
+func isCommodity(s string) bool {
+	for _, r := range s {
+		if !unicode.IsLetter(r) && r != '"' && r != '_' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// setMeta keeps the category and note written after an Expenses or Income
+// posting on that posting; all other metadata describes the transaction.
+func (txn *journalTransaction) setMeta(key, value string) {
+	if n := len(txn.postings); n > 0 && (key == export.MetaCategory || key == export.MetaNote) {
+		if posting := &txn.postings[n-1]; isCategoryAccount(posting.account) {
+			if posting.meta == nil {
+				posting.meta = map[string]string{}
+			}
+			posting.meta[key] = value
+			return
+		}
+	}
+	txn.meta[key] = value
+}
+
+func (txn *journalTransaction) record() Record {
+	rec := Record{Line: txn.line, Error: txn.err}
+	if rec.Error != "" {
+		return rec
+	}
+
+	var categories []*journalPosting
+	var asset *journalPosting
+	for i := range txn.postings {
+		posting := &txn.postings[i]
+		if isCategoryAccount(posting.account) {
+			categories = append(categories, posting)
+		} else if asset == nil {
+			asset = posting
+		}
+	}
+	if len(categories) == 0 {
+		rec.Error = "transaction has no Expenses or Income posting"
+		return rec
+	}
+	category := categories[0]
+
+	amount, code := category.amount, category.currency
+	if len(categories) > 1 {
+		sum := 0
+		for _, posting := range categories {
+			if posting.amount == nil {
+				rec.Error = "every Expenses or Income posting of a split transaction needs an amount"
+				return rec
+			}
+			if posting.currency != code {
+				rec.Error = "the Expenses and Income postings of a transaction must share a currency"
+				return rec
+			}
+			sum += *posting.amount
+		}
+		amount = &sum
+	}
+	if amount == nil {
+		if asset == nil || asset.amount == nil {
+			rec.Error = "transaction amount is missing"
+			return rec
+		}
+		negated := -*asset.amount
+		amount, code = &negated, asset.currency
+	}
+
+	op := &rec.Operation
+	op.CreatedAt = txn.date
+	op.Currency = code
+	op.Amount, op.Type = signed(-*amount)
+
+	if settled, settledCode := settledTotal(categories); settled != nil {
+		op.SettledAmount, op.SettledCurrency = settled, settledCode
+	} else if asset != nil && asset.amount != nil && asset.currency != code {
+		settled := abs(*asset.amount)
+		op.SettledAmount, op.SettledCurrency = &settled, asset.currency
+	}
+
+	op.Name = txn.payee
+	op.Comment = txn.narration
+	if op.Name == "" {
+		op.Name, op.Comment = txn.narration, ""
+	}
+	if comment, ok := txn.meta[export.MetaComment]; ok {
+		op.Comment = comment
+	} else if op.Comment == "" && len(txn.notes) > 0 {
+		op.Comment = strings.Join(txn.notes, "\n")
+	}
+
+	if len(categories) > 1 {
+		// Split amounts follow the operation's sign, so they add up to it.
+		sign := 1
+		if op.Type == TypeIncome {
+			sign = -1
+		}
+		for _, posting := range categories {
+			op.Splits = append(op.Splits, models.SplitRequest{
+				Amount: sign * *posting.amount,
+				Note:   posting.meta[export.MetaNote],
+			})
+			rec.SplitCategories = append(rec.SplitCategories, postingCategory(posting))
+		}
+	}
+
+	if name, ok := txn.meta[export.MetaCategory]; ok {
+		rec.CategoryName = name
+	} else {
+		rec.CategoryName = postingCategory(category)
+	}
+
+	if name, ok := txn.meta[export.MetaAccount]; ok {
+		op.Account = name
+	} else if asset != nil && asset.account != export.UnassignedAccount {
+		op.Account = export.NameFromAccount(strings.TrimPrefix(asset.account, export.AccountAssets+":"))
+	}
+
+	rec.ExternalID = txn.meta[export.MetaExternalID]
+
+	return rec
+}
+
+// settledTotal adds up the @@ totals of the category postings. Totals carry
+// no sign, so each takes the sign of its posting. It returns nil unless
+// every posting has a total in the same currency.
+func settledTotal(postings []*journalPosting) (*int, string) {
+	code := postings[0].totalCurrency
+	sum := 0
+	for _, posting := range postings {
+		if posting.total == nil || posting.totalCurrency != code {
+			return nil, ""
+		}
+		if *posting.amount < 0 {
+			sum -= *posting.total
+		} else {
+			sum += *posting.total
+		}
+	}
+	sum = abs(sum)
+	return &sum, code
+}
+
+// postingCategory names the category of an Expenses or Income posting, from
+// its metadata or else from the account.
+func postingCategory(posting *journalPosting) string {
+	if name, ok := posting.meta[export.MetaCategory]; ok {
+		return name
+	}
+	_, path, _ := strings.Cut(posting.account, ":")
+	return export.NameFromAccount(path)
+}
+
+func isCategoryAccount(account string) bool {
+	root, _, _ := strings.Cut(account, ":")
+	return root == export.AccountExpenses || root == export.AccountIncome
+}
+
+// unquote reads a leading double-quoted string and returns the rest.
+func unquote(s string) (string, string, bool) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					b.WriteByte('\n')
+				} else {
+					b.WriteByte(s[i])
+				}
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", s, false
+}
+
+// unescape reverses the escaping of ledger metadata values.
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
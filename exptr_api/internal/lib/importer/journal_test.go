@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/export"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalRoundTrip(t *testing.T) {
+	userID := uuid.New()
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food & drinks"}
+	salary := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Salary"}
+	travel := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Travel"}
+	settled, settledSplit := 1163, 2791
+	rate := 0.9304
+
+	ops := []domain.Operation{
+		{
+			BaseEntity: domain.BaseEntity{CreatedAt: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+			CategoryID: food.ID,
+			Name:       "Lidl",
+			Comment:    "weekly \"big\" shop\nwith receipts",
+			Amount:     1250,
+			Currency:   "EUR",
+			Type:       TypeExpense,
+			Account:    "Checking",
+			ExternalID: "camt:2024010200001",
+		},
+		{
+			BaseEntity: domain.BaseEntity{CreatedAt: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+			CategoryID: salary.ID,
+			Name:       "ACME GmbH",
+			Amount:     250000,
+			Currency:   "EUR",
+			Type:       TypeIncome,
+			Account:    "Credit card",
+		},
+		{
+			BaseEntity:      domain.BaseEntity{CreatedAt: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+			CategoryID:      travel.ID,
+			Name:            "Hotel",
+			Amount:          1250,
+			Currency:        "USD",
+			SettledAmount:   &settled,
+			SettledCurrency: "EUR",
+			EffectiveRate:   &rate,
+			Type:            TypeExpense,
+		},
+		{
+			BaseEntity:      domain.BaseEntity{CreatedAt: time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)},
+			CategoryID:      travel.ID,
+			Name:            "Airport shop",
+			Amount:          3000,
+			Currency:        "USD",
+			SettledAmount:   &settledSplit,
+			SettledCurrency: "EUR",
+			Type:            TypeExpense,
+			Splits: []domain.OperationSplit{
+				{CategoryID: food.ID, Amount: 1000, Note: "coffee \"to go\""},
+				{CategoryID: travel.ID, Amount: 2000},
+			},
+		},
+	}
+
+	categories := []domain.Category{food, salary, travel}
+	names := map[uuid.UUID]string{food.ID: food.Name, salary.ID: salary.Name, travel.ID: travel.Name}
+
+	for _, format := range []string{export.FormatLedger, export.FormatBeancount} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := export.NewWriter(&buf, format, export.Options{Categories: names})
+			require.NoError(t, err)
+			for i := range ops {
+				require.NoError(t, w.Write(&ops[i]))
+			}
+			require.NoError(t, w.Close())
+
+			records, err := JournalParser{Beancount: format == export.FormatBeancount}.Parse(&buf)
+			require.NoError(t, err)
+
+			rows := Prepare(records, Options{UserID: userID, Categories: categories})
+			require.Len(t, rows, len(ops))
+
+			for i, want := range ops {
+				row := rows[i]
+				require.Equal(t, StatusReady, row.Status, row.Error)
+				got := row.Operation
+				require.Equal(t, want.CreatedAt, got.CreatedAt)
+				require.Equal(t, want.CategoryID, got.CategoryID)
+				require.Equal(t, want.Name, got.Name)
+				require.Equal(t, want.Comment, got.Comment)
+				require.Equal(t, want.Amount, got.Amount)
+				require.Equal(t, want.Currency, got.Currency)
+				require.Equal(t, want.Type, got.Type)
+				require.Equal(t, want.Account, got.Account)
+				require.Equal(t, want.SettledAmount, got.SettledAmount)
+				require.Equal(t, want.SettledCurrency, got.SettledCurrency)
+				if want.ExternalID != "" {
+					require.Equal(t, want.ExternalID, got.ExternalID)
+				}
+				require.Len(t, got.Splits, len(want.Splits))
+				for j, split := range want.Splits {
+					require.Equal(t, split.CategoryID, got.Splits[j].CategoryID)
+					require.Equal(t, split.Amount, got.Splits[j].Amount)
+					require.Equal(t, split.Note, got.Splits[j].Note)
+				}
+			}
+		})
+	}
+}
+
+func TestJournalParserHandWritten(t *testing.T) {
+	ledger := `; personal books
+account Assets:Checking
+
+2024/03/01 * (42) Corner Cafe  ; flat white
+    Expenses:Eating-Out         $4.50
+    Assets:Checking
+
+2024-03-02 Rent
+    Expenses:Housing    1,200.00 EUR
+    Expenses:Fees          2.00 EUR
+    Assets:Checking
+
+P 2024-03-03 USD 0.92 EUR
+`
+	records, err := JournalParser{}.Parse(strings.NewReader(ledger))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	cafe := records[0]
+	require.Empty(t, cafe.Error)
+	require.Equal(t, "Corner Cafe", cafe.Operation.Name)
+	require.Equal(t, "flat white", cafe.Operation.Comment)
+	require.Equal(t, "Eating Out", cafe.CategoryName)
+	require.Equal(t, "Checking", cafe.Operation.Account)
+	require.Equal(t, 450, cafe.Operation.Amount)
+	require.Equal(t, "USD", cafe.Operation.Currency)
+	require.Equal(t, TypeExpense, cafe.Operation.Type)
+
+	rent := records[1]
+	require.Empty(t, rent.Error)
+	require.Equal(t, 120200, rent.Operation.Amount)
+	require.Equal(t, TypeExpense, rent.Operation.Type)
+	require.Equal(t, "Housing", rent.CategoryName)
+	require.Equal(t, []string{"Housing", "Fees"}, rent.SplitCategories)
+	require.Equal(t, []models.SplitRequest{{Amount: 120000}, {Amount: 200}}, rent.Operation.Splits)
+
+	beancount := `1970-01-01 open Assets:Bank
+
+2024-03-05 * "Salary for February"
+  Income:Salary  -3000 JPY @ 0.0062 EUR
+  Assets:Bank
+`
+	records, err = JournalParser{Beancount: true}.Parse(strings.NewReader(beancount))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "Salary for February", records[0].Operation.Name)
+	require.Equal(t, TypeIncome, records[0].Operation.Type)
+	require.Equal(t, 3000, records[0].Operation.Amount)
+	require.Equal(t, 1860, *records[0].Operation.SettledAmount)
+	require.Equal(t, "Bank", records[0].Operation.Account)
+}
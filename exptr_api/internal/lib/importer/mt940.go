@@ -0,0 +1,234 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// MT940Parser reads SWIFT MT940 customer statements. The :86: information
+// is understood both in the German ?-subfield layout and in the /NAME/ and
+// /REMI/ keyword layout; anything else becomes the comment as is.
+type MT940Parser struct{}
+
+var (
+	mt940Tag     = regexp.MustCompile(`^:(\d{2}[A-Z]?):(.*)$`)
+	mt940Balance = regexp.MustCompile(`^([CD])(\d{6})([A-Z]{3})([\d,]+)$`)
+	// :61: value date, optional entry date, mark, funds code, amount,
+	// transaction type, customer reference and optional bank reference.
+	mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(R?[CD])([A-Z])?([\d,]+)([NFS][A-Z0-9]{3})([^/\n]*)(?://([^\n]*))?`)
+)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+func (p MT940Parser) Parse(r io.Reader) ([]Record, error) {
+	statements, err := p.ParseStatements(r)
+	if err != nil {
+		return nil, err
+	}
+	return flatten(statements), nil
+}
+
+func (p MT940Parser) ParseStatements(r io.Reader) ([]Statement, error) {
+	const fn = "importer.MT940Parser.ParseStatements"
+
+	fields, err := mt940Fields(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var (
+		statements []Statement
+		current    *Statement
+		last       *Record
+		line       int
+	)
+
+	for _, f := range fields {
+		if f.tag == "20" {
+			statements = append(statements, Statement{ID: strings.TrimSpace(f.value)})
+			current = &statements[len(statements)-1]
+			last = nil
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%s: field :%s: before :20:", fn, f.tag)
+		}
+
+		switch f.tag {
+		case "60F", "60M":
+			amount, cur, err := mt940ParseBalance(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+			current.Opening, current.Currency = amount, cur
+		case "62F", "62M":
+			amount, _, err := mt940ParseBalance(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", fn, err)
+			}
+			current.Closing = amount
+		case "61":
+			line++
+			current.Records = append(current.Records, mt940Record(line, f.value, current.Currency))
+			last = &current.Records[len(current.Records)-1]
+		case "86":
+			if last != nil {
+				mt940ApplyInfo(last, f.value)
+			}
+		}
+	}
+
+	if len(statements) == 0 {
+		return nil, fmt.Errorf("%s: no MT940 statements found", fn)
+	}
+
+	return statements, nil
+}
+
+// mt940Fields splits the message into tagged fields, joining continuation lines.
+func mt940Fields(r io.Reader) ([]mt940Field, error) {
+	reader, err := decode(r, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []mt940Field
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		text := strings.TrimRight(scanner.Text(), "\r ")
+		if text == "" || text == "-" || strings.HasPrefix(text, "{") || strings.HasPrefix(text, "-}") {
+			continue
+		}
+		if m := mt940Tag.FindStringSubmatch(text); m != nil {
+			fields = append(fields, mt940Field{tag: m[1], value: m[2]})
+			continue
+		}
+		if len(fields) > 0 {
+			fields[len(fields)-1].value += "\n" + text
+		}
+	}
+
+	return fields, scanner.Err()
+}
+
+func mt940ParseBalance(value string) (int, string, error) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return 0, "", fmt.Errorf("invalid balance %q", value)
+	}
+	amount, err := ParseAmount(m[4], ",", "", currency.Exponent(m[3]))
+	if err != nil {
+		return 0, "", err
+	}
+	return signedBalance(amount, m[1] == "D"), m[3], nil
+}
+
+func mt940Record(line int, value, cur string) Record {
+	rec := Record{Line: line}
+	op := &rec.Operation
+	op.Currency = cur
+
+	m := mt940Line.FindStringSubmatch(value)
+	if m == nil {
+		rec.Error = fmt.Sprintf("invalid :61: line %q", strings.SplitN(value, "\n", 2)[0])
+		return rec
+	}
+
+	date, err := time.Parse("060102", m[1])
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.CreatedAt = date
+
+	amount, err := ParseAmount(m[5], ",", "", currency.Exponent(cur))
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	// RC and RD reverse a previous debit or credit.
+	debit := m[3] == "D" || m[3] == "RC"
+	op.Amount, op.Type = signed(signedBalance(amount, debit))
+
+	reference := strings.TrimSpace(m[8])
+	if reference == "" || reference == "NONREF" {
+		reference = strings.TrimSpace(m[7])
+	}
+	if reference != "" && reference != "NONREF" {
+		rec.ExternalID = "mt940:" + reference
+	}
+
+	if i := strings.IndexByte(value, '\n'); i >= 0 {
+		op.Name = strings.TrimSpace(value[i+1:])
+	}
+
+	return rec
+}
+
+var mt940Keyword = regexp.MustCompile(`/(NAME|REMI|EREF|ORDP|BENM)/`)
+
+// mt940ApplyInfo fills name and comment from the :86: information to account owner field.
+func mt940ApplyInfo(rec *Record, info string) {
+	info = strings.ReplaceAll(info, "\n", "")
+	op := &rec.Operation
+
+	var name, remittance []string
+	var bookingText string
+	switch {
+	case len(info) > 3 && info[3] == '?':
+		for _, part := range strings.Split(info[4:], "?") {
+			if len(part) < 2 {
+				continue
+			}
+			code, text := part[:2], strings.TrimSpace(part[2:])
+			switch {
+			case code == "00":
+				bookingText = text
+			case code >= "20" && code <= "29", code >= "60" && code <= "63":
+				remittance = append(remittance, text)
+			case code == "32" || code == "33":
+				name = append(name, text)
+			}
+		}
+	case mt940Keyword.MatchString(info):
+		locs := mt940Keyword.FindAllStringSubmatchIndex(info, -1)
+		for i, loc := range locs {
+			end := len(info)
+			if i+1 < len(locs) {
+				end = locs[i+1][0]
+			}
+			text := strings.TrimSpace(info[loc[1]:end])
+			switch info[loc[2]:loc[3]] {
+			case "NAME", "ORDP", "BENM":
+				name = append(name, text)
+			case "REMI":
+				remittance = append(remittance, text)
+			}
+		}
+	default:
+		remittance = append(remittance, strings.TrimSpace(info))
+	}
+
+	if len(name) > 0 {
+		op.Name = strings.Join(name, "")
+	}
+	// Remittance lines are joined like CAMT's Ustrd lines, so both formats
+	// give the same comment.
+	if len(remittance) > 0 {
+		op.Comment = strings.Join(remittance, " ")
+	} else if bookingText != "" {
+		op.Comment = bookingText
+	}
+	if op.Name == "" {
+		op.Name = op.Comment
+	}
+}
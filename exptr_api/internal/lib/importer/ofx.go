@@ -0,0 +1,259 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// OFXParser reads OFX 1.x SGML and OFX 2.x XML statements (including QFX)
+// and turns every STMTTRN into a record keyed by its FITID.
+type OFXParser struct{}
+
+// ofxTransaction holds the leaf values of a STMTTRN. Leaves nested in PAYEE,
+// CURRENCY or ORIGCURRENCY aggregates are prefixed with the aggregate name.
+// The CURDEF, BANKID and ACCTID of the enclosing statement are copied in
+// under the ofxStatement keys.
+type ofxTransaction map[string]string
+
+const (
+	ofxStatementCurrency = "STMT.CURDEF"
+	ofxStatementBank     = "STMT.BANKID"
+	ofxStatementAccount  = "STMT.ACCTID"
+)
+
+func (p OFXParser) Parse(r io.Reader) ([]Record, error) {
+	const fn = "importer.OFXParser.Parse"
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	start := bytes.Index(bytes.ToUpper(data), []byte("<OFX>"))
+	if start < 0 {
+		return nil, fmt.Errorf("%s: not an OFX document", fn)
+	}
+
+	body, err := ofxDecode(data[:start], data[start:])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	transactions := ofxScan(body)
+
+	records := make([]Record, 0, len(transactions))
+	for i, trn := range transactions {
+		records = append(records, ofxRecord(i+1, trn))
+	}
+
+	return records, nil
+}
+
+// ofxDecode converts OFX 1.x bodies declared as CHARSET:1251 or 1252 into UTF-8.
+func ofxDecode(header, body []byte) (string, error) {
+	h := strings.ToUpper(string(header))
+	switch {
+	case strings.Contains(h, "CHARSET:1251"):
+		b, err := charmap.Windows1251.NewDecoder().Bytes(body)
+		return string(b), err
+	case strings.Contains(h, "CHARSET:1252"), strings.Contains(h, "CHARSET:ISO-8859-1"):
+		b, err := charmap.Windows1252.NewDecoder().Bytes(body)
+		return string(b), err
+	default:
+		return string(body), nil
+	}
+}
+
+// ofxScan walks the tag stream. SGML leaves have no closing tag, so the value
+// of a leaf is the text up to the next tag, which works for XML as well.
+func ofxScan(body string) []ofxTransaction {
+	var (
+		transactions []ofxTransaction
+		current      ofxTransaction
+		path         []string
+		statement    = ofxTransaction{}
+	)
+
+	for len(body) > 0 {
+		open := strings.IndexByte(body, '<')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(body[open:], '>')
+		if end < 0 {
+			break
+		}
+		tag := strings.ToUpper(strings.TrimSpace(body[open+1 : open+end]))
+		body = body[open+end+1:]
+
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+
+		if strings.HasPrefix(tag, "/") {
+			name := tag[1:]
+			if name == "STMTTRN" && current != nil {
+				transactions = append(transactions, current)
+				current = nil
+			}
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == name {
+					path = path[:i]
+					break
+				}
+			}
+			continue
+		}
+
+		if tag == "STMTTRN" {
+			if current != nil {
+				transactions = append(transactions, current)
+			}
+			current = ofxTransaction{}
+			for key, value := range statement {
+				current[key] = value
+			}
+			continue
+		}
+
+		next := strings.IndexByte(body, '<')
+		if next < 0 {
+			next = len(body)
+		}
+		value := strings.TrimSpace(ofxUnescape(body[:next]))
+
+		if value == "" {
+			// an aggregate such as PAYEE or CURRENCY
+			path = append(path, tag)
+			if tag == "STMTRS" || tag == "CCSTMTRS" {
+				statement = ofxTransaction{}
+			}
+			continue
+		}
+
+		if current != nil {
+			key := tag
+			if len(path) > 0 && (path[len(path)-1] == "PAYEE" || path[len(path)-1] == "CURRENCY" || path[len(path)-1] == "ORIGCURRENCY") {
+				key = path[len(path)-1] + "." + tag
+			}
+			current[key] = value
+			continue
+		}
+
+		fromAccount := len(path) > 0 && (path[len(path)-1] == "BANKACCTFROM" || path[len(path)-1] == "CCACCTFROM")
+		switch {
+		case tag == "CURDEF":
+			statement[ofxStatementCurrency] = value
+		case tag == "BANKID" && fromAccount:
+			statement[ofxStatementBank] = value
+		case tag == "ACCTID" && fromAccount:
+			statement[ofxStatementAccount] = value
+		}
+	}
+
+	if current != nil {
+		transactions = append(transactions, current)
+	}
+
+	return transactions
+}
+
+func ofxRecord(line int, trn ofxTransaction) Record {
+	rec := Record{Line: line, ExternalID: trn["FITID"]}
+	op := &rec.Operation
+
+	op.Name = firstNonEmpty(trn["NAME"], trn["PAYEE.NAME"], trn["MEMO"])
+	op.Comment = trn["MEMO"]
+	if op.Comment == op.Name {
+		op.Comment = ""
+	}
+
+	// CURRENCY means the amounts are in that currency. ORIGCURRENCY only
+	// names where they came from: TRNAMT is already in CURDEF.
+	op.Currency = firstNonEmpty(trn["CURRENCY.CURSYM"], trn[ofxStatementCurrency])
+
+	date, err := ParseOFXDate(trn["DTPOSTED"])
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.CreatedAt = date
+
+	raw := trn["TRNAMT"]
+	decimal := "."
+	if strings.Contains(raw, ",") && !strings.Contains(raw, ".") {
+		decimal = ","
+	}
+	amount, err := ParseAmount(raw, decimal, "", currency.Exponent(op.Currency))
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.Amount, op.Type = signed(amount)
+
+	// The converted amount is what the bank settled; CURRATE (CURDEF units
+	// per unit of the original currency) leads back to the original amount.
+	if orig := currency.Normalize(trn["ORIGCURRENCY.CURSYM"]); orig != "" && orig != currency.Normalize(op.Currency) {
+		rate, err := strconv.ParseFloat(strings.Replace(trn["ORIGCURRENCY.CURRATE"], ",", ".", 1), 64)
+		if err == nil && rate > 0 {
+			settled := op.Amount
+			op.SettledAmount, op.SettledCurrency = &settled, currency.Normalize(op.Currency)
+			op.Amount = currency.FromMajor(currency.ToMajor(settled, op.SettledCurrency)/rate, orig)
+			op.Currency = orig
+		}
+	}
+
+	// FITIDs are only unique within one account.
+	if rec.ExternalID != "" {
+		account := strings.Trim(trn[ofxStatementBank]+"/"+trn[ofxStatementAccount], "/")
+		if account != "" {
+			rec.ExternalID = account + ":" + rec.ExternalID
+		}
+		rec.ExternalID = "ofx:" + rec.ExternalID
+	}
+
+	return rec
+}
+
+// ParseOFXDate parses YYYYMMDD[HHMMSS[.XXX]][[offset:TZ]] datetimes.
+func ParseOFXDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if i := strings.IndexByte(value, '['); i >= 0 {
+		value = value[:i]
+	}
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		value = value[:i]
+	}
+
+	switch len(value) {
+	case 8:
+		return time.Parse("20060102", value)
+	case 12:
+		return time.Parse("200601021504", value)
+	case 14:
+		return time.Parse("20060102150405", value)
+	default:
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+}
+
+func ofxUnescape(s string) string {
+	return strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'").Replace(s)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
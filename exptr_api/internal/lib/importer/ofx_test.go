@@ -0,0 +1,148 @@
+package importer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOFXParserSGML(t *testing.T) {
+	f, err := os.Open("testdata/statement-v1.ofx")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := OFXParser{}.Parse(f)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	first := records[0]
+	require.Empty(t, first.Error)
+	require.Equal(t, "ofx:121000248/123456789:2024010201", first.ExternalID)
+	require.Equal(t, "WHOLE FOODS & CO", first.Operation.Name)
+	require.Equal(t, "POS PURCHASE", first.Operation.Comment)
+	require.Equal(t, 4217, first.Operation.Amount)
+	require.Equal(t, TypeExpense, first.Operation.Type)
+	require.Equal(t, "USD", first.Operation.Currency)
+	require.Equal(t, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), first.Operation.CreatedAt)
+
+	require.Equal(t, TypeIncome, records[1].Operation.Type)
+	require.Equal(t, 250000, records[1].Operation.Amount)
+}
+
+func TestOFXParserXML(t *testing.T) {
+	f, err := os.Open("testdata/statement-v2.ofx")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := OFXParser{}.Parse(f)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "Spotify", records[0].Operation.Name)
+	require.Equal(t, "SEK", records[0].Operation.Currency)
+	require.Equal(t, 999, records[0].Operation.Amount)
+	require.Equal(t, "ofx:4111111111111111:CC-778", records[0].ExternalID)
+}
+
+func TestOFXParserOrigCurrency(t *testing.T) {
+	f, err := os.Open("testdata/statement-origcurrency.ofx")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := OFXParser{}.Parse(f)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// TRNAMT is already in CURDEF; ORIGCURRENCY gives the original amount.
+	converted := records[0].Operation
+	require.Empty(t, records[0].Error)
+	require.Equal(t, "USD", converted.Currency)
+	require.Equal(t, 5000, converted.Amount)
+	require.Equal(t, TypeExpense, converted.Type)
+	require.NotNil(t, converted.SettledAmount)
+	require.Equal(t, 4620, *converted.SettledAmount)
+	require.Equal(t, "EUR", converted.SettledCurrency)
+
+	require.Equal(t, "EUR", records[1].Operation.Currency)
+	require.Equal(t, 1200, records[1].Operation.Amount)
+	require.Nil(t, records[1].Operation.SettledAmount)
+
+	// Every statement has its own CURDEF.
+	require.Equal(t, "JPY", records[2].Operation.Currency)
+	require.Equal(t, 1500, records[2].Operation.Amount)
+
+	// Both accounts used FITID 1001.
+	require.Equal(t, "ofx:10020030/DE0012345678:1001", records[0].ExternalID)
+	require.Equal(t, "ofx:4111111111111111:1001", records[2].ExternalID)
+}
+
+func TestQIFParser(t *testing.T) {
+	f, err := os.Open("testdata/statement.qif")
+	require.NoError(t, err)
+	defer f.Close()
+
+	records, err := QIFParser{Currency: "USD"}.Parse(f)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+
+	require.Empty(t, records[0].Error)
+	require.Equal(t, "Rent January", records[0].Operation.Name)
+	require.Equal(t, "Housing", records[0].CategoryName)
+	require.Equal(t, 123450, records[0].Operation.Amount)
+	require.Equal(t, TypeExpense, records[0].Operation.Type)
+	require.Equal(t, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), records[0].Operation.CreatedAt)
+
+	require.Empty(t, records[1].Error)
+	require.Equal(t, "", records[1].CategoryName)
+	require.Equal(t, "Order 1234", records[1].Operation.Comment)
+	require.Equal(t, time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), records[1].Operation.CreatedAt)
+}
+
+func TestPrepareFingerprintsAreStable(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	category := uuidPtr()
+	records := []Record{
+		{Line: 1, Operation: opRequest("Coffee", 350, day)},
+		{Line: 2, Operation: opRequest("Coffee", 350, day)},
+	}
+	opts := Options{DefaultCurrency: "EUR", DefaultCategoryID: category}
+
+	first := Prepare(records, opts)
+	second := Prepare(records, opts)
+
+	require.NotEqual(t, first[0].ExternalID, first[1].ExternalID)
+	require.Equal(t, first[0].ExternalID, second[0].ExternalID)
+	require.Equal(t, first[1].ExternalID, second[1].ExternalID)
+
+	MarkDuplicates(second, []string{first[0].ExternalID})
+	require.Equal(t, StatusDuplicate, second[0].Status)
+	require.Equal(t, StatusReady, second[1].Status)
+}
+
+func TestMarkSuspected(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	manual := domain.Operation{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day.AddDate(0, 0, -1)},
+		Name:       "Lidl",
+		Amount:     1250,
+		Currency:   "EUR",
+		Type:       TypeExpense,
+	}
+	records := []Record{
+		{Line: 1, Operation: opRequest("LIDL SAGT DANKE", 1250, day)},
+		{Line: 2, Operation: opRequest("Lidl", 4000, day)},
+	}
+
+	rows := Prepare(records, Options{DefaultCurrency: "EUR", DefaultCategoryID: uuidPtr()})
+	MarkSuspected(rows, []domain.Operation{manual}, dedup.Options{})
+
+	require.Equal(t, StatusSuspected, rows[0].Status)
+	require.Equal(t, manual.ID, *rows[0].DuplicateOf)
+	require.Equal(t, StatusReady, rows[1].Status)
+	require.Len(t, Ready(rows), 1)
+}
@@ -0,0 +1,162 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// QIFParser reads the bank, cash and credit card sections of a QIF file.
+// QIF has no transaction ids, so records are identified by fingerprint.
+type QIFParser struct {
+	// DateFormat is the pattern of D lines, MM/DD/YYYY by default.
+	DateFormat string
+	Currency   string
+	// DecimalSeparator is "." by default.
+	DecimalSeparator string
+}
+
+// qifAccountTypes lists the !Type sections that hold plain transactions.
+var qifAccountTypes = map[string]bool{
+	"bank":  true,
+	"cash":  true,
+	"ccard": true,
+	"oth a": true,
+	"oth l": true,
+}
+
+func (p QIFParser) Parse(r io.Reader) ([]Record, error) {
+	const fn = "importer.QIFParser.Parse"
+
+	reader, err := decode(r, "")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	layout := DateLayout(p.DateFormat)
+	if p.DateFormat == "" {
+		layout = "01/02/2006"
+	}
+
+	var (
+		records []Record
+		fields  = map[byte]string{}
+		inBank  bool
+		start   int
+	)
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "!") {
+			header := strings.ToLower(strings.TrimSpace(text))
+			if strings.HasPrefix(header, "!type:") {
+				inBank = qifAccountTypes[strings.TrimPrefix(header, "!type:")]
+			} else {
+				// !Account, !Option and friends end the transaction section
+				inBank = false
+			}
+			fields = map[byte]string{}
+			continue
+		}
+
+		if !inBank {
+			continue
+		}
+
+		if len(fields) == 0 {
+			start = line
+		}
+
+		if text[0] == '^' {
+			records = append(records, p.record(start, fields, layout))
+			fields = map[byte]string{}
+			continue
+		}
+
+		code, value := text[0], strings.TrimSpace(text[1:])
+		if _, ok := fields[code]; ok && code == 'M' {
+			value = fields[code] + " " + value
+		}
+		fields[code] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if inBank && len(fields) > 0 {
+		records = append(records, p.record(start, fields, layout))
+	}
+
+	return records, nil
+}
+
+func (p QIFParser) record(line int, fields map[byte]string, layout string) Record {
+	rec := Record{Line: line}
+	op := &rec.Operation
+
+	op.Name = firstNonEmpty(fields['P'], fields['M'])
+	op.Comment = fields['M']
+	if op.Comment == op.Name {
+		op.Comment = ""
+	}
+	op.Currency = p.Currency
+
+	// L holds "Category:Subcategory" or "[Transfer account]".
+	if category := fields['L']; category != "" && !strings.HasPrefix(category, "[") {
+		rec.CategoryName = category
+		if i := strings.IndexByte(category, ':'); i >= 0 {
+			rec.CategoryName = category[:i]
+		}
+	}
+
+	date, err := parseQIFDate(fields['D'], layout)
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.CreatedAt = date
+
+	raw := firstNonEmpty(fields['T'], fields['U'])
+	decimal := p.DecimalSeparator
+	if decimal == "" {
+		decimal = "."
+	}
+	amount, err := ParseAmount(raw, decimal, "", currency.Exponent(op.Currency))
+	if err != nil {
+		rec.Error = err.Error()
+		return rec
+	}
+	op.Amount, op.Type = signed(amount)
+
+	return rec
+}
+
+// parseQIFDate accepts the Quicken 2-digit year forms such as 1/2'24 and 1/ 2/24.
+func parseQIFDate(value, layout string) (time.Time, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	value = strings.ReplaceAll(value, "'", "/")
+
+	if t, err := time.Parse(layout, value); err == nil {
+		return t, nil
+	}
+
+	short := strings.NewReplacer("2006", "06", "01", "1", "02", "2").Replace(layout)
+	if t, err := time.Parse(short, value); err == nil {
+		return t, nil
+	}
+	long := strings.NewReplacer("01", "1", "02", "2").Replace(layout)
+	if t, err := time.Parse(long, value); err == nil {
+		return t, nil
+	}
+
+	return ParseDate(value, layout)
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"io"
+
+	"alex_gorbunov_exptr_api/internal/models"
+)
+
+// Statement is one account statement with its booked balances in minor units.
+type Statement struct {
+	ID       string
+	Currency string
+	Opening  int
+	Closing  int
+	Records  []Record
+}
+
+// BalanceParser is implemented by formats whose statements carry opening and
+// closing balances, so the parsed entries can be checked against them.
+type BalanceParser interface {
+	Parser
+	ParseStatements(r io.Reader) ([]Statement, error)
+}
+
+// Check compares the closing balance with the opening balance plus the net
+// of all parsed entries. Entries that failed to parse make the check fail.
+func (s Statement) Check() models.BalanceCheck {
+	check := models.BalanceCheck{
+		Statement: s.ID,
+		Currency:  s.Currency,
+		Opening:   s.Opening,
+		Closing:   s.Closing,
+	}
+
+	for _, rec := range s.Records {
+		if rec.Error != "" {
+			continue
+		}
+		if rec.Operation.Type == TypeIncome {
+			check.Net += rec.Operation.Amount
+		} else {
+			check.Net -= rec.Operation.Amount
+		}
+	}
+
+	check.Difference = s.Closing - (s.Opening + check.Net)
+	check.Balanced = check.Difference == 0
+
+	return check
+}
+
+func flatten(statements []Statement) []Record {
+	var records []Record
+	for _, s := range statements {
+		records = append(records, s.Records...)
+	}
+	return records
+}
+
+// signedBalance applies a credit/debit mark to a balance amount.
+func signedBalance(amount int, debit bool) int {
+	if debit {
+		return -amount
+	}
+	return amount
+}
@@ -0,0 +1,59 @@
+// Package items normalizes the descriptions of receipt lines so one product
+// is found however a till spells it: "МОЛОКО 3,2% 1Л" and "Молоко 3.2% 1 л"
+// both become "молоко 3.2% 1 л".
+package items
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxKeyLength is the length in characters of the key column.
+const MaxKeyLength = 255
+
+// Key lowercases a description, folds ё into е, reads a decimal comma as a
+// point, keeps "%" and separates numbers from the units glued to them.
+// Other punctuation separates words. Keys are cut to MaxKeyLength
+// characters: the spaces added between numbers and units can make a key
+// longer than the description it came from.
+func Key(description string) string {
+	runes := []rune(strings.ToLower(description))
+
+	var b strings.Builder
+	var prev rune
+	for i, r := range runes {
+		if r == 'ё' {
+			r = 'е'
+		}
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if unicode.IsDigit(prev) && unicode.IsLetter(r) || unicode.IsLetter(prev) && unicode.IsDigit(r) {
+				b.WriteByte(' ')
+			}
+		case (r == '.' || r == ',') && unicode.IsDigit(prev) && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			r = '.'
+		case r == '%':
+		default:
+			r = ' '
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+
+	key := []rune(strings.Join(strings.Fields(b.String()), " "))
+	if len(key) > MaxKeyLength {
+		key = []rune(strings.TrimSpace(string(key[:MaxKeyLength])))
+	}
+	return string(key)
+}
+
+// Words splits a search query into normalized words, each of which a
+// matching key must contain.
+func Words(query string) []string {
+	return strings.Fields(Key(query))
+}
+
+// EscapeLike escapes the wildcards of a LIKE pattern.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,40 @@
+package items
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	for description, want := range map[string]string{
+		"МОЛОКО 3,2% 1Л":          "молоко 3.2% 1 л",
+		"Молоко  3.2%  1 л":       "молоко 3.2% 1 л",
+		"Сыр \"Российский\" 45%":  "сыр российский 45%",
+		"Ёжики, шоколадные":       "ежики шоколадные",
+		"Bananas (loose), 1.25kg": "bananas loose 1.25 kg",
+		"  ":                      "",
+	} {
+		require.Equal(t, want, Key(description), description)
+	}
+}
+
+func TestKeyFitsTheColumn(t *testing.T) {
+	// Every glued "1л" is spaced apart, so 254 characters become 507.
+	description := strings.Repeat("1л", 127)
+	key := Key(description)
+	require.Equal(t, MaxKeyLength, utf8.RuneCountInString(key))
+	require.True(t, utf8.ValidString(key))
+	require.Equal(t, key, strings.TrimSpace(key))
+}
+
+func TestWords(t *testing.T) {
+	require.Equal(t, []string{"молоко", "1", "л"}, Words("молоко 1л"))
+	require.Empty(t, Words(" - "))
+}
+
+func TestEscapeLike(t *testing.T) {
+	require.Equal(t, `3.2\% a\_b \\`, EscapeLike(`3.2% a_b \`))
+}
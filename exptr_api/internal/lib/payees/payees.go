@@ -0,0 +1,129 @@
+// Package payees recognizes the merchant behind the text a bank puts on an
+// operation. Statements spell one merchant many ways, such as
+// "POS 1234 LIDL SAGT 45 BERLIN" and "LIDL BERLIN"; Key reduces both to
+// "lidl" so they land on the same payee.
+package payees
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// prefixes are what payment processors and card networks put in front of
+// the merchant, "POS", "VISA", "SQ *" or "PAYPAL *", and the Russian legal
+// forms, which lead the name.
+var prefixes = map[string]bool{
+	"pos": true, "card": true, "visa": true, "mastercard": true, "mc": true, "maestro": true,
+	"debit": true, "credit": true, "purchase": true, "payment": true, "contactless": true,
+	"paypal": true, "pp": true, "sq": true, "tst": true, "sumup": true,
+	"zettle": true, "izettle": true, "sp": true, "ec": true, "kartenzahlung": true, "lastschrift": true,
+	"оплата": true, "покупка": true, "карта": true, "карты": true, "карте": true, "по": true,
+	"ооо": true, "ип": true, "оао": true, "зао": true, "пао": true, "ао": true,
+}
+
+// noise is dropped wherever it appears: terminal labels and the slogans
+// some chains print on every receipt.
+var noise = map[string]bool{
+	"terminal": true, "term": true, "tid": true, "mid": true, "ref": true, "trx": true,
+	"sagt": true, "danke": true,
+	"терминал": true, "спасибо": true,
+}
+
+// suffixes are legal forms, dropped from the end.
+var suffixes = map[string]bool{
+	"gmbh": true, "ag": true, "kg": true, "ohg": true, "ug": true, "e": true, "k": true,
+	"ltd": true, "limited": true, "llc": true, "inc": true, "corp": true, "co": true, "plc": true,
+	"sa": true, "sarl": true, "bv": true, "nv": true, "srl": true, "spa": true, "se": true, "ab": true,
+	"ооо": true, "ип": true, "оао": true, "зао": true, "пао": true, "ао": true,
+}
+
+// places are cities and countries banks append to the merchant, dropped
+// from the end.
+var places = map[string]bool{
+	"berlin": true, "hamburg": true, "munich": true, "muenchen": true, "münchen": true, "koeln": true,
+	"köln": true, "cologne": true, "frankfurt": true, "stuttgart": true, "duesseldorf": true,
+	"düsseldorf": true, "leipzig": true, "dresden": true, "hannover": true, "bremen": true,
+	"london": true, "manchester": true, "paris": true, "lyon": true, "amsterdam": true, "rotterdam": true,
+	"vienna": true, "wien": true, "zurich": true, "zürich": true, "prague": true, "praha": true,
+	"warsaw": true, "warszawa": true, "madrid": true, "barcelona": true, "rome": true, "roma": true,
+	"milan": true, "milano": true, "lisbon": true, "lisboa": true, "dublin": true, "brussels": true,
+	"nyc": true, "boston": true, "chicago": true, "seattle": true,
+	"moscow": true, "moskva": true, "москва": true, "spb": true, "спб": true, "петербург": true,
+	"petersburg": true, "kazan": true, "казань": true,
+	"рф": true, "россия": true,
+	"de": true, "deu": true, "germany": true, "deutschland": true, "gb": true, "gbr": true, "uk": true,
+	"us": true, "usa": true, "fr": true, "fra": true, "nl": true, "nld": true, "aut": true,
+	"ch": true, "che": true, "esp": true, "ita": true, "pl": true, "pol": true,
+	"ru": true, "rus": true, "ie": true, "irl": true, "bel": true, "cz": true, "cze": true,
+}
+
+// Key normalizes the name of an operation into the key its payee is found
+// by. Card numbers, terminal ids and anything else with a digit go, then
+// processor prefixes, legal forms and trailing places. A key is never
+// stripped bare: the last word standing is kept. Key returns "" when the
+// name has no words at all.
+func Key(name string) string {
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if strings.IndexFunc(w, unicode.IsDigit) >= 0 || noise[w] {
+			continue
+		}
+		words = append(words, w)
+	}
+
+	for len(words) > 1 && prefixes[words[0]] {
+		words = words[1:]
+	}
+	for len(words) > 1 && (suffixes[words[len(words)-1]] || places[words[len(words)-1]]) {
+		words = words[:len(words)-1]
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Name turns a key into a name to show, "lidl" into "Lidl".
+func Name(key string) string {
+	return cases.Title(language.Und).String(key)
+}
+
+// Match finds the payee of a key among aliases, which map keys to payees.
+// An exact alias wins; otherwise the longest alias the key starts with, word
+// by word, so the alias "amazon" also takes "amazon marketplace".
+func Match(key string, aliases map[string]uuid.UUID) (uuid.UUID, bool) {
+	if key == "" {
+		return uuid.Nil, false
+	}
+	if id, ok := aliases[key]; ok {
+		return id, true
+	}
+
+	best, found := "", uuid.Nil
+	for alias, id := range aliases {
+		if alias == "" || len(alias) <= len(best) || !strings.HasPrefix(key, alias+" ") {
+			continue
+		}
+		best, found = alias, id
+	}
+	return found, best != ""
+}
+
+// Candidates lists the aliases Match could pick for a key: the key itself
+// and every run of its leading words. Looking up only these spares loading
+// all of a user's aliases.
+func Candidates(key string) []string {
+	if key == "" {
+		return nil
+	}
+	words := strings.Fields(key)
+	candidates := make([]string, 0, len(words))
+	for i := range words {
+		candidates = append(candidates, strings.Join(words[:i+1], " "))
+	}
+	return candidates
+}
@@ -0,0 +1,63 @@
+package payees
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKey(t *testing.T) {
+	for name, want := range map[string]string{
+		"POS 1234 LIDL SAGT 45 BERLIN":        "lidl",
+		"LIDL BERLIN":                         "lidl",
+		"Lidl":                                "lidl",
+		"VISA *4821 REWE Markt GmbH Koeln DE": "rewe markt",
+		"SQ *BLUE BOTTLE COFFEE":              "blue bottle coffee",
+		"PAYPAL *SPOTIFY 35314369001":         "spotify",
+		"AMAZON MKTPLACE PMTS AMZN.COM/BILL":  "amazon mktplace pmts amzn com bill",
+		"Оплата по карте *1234 ПЯТЁРОЧКА 5521 Москва": "пятёрочка",
+		"ООО Ромашка":     "ромашка",
+		"Berlin":          "berlin",
+		"POS Berlin":      "berlin",
+		"12.03.2024 4821": "",
+	} {
+		require.Equal(t, want, Key(name), name)
+	}
+}
+
+func TestName(t *testing.T) {
+	require.Equal(t, "Blue Bottle Coffee", Name("blue bottle coffee"))
+	require.Equal(t, "Пятёрочка", Name("пятёрочка"))
+}
+
+func TestMatch(t *testing.T) {
+	amazon, prime, lidl := uuid.New(), uuid.New(), uuid.New()
+	aliases := map[string]uuid.UUID{
+		"amazon":       amazon,
+		"amazon prime": prime,
+		"lidl":         lidl,
+	}
+
+	for key, want := range map[string]uuid.UUID{
+		"lidl":               lidl,
+		"amazon":             amazon,
+		"amazon marketplace": amazon,
+		"amazon prime":       prime,
+		"amazon prime video": prime,
+	} {
+		got, ok := Match(key, aliases)
+		require.True(t, ok, key)
+		require.Equal(t, want, got, key)
+	}
+
+	for _, key := range []string{"", "lidlx", "amazonia", "rewe"} {
+		_, ok := Match(key, aliases)
+		require.False(t, ok, key)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	require.Equal(t, []string{"amazon", "amazon prime", "amazon prime video"}, Candidates("amazon prime video"))
+	require.Empty(t, Candidates(""))
+}
@@ -0,0 +1,209 @@
+package quickentry
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// amountToken is a number with an optional sign, currency symbol or code
+// glued to it and a thousands suffix: +3000, €4.50, 4,50eur, 300р, 2.5k.
+var amountToken = regexp.MustCompile(`^([+-]?)([^\d+-]*?)(\d+(?:[.,]\d+)*)([kкKК]?)(\D*)$`)
+
+var currencyAliases = map[string]string{
+	"€": "EUR", "euro": "EUR", "euros": "EUR", "евро": "EUR",
+	"$": "USD", "dollar": "USD", "dollars": "USD", "bucks": "USD",
+	"доллар": "USD", "доллара": "USD", "долларов": "USD", "бакс": "USD", "бакса": "USD", "баксов": "USD",
+	"₽": "RUB", "р": "RUB", "руб": "RUB", "рубль": "RUB", "рубля": "RUB", "рублей": "RUB",
+	"£": "GBP", "pound": "GBP", "pounds": "GBP", "фунт": "GBP", "фунта": "GBP", "фунтов": "GBP",
+}
+
+var incomeWords = map[string]bool{
+	"salary": true, "income": true, "refund": true, "cashback": true, "bonus": true,
+	"paycheck": true, "dividend": true, "dividends": true, "wage": true, "wages": true,
+	"зарплата": true, "зарплату": true, "зп": true, "доход": true, "возврат": true,
+	"кэшбэк": true, "кешбэк": true, "премия": true, "аванс": true, "дивиденды": true,
+}
+
+// fillers are dropped from both ends of the name, where they are usually
+// left over from "for 4.50" or "в пятницу".
+var fillers = map[string]bool{
+	"for": true, "on": true, "at": true, "in": true, "from": true, "to": true,
+	"за": true, "на": true, "в": true, "во": true, "с": true, "от": true, "для": true,
+}
+
+type amount struct {
+	value    float64
+	sign     string
+	currency string
+}
+
+// amount takes the most likely amount: one with a sign or currency beats
+// one with decimals, which beats a bare number; among equals the last
+// wins, as in "2 coffees 9".
+func (p *parser) amount() (amount, error) {
+	best, bestScore := -1, 0
+	var found amount
+	var extra []int
+
+	for i := range p.tokens {
+		if p.used[i] {
+			continue
+		}
+		g := amountToken.FindStringSubmatch(p.tokens[i])
+		if g == nil {
+			continue
+		}
+		value, ok := number(g[3])
+		if !ok {
+			continue
+		}
+		if g[4] != "" {
+			value *= 1000
+		}
+
+		a := amount{value: value, sign: g[1]}
+		if !a.glue(g[2], g[5]) {
+			continue
+		}
+		var glued []int
+		if a.currency == "" {
+			for _, j := range []int{i + 1, i - 1} {
+				if j < 0 || j >= len(p.tokens) || p.used[j] {
+					continue
+				}
+				// Only a code following the number may be lower case:
+				// "all 300" is not 300 Albanian lek.
+				if code, ok := currencyOf(p.tokens[j], j > i); ok {
+					a.currency = code
+					glued = append(glued, j)
+					break
+				}
+			}
+		}
+
+		score := 1
+		if strings.ContainsAny(g[3], ".,") {
+			score = 2
+		}
+		if a.sign != "" || a.currency != "" {
+			score = 3
+		}
+		if score >= bestScore {
+			best, bestScore, found, extra = i, score, a, glued
+		}
+	}
+
+	if best < 0 {
+		return amount{}, ErrNoAmount
+	}
+	p.used[best] = true
+	p.use(extra...)
+	return found, nil
+}
+
+// glue sets the currency from the text glued before and after the number,
+// reporting false when that text is not a currency.
+func (a *amount) glue(affixes ...string) bool {
+	for _, affix := range affixes {
+		if affix == "" {
+			continue
+		}
+		code, ok := currencyOf(affix, true)
+		if !ok || a.currency != "" && a.currency != code {
+			return false
+		}
+		a.currency = code
+	}
+	return true
+}
+
+// currency takes a currency named apart from the amount, as in
+// "4.50 for coffee EUR".
+func (p *parser) currency() string {
+	for i, t := range p.tokens {
+		if p.used[i] {
+			continue
+		}
+		if code, ok := currencyOf(t, false); ok {
+			p.used[i] = true
+			return code
+		}
+	}
+	return ""
+}
+
+// currencyOf resolves a symbol, word or ISO code. Lower-case codes are only
+// taken next to the amount, since "all", "try" or "top" are codes too.
+func currencyOf(word string, adjacent bool) (string, bool) {
+	trimmed := strings.TrimRight(word, ".,")
+	if code, ok := currencyAliases[strings.ToLower(trimmed)]; ok {
+		return code, true
+	}
+	if len(trimmed) != 3 || !currency.IsValid(trimmed) {
+		return "", false
+	}
+	if adjacent || trimmed == strings.ToUpper(trimmed) {
+		return currency.Normalize(trimmed), true
+	}
+	return "", false
+}
+
+// number reads 4.50, 4,50 and 1,200.50. With both separators the last one
+// is the decimal point; a lone comma followed by three digits groups
+// thousands.
+func number(s string) (float64, bool) {
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		if dot > comma {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+		}
+	case comma >= 0:
+		if strings.Count(s, ",") > 1 || len(s)-comma-1 == 3 {
+			s = strings.ReplaceAll(s, ",", "")
+		} else {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	case strings.Count(s, ".") > 1:
+		s = strings.ReplaceAll(s, ".", "")
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	return value, err == nil
+}
+
+// name joins the words left over, without fillers at either end.
+func (p *parser) name() string {
+	var words []string
+	for i, t := range p.tokens {
+		if !p.used[i] {
+			words = append(words, t)
+		}
+	}
+	for len(words) > 0 && fillers[strings.ToLower(words[0])] {
+		words = words[1:]
+	}
+	for len(words) > 0 && fillers[strings.ToLower(words[len(words)-1])] {
+		words = words[:len(words)-1]
+	}
+	return strings.TrimFunc(strings.Join(words, " "), func(r rune) bool {
+		return unicode.IsPunct(r) && r != ')' && r != '"'
+	})
+}
+
+func hasIncomeWord(name string) bool {
+	for _, w := range strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if incomeWords[w] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,264 @@
+// Package quickentry parses a short free-text note such as
+// "coffee 4.50 eur yesterday #work" or "зарплата +3000" into the parts of an
+// operation. It understands English and Russian.
+package quickentry
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+const (
+	TypeIncome  = "income"
+	TypeExpense = "expense"
+)
+
+var (
+	ErrEmpty    = errors.New("text is empty")
+	ErrNoAmount = errors.New("no amount found")
+	ErrNoName   = errors.New("no name found")
+)
+
+// Entry is what was understood from a note. Currency is empty when the
+// note names none; Category is the name given with "@", if any.
+type Entry struct {
+	Name     string
+	Amount   int
+	Currency string
+	Type     string
+	Date     time.Time
+	Tags     []string
+	Category string
+}
+
+// Options of Parse. Now is the moment relative dates count from, in the
+// user's time zone. DefaultCurrency sizes the amount when the note names no
+// currency.
+type Options struct {
+	Now             time.Time
+	DefaultCurrency string
+}
+
+// Parse reads a note. Words that are neither amount, currency, date, tag
+// nor category make up the name.
+func Parse(text string, opts Options) (Entry, error) {
+	tokens := strings.Fields(text)
+	if len(tokens) == 0 {
+		return Entry{}, ErrEmpty
+	}
+
+	p := parser{tokens: tokens, used: make([]bool, len(tokens)), now: opts.Now}
+	entry := Entry{Date: opts.Now}
+
+	p.tags(&entry)
+	p.dates(&entry)
+
+	amount, err := p.amount()
+	if err != nil {
+		return Entry{}, err
+	}
+	entry.Currency = amount.currency
+	if entry.Currency == "" {
+		entry.Currency = p.currency()
+	}
+	sizing := entry.Currency
+	if sizing == "" {
+		sizing = opts.DefaultCurrency
+	}
+	entry.Amount = currency.FromMajor(amount.value, sizing)
+	if entry.Amount == 0 {
+		return Entry{}, ErrNoAmount
+	}
+
+	entry.Name = p.name()
+	entry.Type = TypeExpense
+	switch {
+	case amount.sign == "+":
+		entry.Type = TypeIncome
+	case amount.sign == "-":
+	case hasIncomeWord(entry.Name):
+		entry.Type = TypeIncome
+	}
+
+	if entry.Name == "" {
+		switch {
+		case entry.Category != "":
+			entry.Name = entry.Category
+		case len(entry.Tags) > 0:
+			entry.Name = entry.Tags[0]
+		default:
+			return Entry{}, ErrNoName
+		}
+	}
+
+	return entry, nil
+}
+
+type parser struct {
+	tokens []string
+	used   []bool
+	now    time.Time
+}
+
+func (p *parser) lower(i int) string {
+	return strings.ToLower(p.tokens[i])
+}
+
+// tags takes "#tag" and "@category" tokens.
+func (p *parser) tags(entry *Entry) {
+	for i, t := range p.tokens {
+		switch {
+		case len(t) > 1 && t[0] == '#':
+			entry.Tags = append(entry.Tags, strings.TrimLeft(t, "#"))
+			p.used[i] = true
+		case len(t) > 1 && t[0] == '@':
+			entry.Category = strings.ReplaceAll(t[1:], "_", " ")
+			p.used[i] = true
+		}
+	}
+}
+
+var (
+	isoDate   = regexp.MustCompile(`^(\d{4})-(\d{1,2})-(\d{1,2})$`)
+	dottedDay = regexp.MustCompile(`^(\d{1,2})\.(\d{1,2})\.(\d{2}|\d{4})$`)
+	slashDay  = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?$`)
+)
+
+var relativeDays = map[string]int{
+	"today":     0,
+	"сегодня":   0,
+	"yesterday": 1,
+	"вчера":     1,
+	"позавчера": 2,
+}
+
+var weekdays = map[string]time.Weekday{
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"понедельник": time.Monday, "пн": time.Monday,
+	"вторник": time.Tuesday, "вт": time.Tuesday,
+	"среда": time.Wednesday, "среду": time.Wednesday, "ср": time.Wednesday,
+	"четверг": time.Thursday, "чт": time.Thursday,
+	"пятница": time.Friday, "пятницу": time.Friday, "пт": time.Friday,
+	"суббота": time.Saturday, "субботу": time.Saturday, "сб": time.Saturday,
+	"воскресенье": time.Sunday, "вс": time.Sunday,
+}
+
+var lastWords = map[string]bool{
+	"last": true, "прошлый": true, "прошлую": true, "прошлое": true, "прошлой": true, "прошлом": true,
+}
+
+// dates takes the first date found: a relative day, "N days ago", a
+// weekday or a calendar date.
+func (p *parser) dates(entry *Entry) {
+	for i := range p.tokens {
+		if p.used[i] {
+			continue
+		}
+		word := strings.TrimRight(p.lower(i), ",.")
+
+		if n, ok := relativeDays[word]; ok {
+			entry.Date = p.now.AddDate(0, 0, -n)
+			p.used[i] = true
+			return
+		}
+		if word == "day" && p.next(i, "before") && p.next(i+1, "yesterday") {
+			entry.Date = p.now.AddDate(0, 0, -2)
+			p.use(i, i+1, i+2)
+			return
+		}
+
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n < 1000 && i+2 < len(p.tokens) {
+			unit := p.lower(i + 1)
+			ago := strings.TrimRight(p.lower(i+2), ",.")
+			if ago == "ago" && (unit == "day" || unit == "days") ||
+				ago == "назад" && (unit == "день" || unit == "дня" || unit == "дней") {
+				entry.Date = p.now.AddDate(0, 0, -n)
+				p.use(i, i+1, i+2)
+				return
+			}
+		}
+
+		if day, ok := weekdays[word]; ok {
+			last := i > 0 && !p.used[i-1] && lastWords[p.lower(i-1)]
+			back := (int(p.now.Weekday()) - int(day) + 7) % 7
+			if back == 0 && last {
+				back = 7
+			}
+			entry.Date = p.now.AddDate(0, 0, -back)
+			p.used[i] = true
+			if last {
+				p.used[i-1] = true
+			}
+			return
+		}
+
+		if date, ok := p.calendar(word); ok {
+			entry.Date = date
+			p.used[i] = true
+			return
+		}
+	}
+}
+
+// calendar reads 2024-03-15, 15.03.2024, 15/03/2024 and 15/03. Dotted dates
+// need a year, since 15.03 is more likely an amount.
+func (p *parser) calendar(word string) (time.Time, bool) {
+	var y, m, d int
+	if g := isoDate.FindStringSubmatch(word); g != nil {
+		y, m, d = atoi(g[1]), atoi(g[2]), atoi(g[3])
+	} else if g := dottedDay.FindStringSubmatch(word); g != nil {
+		d, m, y = atoi(g[1]), atoi(g[2]), year(g[3])
+	} else if g := slashDay.FindStringSubmatch(word); g != nil {
+		d, m = atoi(g[1]), atoi(g[2])
+		y = p.now.Year()
+		if g[3] != "" {
+			y = year(g[3])
+		}
+	} else {
+		return time.Time{}, false
+	}
+
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, p.now.Location())
+	if date.Day() != d || int(date.Month()) != m {
+		return time.Time{}, false
+	}
+	// A day without a year that lies ahead means last year's.
+	if date.After(p.now) && strings.Count(word, "/") == 1 {
+		date = date.AddDate(-1, 0, 0)
+	}
+	return date, true
+}
+
+func (p *parser) next(i int, word string) bool {
+	return i+1 < len(p.tokens) && !p.used[i+1] && p.lower(i+1) == word
+}
+
+func (p *parser) use(indexes ...int) {
+	for _, i := range indexes {
+		p.used[i] = true
+	}
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+func year(s string) int {
+	y := atoi(s)
+	if len(s) == 2 {
+		y += 2000
+	}
+	return y
+}
@@ -0,0 +1,100 @@
+package quickentry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday.
+	now := time.Date(2024, 3, 13, 18, 30, 0, 0, time.UTC)
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		text string
+		want Entry
+	}{
+		{"coffee 4.50 eur yesterday #work", Entry{
+			Name: "coffee", Amount: 450, Currency: "EUR", Type: TypeExpense,
+			Date: now.AddDate(0, 0, -1), Tags: []string{"work"},
+		}},
+		{"salary +3000", Entry{Name: "salary", Amount: 300000, Type: TypeIncome, Date: now}},
+		{"Salary 3000 USD", Entry{Name: "Salary", Amount: 300000, Currency: "USD", Type: TypeIncome, Date: now}},
+		{"refund -15", Entry{Name: "refund", Amount: 1500, Type: TypeExpense, Date: now}},
+		{"€12,90 lunch with Anna @eating_out", Entry{
+			Name: "lunch with Anna", Amount: 1290, Currency: "EUR", Type: TypeExpense, Date: now, Category: "eating out",
+		}},
+		{"2 coffees for 9", Entry{Name: "2 coffees", Amount: 900, Type: TypeExpense, Date: now}},
+		{"taxi $ 1,250.00 last monday", Entry{
+			Name: "taxi", Amount: 125000, Currency: "USD", Type: TypeExpense, Date: now.AddDate(0, 0, -2),
+		}},
+		{"books 30 friday", Entry{Name: "books", Amount: 3000, Type: TypeExpense, Date: now.AddDate(0, 0, -5)}},
+		{"gym 45 3 days ago", Entry{Name: "gym", Amount: 4500, Type: TypeExpense, Date: now.AddDate(0, 0, -3)}},
+		{"rent 1.2k 2024-03-01", Entry{Name: "rent", Amount: 120000, Type: TypeExpense, Date: day(2024, 3, 1)}},
+		{"кофе 250р вчера #работа", Entry{
+			Name: "кофе", Amount: 25000, Currency: "RUB", Type: TypeExpense,
+			Date: now.AddDate(0, 0, -1), Tags: []string{"работа"},
+		}},
+		{"зарплата 85000 руб", Entry{Name: "зарплата", Amount: 8500000, Currency: "RUB", Type: TypeIncome, Date: now}},
+		{"такси 540,50 ₽ в пятницу", Entry{
+			Name: "такси", Amount: 54050, Currency: "RUB", Type: TypeExpense, Date: now.AddDate(0, 0, -5),
+		}},
+		{"продукты 1200 позавчера", Entry{Name: "продукты", Amount: 120000, Type: TypeExpense, Date: now.AddDate(0, 0, -2)}},
+		{"аптека 300 2 дня назад", Entry{Name: "аптека", Amount: 30000, Type: TypeExpense, Date: now.AddDate(0, 0, -2)}},
+		{"ужин 15.02.2024 2400 евро", Entry{Name: "ужин", Amount: 240000, Currency: "EUR", Type: TypeExpense, Date: day(2024, 2, 15)}},
+		{"gift 50 25/12", Entry{Name: "gift", Amount: 5000, Type: TypeExpense, Date: day(2023, 12, 25)}},
+		{"all you can eat 20", Entry{Name: "all you can eat", Amount: 2000, Type: TypeExpense, Date: now}},
+		{"+500 #freelance", Entry{Name: "freelance", Amount: 50000, Type: TypeIncome, Date: now, Tags: []string{"freelance"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			got, err := Parse(tc.text, Options{Now: now, DefaultCurrency: "EUR"})
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseSizesAmountByCurrency(t *testing.T) {
+	now := time.Now()
+
+	got, err := Parse("ramen 1200 JPY", Options{Now: now, DefaultCurrency: "EUR"})
+	require.NoError(t, err)
+	require.Equal(t, 1200, got.Amount)
+
+	got, err = Parse("ramen 1200", Options{Now: now, DefaultCurrency: "JPY"})
+	require.NoError(t, err)
+	require.Equal(t, 1200, got.Amount)
+	require.Empty(t, got.Currency)
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Now()
+	for text, want := range map[string]error{
+		"   ":           ErrEmpty,
+		"coffee":        ErrNoAmount,
+		"coffee 0":      ErrNoAmount,
+		"4.50 EUR":      ErrNoName,
+		"за 4.50 вчера": ErrNoName,
+	} {
+		_, err := Parse(text, Options{Now: now})
+		require.ErrorIs(t, err, want, text)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for in, want := range map[string]float64{
+		"4.50":      4.5,
+		"4,50":      4.5,
+		"1,200":     1200,
+		"1,200.50":  1200.5,
+		"1.200,50":  1200.5,
+		"1.000.000": 1000000,
+	} {
+		got, ok := number(in)
+		require.True(t, ok, in)
+		require.Equal(t, want, got, in)
+	}
+}
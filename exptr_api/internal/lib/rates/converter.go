@@ -0,0 +1,87 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/storage"
+)
+
+// Pivot is the currency cross rates are computed through when no direct rate exists.
+const Pivot = "EUR"
+
+var ErrNoRate = errors.New("no exchange rate")
+
+type RateSource interface {
+	GetExchangeRate(base, quote string, on time.Time) (float64, error)
+}
+
+// Converter converts amounts using the latest rate known on the operation date.
+// It tries the direct pair, its inverse and finally a cross rate through Pivot.
+type Converter struct {
+	source RateSource
+}
+
+func NewConverter(source RateSource) *Converter {
+	return &Converter{source: source}
+}
+
+// Rate returns how many units of quote one unit of base was worth on the given date.
+func (c *Converter) Rate(base, quote string, on time.Time) (float64, error) {
+	const fn = "rates.Converter.Rate"
+
+	base, quote = currency.Normalize(base), currency.Normalize(quote)
+	if base == quote {
+		return 1, nil
+	}
+
+	rate, err := c.pair(base, quote, on)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, ErrNoRate) {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if base != Pivot && quote != Pivot {
+		toBase, errBase := c.pair(Pivot, base, on)
+		toQuote, errQuote := c.pair(Pivot, quote, on)
+		if errBase == nil && errQuote == nil {
+			return toQuote / toBase, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%s: %s/%s on %s: %w", fn, base, quote, on.Format(dateLayout), ErrNoRate)
+}
+
+// Convert converts an amount in minor units of from into minor units of to.
+func (c *Converter) Convert(amount int, from, to string, on time.Time) (int, error) {
+	rate, err := c.Rate(from, to, on)
+	if err != nil {
+		return 0, err
+	}
+
+	return currency.FromMajor(currency.ToMajor(amount, from)*rate, to), nil
+}
+
+func (c *Converter) pair(base, quote string, on time.Time) (float64, error) {
+	rate, err := c.source.GetExchangeRate(base, quote, on)
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, storage.ErrItemNotFound) {
+		return 0, err
+	}
+
+	inverse, err := c.source.GetExchangeRate(quote, base, on)
+	if err == nil && inverse != 0 {
+		return 1 / inverse, nil
+	}
+	if err != nil && !errors.Is(err, storage.ErrItemNotFound) {
+		return 0, err
+	}
+
+	return 0, ErrNoRate
+}
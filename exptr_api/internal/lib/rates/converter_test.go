@@ -0,0 +1,57 @@
+package rates
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+type memorySource map[string]float64
+
+func (m memorySource) GetExchangeRate(base, quote string, _ time.Time) (float64, error) {
+	if rate, ok := m[base+"/"+quote]; ok {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("memory: %w", storage.ErrItemNotFound)
+}
+
+func TestConverterConvert(t *testing.T) {
+	conv := NewConverter(memorySource{
+		"EUR/USD": 1.1,
+		"EUR/JPY": 160,
+		"USD/RUB": 90,
+	})
+	on := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		amount   int
+		from, to string
+		want     int
+		wantErr  bool
+	}{
+		{name: "same currency", amount: 1234, from: "USD", to: "usd", want: 1234},
+		{name: "direct", amount: 1000, from: "EUR", to: "USD", want: 1100},
+		{name: "inverse", amount: 1100, from: "USD", to: "EUR", want: 1000},
+		{name: "cross through pivot", amount: 1100, from: "USD", to: "JPY", want: 1600},
+		{name: "zero exponent source", amount: 160, from: "JPY", to: "EUR", want: 100},
+		{name: "direct non pivot", amount: 100, from: "USD", to: "RUB", want: 9000},
+		{name: "missing rate", amount: 100, from: "GBP", to: "RUB", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := conv.Convert(tc.amount, tc.from, tc.to, on)
+			if tc.wantErr {
+				require.ErrorIs(t, err, ErrNoRate)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
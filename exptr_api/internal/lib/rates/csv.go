@@ -0,0 +1,89 @@
+package rates
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+const dateLayout = "2006-01-02"
+
+// CSVFile reads rates from a file with the columns date,base,quote,rate.
+// A header row is optional.
+type CSVFile struct {
+	Path string
+}
+
+func (p CSVFile) Fetch(_ context.Context) ([]domain.ExchangeRate, error) {
+	const fn = "rates.CSVFile.Fetch"
+
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer f.Close()
+
+	rates, err := ParseCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rates, nil
+}
+
+func ParseCSV(r io.Reader) ([]domain.ExchangeRate, error) {
+	const fn = "rates.ParseCSV"
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []domain.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if line == 1 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, record[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: invalid date %q", fn, line, record[0])
+		}
+
+		base, quote := currency.Normalize(record[1]), currency.Normalize(record[2])
+		if !currency.IsValid(base) || !currency.IsValid(quote) {
+			return nil, fmt.Errorf("%s: line %d: unknown currency pair %s/%s", fn, line, record[1], record[2])
+		}
+
+		rate, err := strconv.ParseFloat(record[3], 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("%s: line %d: invalid rate %q", fn, line, record[3])
+		}
+
+		rates = append(rates, domain.ExchangeRate{
+			Date:   date,
+			Base:   base,
+			Quote:  quote,
+			Rate:   rate,
+			Source: "csv",
+		})
+	}
+
+	return rates, nil
+}
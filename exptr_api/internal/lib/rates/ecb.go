@@ -0,0 +1,105 @@
+package rates
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+// ECBDailyURL is the European Central Bank reference rates feed.
+const ECBDailyURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECBFeed reads an ECB-style eurofxref XML feed, where every rate is quoted against EUR.
+type ECBFeed struct {
+	URL    string
+	Client *http.Client
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+func (p ECBFeed) Fetch(ctx context.Context) ([]domain.ExchangeRate, error) {
+	const fn = "rates.ECBFeed.Fetch"
+
+	url := p.URL
+	if url == "" {
+		url = ECBDailyURL
+	}
+
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %d", fn, resp.StatusCode)
+	}
+
+	rates, err := ParseECB(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rates, nil
+}
+
+func ParseECB(r io.Reader) ([]domain.ExchangeRate, error) {
+	const fn = "rates.ParseECB"
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var rates []domain.ExchangeRate
+	for _, day := range envelope.Days {
+		date, err := time.Parse(dateLayout, day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid date %q", fn, day.Time)
+		}
+
+		for _, r := range day.Rates {
+			quote := currency.Normalize(r.Currency)
+			if !currency.IsValid(quote) || r.Rate <= 0 {
+				continue
+			}
+			rates = append(rates, domain.ExchangeRate{
+				Date:   date,
+				Base:   "EUR",
+				Quote:  quote,
+				Rate:   r.Rate,
+				Source: "ecb",
+			})
+		}
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("%s: feed contains no rates", fn)
+	}
+
+	return rates, nil
+}
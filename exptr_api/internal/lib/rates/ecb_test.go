@@ -0,0 +1,48 @@
+package rates
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestECBFeedFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/eurofxref-hist.xml")
+	}))
+	defer srv.Close()
+
+	rates, err := ECBFeed{URL: srv.URL, Client: srv.Client()}.Fetch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rates, 6)
+
+	first := rates[0]
+	require.Equal(t, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), first.Date)
+	require.Equal(t, "EUR", first.Base)
+	require.Equal(t, "USD", first.Quote)
+	require.Equal(t, 1.0919, first.Rate)
+	require.Equal(t, "ecb", first.Source)
+}
+
+func TestECBFeedFetchBadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	_, err := ECBFeed{URL: srv.URL, Client: srv.Client()}.Fetch(context.Background())
+	require.Error(t, err)
+}
+
+func TestCSVFileFetch(t *testing.T) {
+	rates, err := CSVFile{Path: "testdata/rates.csv"}.Fetch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+	require.Equal(t, "USD", rates[1].Base)
+	require.Equal(t, "RUB", rates[1].Quote)
+	require.Equal(t, 90.7493, rates[1].Rate)
+}
@@ -0,0 +1,14 @@
+// Package rates loads exchange rates from external sources and converts
+// amounts between currencies using the stored rates.
+package rates
+
+import (
+	"context"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+)
+
+// Provider fetches a batch of exchange rates from a single source.
+type Provider interface {
+	Fetch(ctx context.Context) ([]domain.ExchangeRate, error)
+}
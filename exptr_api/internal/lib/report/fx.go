@@ -0,0 +1,111 @@
+package report
+
+import (
+	"errors"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+
+	"github.com/google/uuid"
+)
+
+// FXCost compares what the bank settled for a foreign-currency operation with
+// the market conversion on the same date. Fee is in SettledCurrency minor
+// units; a positive fee is a cost to the user and a negative one a gain.
+type FXCost struct {
+	OperationID     uuid.UUID `json:"operation_id"`
+	Name            string    `json:"name"`
+	Type            string    `json:"type"`
+	Date            time.Time `json:"date"`
+	Amount          int       `json:"amount"`
+	Currency        string    `json:"currency"`
+	SettledAmount   int       `json:"settled_amount"`
+	SettledCurrency string    `json:"settled_currency"`
+	EffectiveRate   float64   `json:"effective_rate"`
+	MarketRate      float64   `json:"market_rate"`
+	MarketAmount    int       `json:"market_amount"`
+	Fee             int       `json:"fee"`
+	FeePercent      float64   `json:"fee_percent"`
+}
+
+// FXReport lists FX costs of settled operations. TotalFee is in Currency.
+type FXReport struct {
+	Currency    string      `json:"currency"`
+	TotalFee    int         `json:"total_fee"`
+	Operations  []FXCost    `json:"operations"`
+	Unconverted []uuid.UUID `json:"unconverted,omitempty"`
+}
+
+func BuildFX(operations []domain.Operation, baseCurrency string, conv Converter) (*FXReport, error) {
+	fx := &FXReport{Currency: baseCurrency, Operations: []FXCost{}}
+
+	for _, op := range operations {
+		if !op.Settled() || op.SettledCurrency == op.Currency {
+			continue
+		}
+
+		cost, err := fxCost(op, conv)
+		if errors.Is(err, rates.ErrNoRate) {
+			fx.Unconverted = append(fx.Unconverted, op.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fee, err := conv.Convert(cost.Fee, cost.SettledCurrency, baseCurrency, op.CreatedAt)
+		if errors.Is(err, rates.ErrNoRate) {
+			fx.Unconverted = append(fx.Unconverted, op.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		fx.TotalFee += fee
+		fx.Operations = append(fx.Operations, cost)
+	}
+
+	return fx, nil
+}
+
+func fxCost(op domain.Operation, conv Converter) (FXCost, error) {
+	marketRate, err := conv.Rate(op.Currency, op.SettledCurrency, op.CreatedAt)
+	if err != nil {
+		return FXCost{}, err
+	}
+
+	market, err := conv.Convert(op.Amount, op.Currency, op.SettledCurrency, op.CreatedAt)
+	if err != nil {
+		return FXCost{}, err
+	}
+
+	cost := FXCost{
+		OperationID:     op.ID,
+		Name:            op.Name,
+		Type:            op.Type,
+		Date:            op.CreatedAt,
+		Amount:          op.Amount,
+		Currency:        op.Currency,
+		SettledAmount:   *op.SettledAmount,
+		SettledCurrency: op.SettledCurrency,
+		MarketRate:      marketRate,
+		MarketAmount:    market,
+	}
+	if op.EffectiveRate != nil {
+		cost.EffectiveRate = *op.EffectiveRate
+	}
+
+	// Paying more than the market amount is a cost, receiving more is a gain.
+	if op.Type == TypeIncome {
+		cost.Fee = market - cost.SettledAmount
+	} else {
+		cost.Fee = cost.SettledAmount - market
+	}
+	if market != 0 {
+		cost.FeePercent = float64(cost.Fee) / float64(market) * 100
+	}
+
+	return cost, nil
+}
@@ -0,0 +1,197 @@
+package report
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+
+	"github.com/google/uuid"
+)
+
+const monthLayout = "2006-01"
+
+// ItemPurchase is a receipt line with the operation it was bought in.
+// UnitPrice is in Currency.
+type ItemPurchase struct {
+	OperationID uuid.UUID  `json:"operation_id"`
+	Date        time.Time  `json:"date"`
+	PayeeID     *uuid.UUID `json:"payee_id,omitempty"`
+	Payee       string     `json:"payee,omitempty"`
+	Description string     `json:"description"`
+	Quantity    float64    `json:"quantity"`
+	UnitPrice   int        `json:"unit_price"`
+	Currency    string     `json:"currency"`
+	CategoryID  *uuid.UUID `json:"category_id,omitempty"`
+}
+
+// Purchases lists the loaded lines of the operations in their order, naming
+// the payee of each.
+func Purchases(operations []domain.Operation, payees []domain.Payee) []ItemPurchase {
+	names := make(map[uuid.UUID]string, len(payees))
+	for _, p := range payees {
+		names[p.ID] = p.Name
+	}
+
+	list := []ItemPurchase{}
+	for _, op := range operations {
+		for _, item := range op.Items {
+			purchase := ItemPurchase{
+				OperationID: op.ID,
+				Date:        op.CreatedAt,
+				PayeeID:     op.PayeeID,
+				Description: item.Description,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+				Currency:    op.Currency,
+				CategoryID:  item.CategoryID,
+			}
+			if op.PayeeID != nil {
+				purchase.Payee = names[*op.PayeeID]
+			}
+			list = append(list, purchase)
+		}
+	}
+	return list
+}
+
+// PriceStats summarizes unit prices. Average is weighted by quantity, so
+// 2 kg at one price count twice as much as 1 kg at another. Last is the
+// price paid on LastDate.
+type PriceStats struct {
+	Count    int       `json:"count"`
+	Quantity float64   `json:"quantity"`
+	Min      int       `json:"min"`
+	Max      int       `json:"max"`
+	Average  int       `json:"average"`
+	Last     int       `json:"last"`
+	LastDate time.Time `json:"last_date"`
+
+	spent float64
+}
+
+// PayeePrices are the prices paid at one payee; purchases at operations
+// without a payee are gathered under a nil PayeeID.
+type PayeePrices struct {
+	PayeeID *uuid.UUID `json:"payee_id"`
+	Name    string     `json:"name"`
+	PriceStats
+}
+
+// MonthPrices are the prices paid in one month, as "2006-01".
+type MonthPrices struct {
+	Month string `json:"month"`
+	PriceStats
+}
+
+// PriceHistory charts the unit price of an item in Currency: every purchase
+// oldest first, the prices per payee, cheapest on average first, and per
+// month. Purchases without a known rate on their date are listed by
+// operation in Unconverted and left out.
+type PriceHistory struct {
+	Currency    string         `json:"currency"`
+	Overall     PriceStats     `json:"overall"`
+	Purchases   []ItemPurchase `json:"purchases"`
+	Payees      []PayeePrices  `json:"payees"`
+	Months      []MonthPrices  `json:"months"`
+	Unconverted []uuid.UUID    `json:"unconverted,omitempty"`
+}
+
+func BuildPrices(purchases []ItemPurchase, baseCurrency string, conv Converter) (*PriceHistory, error) {
+	history := &PriceHistory{
+		Currency:  baseCurrency,
+		Purchases: []ItemPurchase{},
+		Payees:    []PayeePrices{},
+		Months:    []MonthPrices{},
+	}
+	byPayee := make(map[uuid.UUID]*PayeePrices)
+	byMonth := make(map[string]*MonthPrices)
+	unconverted := make(map[uuid.UUID]bool)
+
+	sorted := append([]ItemPurchase(nil), purchases...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Date.Before(sorted[j].Date) })
+
+	for _, p := range sorted {
+		price, err := conv.Convert(p.UnitPrice, p.Currency, baseCurrency, p.Date)
+		if errors.Is(err, rates.ErrNoRate) {
+			if !unconverted[p.OperationID] {
+				unconverted[p.OperationID] = true
+				history.Unconverted = append(history.Unconverted, p.OperationID)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		p.UnitPrice, p.Currency = price, baseCurrency
+		history.Purchases = append(history.Purchases, p)
+
+		// uuid.Nil collects the purchases without a payee.
+		id := uuid.Nil
+		if p.PayeeID != nil {
+			id = *p.PayeeID
+		}
+		payee, ok := byPayee[id]
+		if !ok {
+			payee = &PayeePrices{PayeeID: p.PayeeID, Name: p.Payee}
+			byPayee[id] = payee
+		}
+
+		month := p.Date.Format(monthLayout)
+		monthly, ok := byMonth[month]
+		if !ok {
+			monthly = &MonthPrices{Month: month}
+			byMonth[month] = monthly
+		}
+
+		for _, stats := range []*PriceStats{&history.Overall, &payee.PriceStats, &monthly.PriceStats} {
+			stats.add(price, p.Quantity, p.Date)
+		}
+	}
+
+	history.Overall.finish()
+	for _, payee := range byPayee {
+		payee.finish()
+		history.Payees = append(history.Payees, *payee)
+	}
+	sort.Slice(history.Payees, func(i, j int) bool {
+		a, b := history.Payees[i], history.Payees[j]
+		if a.Average != b.Average {
+			return a.Average < b.Average
+		}
+		return a.Name < b.Name
+	})
+	for _, monthly := range byMonth {
+		monthly.finish()
+		history.Months = append(history.Months, *monthly)
+	}
+	sort.Slice(history.Months, func(i, j int) bool { return history.Months[i].Month < history.Months[j].Month })
+
+	return history, nil
+}
+
+// add counts a purchase; purchases come oldest first.
+func (s *PriceStats) add(price int, quantity float64, on time.Time) {
+	if quantity <= 0 {
+		quantity = 1
+	}
+	if s.Count == 0 || price < s.Min {
+		s.Min = price
+	}
+	if s.Count == 0 || price > s.Max {
+		s.Max = price
+	}
+	s.Count++
+	s.Quantity += quantity
+	s.spent += float64(price) * quantity
+	s.Last, s.LastDate = price, on
+}
+
+func (s *PriceStats) finish() {
+	if s.Quantity > 0 {
+		s.Average = int(math.Round(s.spent / s.Quantity))
+	}
+}
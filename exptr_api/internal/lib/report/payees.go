@@ -0,0 +1,93 @@
+package report
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+
+	"github.com/google/uuid"
+)
+
+// PayeeTotal sums the operations of one payee. Operations without a payee
+// are gathered under a nil PayeeID. Last is the date of the latest one.
+type PayeeTotal struct {
+	PayeeID *uuid.UUID `json:"payee_id"`
+	Name    string     `json:"name"`
+	Income  int        `json:"income"`
+	Expense int        `json:"expense"`
+	Count   int        `json:"count"`
+	Last    time.Time  `json:"last"`
+}
+
+// PayeeReport lists payee totals in Currency, those spent most with first.
+// Operations without a known rate on their date are listed in Unconverted
+// and left out of every total.
+type PayeeReport struct {
+	Currency    string       `json:"currency"`
+	Payees      []PayeeTotal `json:"payees"`
+	Unconverted []uuid.UUID  `json:"unconverted,omitempty"`
+}
+
+func BuildPayees(operations []domain.Operation, payees []domain.Payee, baseCurrency string, conv Converter) (*PayeeReport, error) {
+	names := make(map[uuid.UUID]string, len(payees))
+	for _, p := range payees {
+		names[p.ID] = p.Name
+	}
+
+	rep := &PayeeReport{Currency: baseCurrency, Payees: []PayeeTotal{}}
+	totals := make(map[uuid.UUID]*PayeeTotal)
+
+	for _, op := range operations {
+		amount, err := amountIn(op, baseCurrency, conv)
+		if errors.Is(err, rates.ErrNoRate) {
+			rep.Unconverted = append(rep.Unconverted, op.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// uuid.Nil collects the operations without a payee.
+		id := uuid.Nil
+		if op.PayeeID != nil {
+			id = *op.PayeeID
+		}
+		total, ok := totals[id]
+		if !ok {
+			total = &PayeeTotal{Name: names[id]}
+			if id != uuid.Nil {
+				total.PayeeID = &id
+			}
+			totals[id] = total
+		}
+
+		if op.Type == TypeIncome {
+			total.Income += amount
+		} else {
+			total.Expense += amount
+		}
+		total.Count++
+		if op.CreatedAt.After(total.Last) {
+			total.Last = op.CreatedAt
+		}
+	}
+
+	for _, t := range totals {
+		rep.Payees = append(rep.Payees, *t)
+	}
+	sort.Slice(rep.Payees, func(i, j int) bool {
+		a, b := rep.Payees[i], rep.Payees[j]
+		if a.Expense != b.Expense {
+			return a.Expense > b.Expense
+		}
+		if a.Income != b.Income {
+			return a.Income > b.Income
+		}
+		return a.Name < b.Name
+	})
+
+	return rep, nil
+}
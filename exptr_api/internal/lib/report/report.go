@@ -0,0 +1,171 @@
+// Package report aggregates operations into totals expressed in a single currency.
+package report
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TypeIncome  = "income"
+	TypeExpense = "expense"
+)
+
+type Converter interface {
+	Rate(base, quote string, on time.Time) (float64, error)
+	Convert(amount int, from, to string, on time.Time) (int, error)
+}
+
+// CategoryTotal sums the operations booked on a category; a split operation
+// counts once in each category it is split over. RollupTotal and
+// RollupCount include its subcategories too; parents without operations of
+// their own are listed with a zero Total so the roll-up has a place to go.
+type CategoryTotal struct {
+	CategoryID  uuid.UUID  `json:"category_id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Total       int        `json:"total"`
+	Count       int        `json:"count"`
+	RollupTotal int        `json:"rollup_total"`
+	RollupCount int        `json:"rollup_count"`
+}
+
+// Summary holds totals in Currency. Operations that could not be converted
+// because no rate was known on their date are listed in Unconverted and left
+// out of every total.
+type Summary struct {
+	Currency    string          `json:"currency"`
+	Income      int             `json:"income"`
+	Expense     int             `json:"expense"`
+	Balance     int             `json:"balance"`
+	Count       int             `json:"count"`
+	Categories  []CategoryTotal `json:"categories"`
+	Unconverted []uuid.UUID     `json:"unconverted,omitempty"`
+}
+
+func Build(operations []domain.Operation, categories []domain.Category, baseCurrency string, conv Converter) (*Summary, error) {
+	names := make(map[uuid.UUID]domain.Category, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c
+	}
+
+	summary := &Summary{Currency: baseCurrency}
+	totals := make(map[uuid.UUID]*CategoryTotal)
+
+	for _, op := range operations {
+		amount, err := amountIn(op, baseCurrency, conv)
+		if errors.Is(err, rates.ErrNoRate) {
+			summary.Unconverted = append(summary.Unconverted, op.ID)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		summary.add(op.Type, amount)
+
+		for _, part := range attribute(op, amount) {
+			total := categoryTotal(totals, names, part.CategoryID)
+			total.Total += part.Amount
+			total.Count++
+		}
+	}
+
+	rollup(totals, names, categorytree.New(categories))
+
+	summary.Balance = summary.Income - summary.Expense
+	summary.Categories = sortedTotals(totals)
+
+	return summary, nil
+}
+
+func categoryTotal(totals map[uuid.UUID]*CategoryTotal, names map[uuid.UUID]domain.Category, id uuid.UUID) *CategoryTotal {
+	total, ok := totals[id]
+	if !ok {
+		category := names[id]
+		total = &CategoryTotal{CategoryID: id, ParentID: category.ParentID, Name: category.Name, Type: category.Type}
+		totals[id] = total
+	}
+	return total
+}
+
+// rollup adds the totals of every category to its ancestors.
+func rollup(totals map[uuid.UUID]*CategoryTotal, names map[uuid.UUID]domain.Category, tree *categorytree.Tree) {
+	booked := make([]*CategoryTotal, 0, len(totals))
+	for _, total := range totals {
+		booked = append(booked, total)
+	}
+
+	for _, total := range booked {
+		total.RollupTotal += total.Total
+		total.RollupCount += total.Count
+		for _, id := range tree.Ancestors(total.CategoryID) {
+			parent := categoryTotal(totals, names, id)
+			parent.RollupTotal += total.Total
+			parent.RollupCount += total.Count
+		}
+	}
+}
+
+// attribute spreads amount, the operation converted into the report
+// currency, over the operation's categories. The splits get shares
+// proportional to their amounts with the rounding remainder on the last, so
+// the parts always add up to amount. An operation without splits goes to
+// its category whole.
+func attribute(op domain.Operation, amount int) []domain.OperationSplit {
+	if !op.Split() || op.Amount == 0 {
+		return []domain.OperationSplit{{CategoryID: op.CategoryID, Amount: amount}}
+	}
+
+	parts := make([]domain.OperationSplit, len(op.Splits))
+	rest := amount
+	for i, split := range op.Splits {
+		share := rest
+		if i < len(op.Splits)-1 {
+			share = int(int64(amount) * int64(split.Amount) / int64(op.Amount))
+		}
+		parts[i] = domain.OperationSplit{CategoryID: split.CategoryID, Amount: share}
+		rest -= share
+	}
+	return parts
+}
+
+// amountIn returns the operation amount in cur, preferring the amount the bank
+// actually settled over a conversion at the market rate.
+func amountIn(op domain.Operation, cur string, conv Converter) (int, error) {
+	if op.Settled() {
+		return conv.Convert(*op.SettledAmount, op.SettledCurrency, cur, op.CreatedAt)
+	}
+	return conv.Convert(op.Amount, op.Currency, cur, op.CreatedAt)
+}
+
+func (s *Summary) add(opType string, amount int) {
+	if opType == TypeIncome {
+		s.Income += amount
+	} else {
+		s.Expense += amount
+	}
+	s.Count++
+}
+
+func sortedTotals(totals map[uuid.UUID]*CategoryTotal) []CategoryTotal {
+	list := make([]CategoryTotal, 0, len(totals))
+	for _, t := range totals {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].RollupTotal != list[j].RollupTotal {
+			return list[i].RollupTotal > list[j].RollupTotal
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
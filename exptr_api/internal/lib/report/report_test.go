@@ -0,0 +1,254 @@
+package report
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fixedRates map[string]float64
+
+func (f fixedRates) GetExchangeRate(base, quote string, _ time.Time) (float64, error) {
+	if rate, ok := f[base+"/"+quote]; ok {
+		return rate, nil
+	}
+	return 0, fmt.Errorf("fixed: %w", storage.ErrItemNotFound)
+}
+
+func TestBuildConvertsIntoBaseCurrency(t *testing.T) {
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food", Type: TypeExpense}
+	salary := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Salary", Type: TypeIncome}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	ops := []domain.Operation{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: food.ID, Amount: 1000, Currency: "EUR", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: food.ID, Amount: 500, Currency: "USD", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: salary.ID, Amount: 300000, Currency: "USD", Type: TypeIncome},
+	}
+	unknown := domain.Operation{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: food.ID, Amount: 100, Currency: "GBP", Type: TypeExpense}
+	ops = append(ops, unknown)
+
+	conv := rates.NewConverter(fixedRates{"EUR/USD": 1.1})
+
+	summary, err := Build(ops, []domain.Category{food, salary}, "USD", conv)
+	require.NoError(t, err)
+
+	require.Equal(t, 300000, summary.Income)
+	require.Equal(t, 1600, summary.Expense)
+	require.Equal(t, 298400, summary.Balance)
+	require.Equal(t, 3, summary.Count)
+	require.Equal(t, []uuid.UUID{unknown.ID}, summary.Unconverted)
+	require.Len(t, summary.Categories, 2)
+	require.Equal(t, "Salary", summary.Categories[0].Name)
+	require.Equal(t, 1600, summary.Categories[1].Total)
+	require.Equal(t, 2, summary.Categories[1].Count)
+}
+
+func TestBuildPrefersSettledAmount(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	settled := 1125
+	rate := 1.125
+	op := domain.Operation{
+		BaseEntity:      domain.BaseEntity{ID: uuid.New(), CreatedAt: day},
+		Amount:          1000,
+		Currency:        "EUR",
+		SettledAmount:   &settled,
+		SettledCurrency: "USD",
+		EffectiveRate:   &rate,
+		Type:            TypeExpense,
+	}
+	conv := rates.NewConverter(fixedRates{"EUR/USD": 1.1})
+
+	summary, err := Build([]domain.Operation{op}, nil, "USD", conv)
+	require.NoError(t, err)
+	require.Equal(t, 1125, summary.Expense)
+
+	fx, err := BuildFX([]domain.Operation{op}, "USD", conv)
+	require.NoError(t, err)
+	require.Len(t, fx.Operations, 1)
+	require.Equal(t, 1100, fx.Operations[0].MarketAmount)
+	require.Equal(t, 25, fx.Operations[0].Fee)
+	require.Equal(t, 25, fx.TotalFee)
+}
+
+func TestBuildRollsUpSubcategories(t *testing.T) {
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Food", Type: TypeExpense}
+	restaurants := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ParentID: &food.ID, Name: "Restaurants", Type: TypeExpense}
+	coffee := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ParentID: &restaurants.ID, Name: "Coffee", Type: TypeExpense}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	ops := []domain.Operation{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: coffee.ID, Amount: 300, Currency: "USD", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: coffee.ID, Amount: 200, Currency: "USD", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, CategoryID: food.ID, Amount: 1000, Currency: "USD", Type: TypeExpense},
+	}
+
+	summary, err := Build(ops, []domain.Category{food, restaurants, coffee}, "USD", rates.NewConverter(fixedRates{}))
+	require.NoError(t, err)
+	require.Len(t, summary.Categories, 3)
+
+	byName := make(map[string]CategoryTotal)
+	for _, total := range summary.Categories {
+		byName[total.Name] = total
+	}
+	require.Equal(t, "Food", summary.Categories[0].Name)
+	require.Equal(t, 1000, byName["Food"].Total)
+	require.Equal(t, 1500, byName["Food"].RollupTotal)
+	require.Equal(t, 3, byName["Food"].RollupCount)
+	require.Equal(t, 0, byName["Restaurants"].Total)
+	require.Equal(t, 500, byName["Restaurants"].RollupTotal)
+	require.Equal(t, &food.ID, byName["Restaurants"].ParentID)
+	require.Equal(t, 500, byName["Coffee"].Total)
+	require.Equal(t, 500, byName["Coffee"].RollupTotal)
+}
+
+func TestBuildAttributesSplits(t *testing.T) {
+	groceries := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Groceries", Type: TypeExpense}
+	household := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Household", Type: TypeExpense}
+	alcohol := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Alcohol", Type: TypeExpense}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	receipt := domain.Operation{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day},
+		CategoryID: groceries.ID, Amount: 1000, Currency: "EUR", Type: TypeExpense,
+		Splits: []domain.OperationSplit{
+			{CategoryID: groceries.ID, Amount: 600},
+			{CategoryID: household.ID, Amount: 300},
+			{CategoryID: alcohol.ID, Amount: 100},
+		},
+	}
+	bread := domain.Operation{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day},
+		CategoryID: groceries.ID, Amount: 200, Currency: "EUR", Type: TypeExpense,
+	}
+	conv := rates.NewConverter(fixedRates{"EUR/USD": 1.1})
+
+	summary, err := Build([]domain.Operation{receipt, bread}, []domain.Category{groceries, household, alcohol}, "USD", conv)
+	require.NoError(t, err)
+
+	require.Equal(t, 1320, summary.Expense)
+	require.Equal(t, 2, summary.Count)
+
+	byName := make(map[string]CategoryTotal)
+	sum := 0
+	for _, total := range summary.Categories {
+		byName[total.Name] = total
+		sum += total.Total
+	}
+	require.Equal(t, summary.Expense, sum)
+	require.Equal(t, 880, byName["Groceries"].Total)
+	require.Equal(t, 2, byName["Groceries"].Count)
+	require.Equal(t, 330, byName["Household"].Total)
+	require.Equal(t, 110, byName["Alcohol"].Total)
+}
+
+func TestAttributeKeepsRoundingRemainder(t *testing.T) {
+	op := domain.Operation{
+		Amount: 3,
+		Splits: []domain.OperationSplit{
+			{CategoryID: uuid.New(), Amount: 1},
+			{CategoryID: uuid.New(), Amount: 1},
+			{CategoryID: uuid.New(), Amount: 1},
+		},
+	}
+
+	parts := attribute(op, 10)
+	require.Len(t, parts, 3)
+	require.Equal(t, 3, parts[0].Amount)
+	require.Equal(t, 3, parts[1].Amount)
+	require.Equal(t, 4, parts[2].Amount)
+}
+
+func TestBuildPayees(t *testing.T) {
+	lidl := domain.Payee{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Lidl"}
+	employer := domain.Payee{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Acme"}
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	later := day.AddDate(0, 0, 5)
+
+	ops := []domain.Operation{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, PayeeID: &lidl.ID, Amount: 1000, Currency: "EUR", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: later}, PayeeID: &lidl.ID, Amount: 2000, Currency: "USD", Type: TypeExpense},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, PayeeID: &employer.ID, Amount: 300000, Currency: "USD", Type: TypeIncome},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: day}, Amount: 700, Currency: "USD", Type: TypeExpense},
+	}
+	conv := rates.NewConverter(fixedRates{"EUR/USD": 1.1})
+
+	rep, err := BuildPayees(ops, []domain.Payee{lidl, employer}, "USD", conv)
+	require.NoError(t, err)
+	require.Equal(t, "USD", rep.Currency)
+	require.Len(t, rep.Payees, 3)
+
+	require.Equal(t, &lidl.ID, rep.Payees[0].PayeeID)
+	require.Equal(t, "Lidl", rep.Payees[0].Name)
+	require.Equal(t, 3100, rep.Payees[0].Expense)
+	require.Equal(t, 2, rep.Payees[0].Count)
+	require.Equal(t, later, rep.Payees[0].Last)
+
+	require.Nil(t, rep.Payees[1].PayeeID, "operations without a payee are gathered together")
+	require.Equal(t, 700, rep.Payees[1].Expense)
+
+	require.Equal(t, "Acme", rep.Payees[2].Name)
+	require.Equal(t, 300000, rep.Payees[2].Income)
+}
+
+func TestBuildPrices(t *testing.T) {
+	lidl := domain.Payee{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Lidl"}
+	aldi := domain.Payee{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Aldi"}
+	jan := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	ops := []domain.Operation{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: feb}, PayeeID: &lidl.ID, Currency: "EUR", Items: []domain.OperationItem{
+			{Description: "Milk 1 l", Quantity: 2, UnitPrice: 120},
+		}},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: jan}, PayeeID: &lidl.ID, Currency: "EUR", Items: []domain.OperationItem{
+			{Description: "Milk 1 l", Quantity: 1, UnitPrice: 90},
+		}},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: feb}, PayeeID: &aldi.ID, Currency: "USD", Items: []domain.OperationItem{
+			{Description: "Milk 1L", Quantity: 1, UnitPrice: 110},
+		}},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: jan}, Currency: "GBP", Items: []domain.OperationItem{
+			{Description: "Milk", Quantity: 1, UnitPrice: 100},
+		}},
+	}
+	purchases := Purchases(ops, []domain.Payee{lidl, aldi})
+	require.Len(t, purchases, 4)
+	require.Equal(t, "Lidl", purchases[0].Payee)
+	require.Equal(t, "EUR", purchases[0].Currency)
+
+	conv := rates.NewConverter(fixedRates{"EUR/USD": 1.1})
+	history, err := BuildPrices(purchases, "EUR", conv)
+	require.NoError(t, err)
+	require.Equal(t, []uuid.UUID{ops[3].ID}, history.Unconverted)
+
+	require.Len(t, history.Purchases, 3)
+	require.Equal(t, jan, history.Purchases[0].Date, "oldest first")
+	require.Equal(t, 100, history.Purchases[2].UnitPrice, "110 USD in EUR")
+	require.Equal(t, "EUR", history.Purchases[2].Currency)
+
+	require.Equal(t, 3, history.Overall.Count)
+	require.Equal(t, 90, history.Overall.Min)
+	require.Equal(t, 120, history.Overall.Max)
+	require.Equal(t, 108, history.Overall.Average, "(90 + 2*120 + 100) / 4")
+
+	require.Len(t, history.Payees, 2)
+	require.Equal(t, "Aldi", history.Payees[0].Name, "cheapest first")
+	require.Equal(t, 100, history.Payees[0].Average)
+	require.Equal(t, "Lidl", history.Payees[1].Name)
+	require.Equal(t, 110, history.Payees[1].Average)
+	require.Equal(t, 120, history.Payees[1].Last)
+	require.Equal(t, feb, history.Payees[1].LastDate)
+
+	require.Len(t, history.Months, 2)
+	require.Equal(t, "2024-01", history.Months[0].Month)
+	require.Equal(t, 90, history.Months[0].Average)
+	require.Equal(t, "2024-02", history.Months[1].Month)
+	require.Equal(t, 113, history.Months[1].Average, "(2*120 + 100) / 3")
+}
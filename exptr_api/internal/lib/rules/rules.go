@@ -0,0 +1,221 @@
+// Package rules runs user-defined auto-categorization rules against
+// operations. A Set is compiled once per request and evaluated for every
+// operation being created, imported or previewed.
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Input is what rule conditions look at.
+type Input struct {
+	Name     string
+	Comment  string
+	Amount   int
+	Currency string
+	Account  string
+	Type     string
+}
+
+func FromRequest(op *models.OperationRequest) Input {
+	return Input{
+		Name:     op.Name,
+		Comment:  op.Comment,
+		Amount:   op.Amount,
+		Currency: op.Currency,
+		Account:  op.Account,
+		Type:     op.Type,
+	}
+}
+
+func FromOperation(op *domain.Operation) Input {
+	return Input{
+		Name:     op.Name,
+		Comment:  op.Comment,
+		Amount:   op.Amount,
+		Currency: op.Currency,
+		Account:  op.Account,
+		Type:     op.Type,
+	}
+}
+
+// Result collects what the matching rules set. The first matching rule
+// that sets the category or the name wins; tags of all of them add up.
+type Result struct {
+	Matched    []uuid.UUID
+	CategoryID *uuid.UUID
+	Tags       []string
+	Name       string
+}
+
+// Apply writes the result into an operation request. A category given with
+// the request is kept.
+func (r Result) Apply(op *models.OperationRequest) {
+	if r.CategoryID != nil && op.CategoryID == uuid.Nil {
+		op.CategoryID = *r.CategoryID
+	}
+	if r.Name != "" {
+		op.Name = r.Name
+	}
+	op.Tags = append(op.Tags, r.Tags...)
+}
+
+type compiled struct {
+	rule    domain.Rule
+	name    *textMatcher
+	comment *textMatcher
+}
+
+type textMatcher struct {
+	contains string
+	regex    *regexp.Regexp
+}
+
+func (m *textMatcher) match(s string) bool {
+	if m.contains != "" && !strings.Contains(strings.ToLower(s), m.contains) {
+		return false
+	}
+	if m.regex != nil && !m.regex.MatchString(s) {
+		return false
+	}
+	return true
+}
+
+// Set is a compiled, ordered list of enabled rules.
+type Set struct {
+	rules []compiled
+}
+
+// Compile checks the rules and orders the enabled ones by priority, oldest
+// first among equals.
+func Compile(rules []domain.Rule) (*Set, error) {
+	set := &Set{rules: make([]compiled, 0, len(rules))}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c, err := compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		set.rules = append(set.rules, c)
+	}
+
+	sort.SliceStable(set.rules, func(i, j int) bool {
+		a, b := set.rules[i].rule, set.rules[j].rule
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return set, nil
+}
+
+// Check reports why a rule cannot be used, such as a broken regular
+// expression or a rule without conditions or actions.
+func Check(rule domain.Rule) error {
+	if rule.Conditions.Empty() {
+		return errors.New("rule has no conditions")
+	}
+	if rule.Actions.Empty() {
+		return errors.New("rule has no actions")
+	}
+	_, err := compile(rule)
+	return err
+}
+
+func compile(rule domain.Rule) (compiled, error) {
+	c := compiled{rule: rule}
+
+	var err error
+	if c.name, err = compileText(rule.Conditions.Name); err != nil {
+		return c, fmt.Errorf("rule %q: name: %w", rule.Name, err)
+	}
+	if c.comment, err = compileText(rule.Conditions.Comment); err != nil {
+		return c, fmt.Errorf("rule %q: comment: %w", rule.Name, err)
+	}
+
+	return c, nil
+}
+
+func compileText(cond *domain.TextCondition) (*textMatcher, error) {
+	if cond == nil {
+		return nil, nil
+	}
+	m := &textMatcher{contains: strings.ToLower(cond.Contains)}
+	if cond.Regex != "" {
+		re, err := regexp.Compile(cond.Regex)
+		if err != nil {
+			return nil, err
+		}
+		m.regex = re
+	}
+	return m, nil
+}
+
+// Len returns the number of enabled rules.
+func (s *Set) Len() int {
+	return len(s.rules)
+}
+
+// Evaluate runs the rules against an operation in priority order.
+func (s *Set) Evaluate(in Input) Result {
+	var res Result
+	for _, c := range s.rules {
+		if !c.match(in) {
+			continue
+		}
+
+		res.Matched = append(res.Matched, c.rule.ID)
+		actions := c.rule.Actions
+		if res.CategoryID == nil && actions.CategoryID != nil {
+			id := *actions.CategoryID
+			res.CategoryID = &id
+		}
+		if res.Name == "" {
+			res.Name = actions.Name
+		}
+		res.Tags = append(res.Tags, actions.Tags...)
+
+		if c.rule.Stop {
+			break
+		}
+	}
+	return res
+}
+
+func (c compiled) match(in Input) bool {
+	cond := c.rule.Conditions
+	if c.name != nil && !c.name.match(in.Name) {
+		return false
+	}
+	if c.comment != nil && !c.comment.match(in.Comment) {
+		return false
+	}
+	if cond.AmountMin != nil && in.Amount < *cond.AmountMin {
+		return false
+	}
+	if cond.AmountMax != nil && in.Amount > *cond.AmountMax {
+		return false
+	}
+	if cond.Currency != "" && !strings.EqualFold(cond.Currency, in.Currency) {
+		return false
+	}
+	if cond.Account != "" && !strings.EqualFold(cond.Account, in.Account) {
+		return false
+	}
+	if cond.Type != "" && cond.Type != in.Type {
+		return false
+	}
+	return true
+}
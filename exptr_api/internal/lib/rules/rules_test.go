@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func rule(priority int, cond domain.RuleConditions, actions domain.RuleActions) domain.Rule {
+	return domain.Rule{
+		BaseEntity: domain.BaseEntity{ID: uuid.New(), CreatedAt: time.Now()},
+		Name:       "rule",
+		Priority:   priority,
+		Enabled:    true,
+		Conditions: cond,
+		Actions:    actions,
+	}
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestEvaluateConditions(t *testing.T) {
+	groceries := uuid.New()
+	r := rule(0, domain.RuleConditions{
+		Name:      &domain.TextCondition{Contains: "lidl"},
+		AmountMin: intPtr(100),
+		AmountMax: intPtr(10000),
+		Currency:  "EUR",
+	}, domain.RuleActions{CategoryID: &groceries})
+
+	set, err := Compile([]domain.Rule{r})
+	require.NoError(t, err)
+
+	cases := []struct {
+		name  string
+		in    Input
+		match bool
+	}{
+		{"matches ignoring case", Input{Name: "LIDL Berlin", Amount: 2500, Currency: "eur"}, true},
+		{"other payee", Input{Name: "Aldi", Amount: 2500, Currency: "EUR"}, false},
+		{"below range", Input{Name: "Lidl", Amount: 99, Currency: "EUR"}, false},
+		{"range is inclusive", Input{Name: "Lidl", Amount: 10000, Currency: "EUR"}, true},
+		{"other currency", Input{Name: "Lidl", Amount: 2500, Currency: "USD"}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := set.Evaluate(tc.in)
+			if tc.match {
+				require.Equal(t, []uuid.UUID{r.ID}, res.Matched)
+				require.Equal(t, groceries, *res.CategoryID)
+			} else {
+				require.Empty(t, res.Matched)
+				require.Nil(t, res.CategoryID)
+			}
+		})
+	}
+}
+
+func TestEvaluateRegex(t *testing.T) {
+	r := rule(0, domain.RuleConditions{
+		Comment: &domain.TextCondition{Regex: `(?i)^spotify\s+p\d+`},
+	}, domain.RuleActions{Name: "Spotify"})
+
+	set, err := Compile([]domain.Rule{r})
+	require.NoError(t, err)
+
+	require.Equal(t, "Spotify", set.Evaluate(Input{Comment: "SPOTIFY P1234 Stockholm"}).Name)
+	require.Empty(t, set.Evaluate(Input{Comment: "card payment spotify"}).Matched)
+}
+
+func TestEvaluatePriorityAndStop(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	cond := domain.RuleConditions{Name: &domain.TextCondition{Contains: "shop"}}
+
+	low := rule(10, cond, domain.RuleActions{CategoryID: &second, Tags: []string{"later"}})
+	high := rule(1, cond, domain.RuleActions{CategoryID: &first, Tags: []string{"business"}})
+	disabled := rule(0, cond, domain.RuleActions{Name: "never"})
+	disabled.Enabled = false
+
+	set, err := Compile([]domain.Rule{low, high, disabled})
+	require.NoError(t, err)
+	require.Equal(t, 2, set.Len())
+
+	res := set.Evaluate(Input{Name: "Shop"})
+	require.Equal(t, []uuid.UUID{high.ID, low.ID}, res.Matched)
+	require.Equal(t, first, *res.CategoryID)
+	require.Equal(t, []string{"business", "later"}, res.Tags)
+	require.Empty(t, res.Name)
+
+	high.Stop = true
+	set, err = Compile([]domain.Rule{low, high})
+	require.NoError(t, err)
+	res = set.Evaluate(Input{Name: "Shop"})
+	require.Equal(t, []uuid.UUID{high.ID}, res.Matched)
+	require.Equal(t, []string{"business"}, res.Tags)
+}
+
+func TestApplyKeepsGivenCategory(t *testing.T) {
+	given, ruled := uuid.New(), uuid.New()
+	res := Result{CategoryID: &ruled, Tags: []string{"auto"}, Name: "Renamed"}
+
+	op := models.OperationRequest{CategoryID: given, Name: "raw", Tags: []string{"manual"}}
+	res.Apply(&op)
+	require.Equal(t, given, op.CategoryID)
+	require.Equal(t, "Renamed", op.Name)
+	require.Equal(t, []string{"manual", "auto"}, op.Tags)
+
+	op = models.OperationRequest{Name: "raw"}
+	res.Apply(&op)
+	require.Equal(t, ruled, op.CategoryID)
+}
+
+func TestCheck(t *testing.T) {
+	id := uuid.New()
+	require.Error(t, Check(rule(0, domain.RuleConditions{}, domain.RuleActions{CategoryID: &id})))
+	require.Error(t, Check(rule(0, domain.RuleConditions{Currency: "EUR"}, domain.RuleActions{})))
+	require.Error(t, Check(rule(0, domain.RuleConditions{Name: &domain.TextCondition{Regex: "("}}, domain.RuleActions{CategoryID: &id})))
+	require.NoError(t, Check(rule(0, domain.RuleConditions{Currency: "EUR"}, domain.RuleActions{CategoryID: &id})))
+}
@@ -0,0 +1,189 @@
+// Package templates holds the category sets bundled into the binary that new
+// accounts start with. Each template has names in several languages; a name
+// missing in the requested language falls back to English.
+package templates
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"golang.org/x/text/language"
+)
+
+const (
+	// Default is applied on signup when no template is chosen.
+	Default = "basic"
+	// None skips seeding categories.
+	None = "none"
+
+	FallbackLocale = "en"
+)
+
+//go:embed data/*.json
+var files embed.FS
+
+// Text is a string in several languages keyed by ISO 639-1 code.
+type Text map[string]string
+
+// In returns the text in locale, trying its base language and English next.
+func (t Text) In(locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if s, ok := t[locale]; ok {
+		return s
+	}
+	if base, _, ok := strings.Cut(locale, "-"); ok {
+		if s, ok := t[base]; ok {
+			return s
+		}
+	}
+	return t[FallbackLocale]
+}
+
+// Entry is a template category. Children inherit Type and Color when they
+// leave them empty.
+type Entry struct {
+	Name     Text    `json:"name"`
+	Type     string  `json:"type"`
+	Color    string  `json:"color"`
+	Icon     string  `json:"icon"`
+	Children []Entry `json:"children"`
+}
+
+type Template struct {
+	ID          string  `json:"id"`
+	Name        Text    `json:"name"`
+	Description Text    `json:"description"`
+	Categories  []Entry `json:"categories"`
+}
+
+var catalog = mustLoad()
+
+func mustLoad() map[string]*Template {
+	entries, err := files.ReadDir("data")
+	if err != nil {
+		panic(err)
+	}
+
+	catalog := make(map[string]*Template, len(entries))
+	for _, entry := range entries {
+		data, err := files.ReadFile(path.Join("data", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var t Template
+		if err := json.Unmarshal(data, &t); err != nil {
+			panic(fmt.Sprintf("templates: %s: %v", entry.Name(), err))
+		}
+		catalog[t.ID] = &t
+	}
+
+	return catalog
+}
+
+func Get(id string) (*Template, bool) {
+	t, ok := catalog[id]
+	return t, ok
+}
+
+// List returns the bundled templates sorted by id.
+func List() []*Template {
+	list := make([]*Template, 0, len(catalog))
+	for _, t := range catalog {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Locale picks the preferred language of an Accept-Language header,
+// defaulting to English.
+func Locale(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return FallbackLocale
+	}
+	base, _ := tags[0].Base()
+	return base.String()
+}
+
+// Build turns the template into categories of userID named in locale,
+// parents before children, with ids assigned so children can point at them.
+func (t *Template) Build(userID uuid.UUID, locale string) []domain.Category {
+	var categories []domain.Category
+	var add func(entries []Entry, parent *domain.Category)
+	add = func(entries []Entry, parent *domain.Category) {
+		for _, e := range entries {
+			c := domain.Category{
+				BaseEntity: domain.BaseEntity{ID: uuid.New()},
+				UserID:     userID,
+				Name:       e.Name.In(locale),
+				Type:       e.Type,
+				Color:      e.Color,
+				Icon:       e.Icon,
+			}
+			if parent != nil {
+				c.ParentID = &parent.ID
+				if c.Type == "" {
+					c.Type = parent.Type
+				}
+				if c.Color == "" {
+					c.Color = parent.Color
+				}
+			}
+			categories = append(categories, c)
+			add(e.Children, &c)
+		}
+	}
+	add(t.Categories, nil)
+
+	return categories
+}
+
+// Missing returns the template categories the user does not have yet, so a
+// template can be applied to an account that already has categories. A
+// category matches an existing one with the same name, type and parent,
+// ignoring case; children of a matched category are nested under the
+// existing one.
+func Missing(categories, existing []domain.Category) []domain.Category {
+	type key struct {
+		parent uuid.UUID
+		name   string
+		typ    string
+	}
+	keyOf := func(c domain.Category) key {
+		k := key{name: strings.ToLower(strings.TrimSpace(c.Name)), typ: c.Type}
+		if c.ParentID != nil {
+			k.parent = *c.ParentID
+		}
+		return k
+	}
+
+	have := make(map[key]uuid.UUID, len(existing))
+	for _, c := range existing {
+		have[keyOf(c)] = c.ID
+	}
+
+	matched := make(map[uuid.UUID]uuid.UUID)
+	var missing []domain.Category
+	for _, c := range categories {
+		if c.ParentID != nil {
+			if id, ok := matched[*c.ParentID]; ok {
+				c.ParentID = &id
+			}
+		}
+		if id, ok := have[keyOf(c)]; ok {
+			matched[c.ID] = id
+			continue
+		}
+		missing = append(missing, c)
+	}
+
+	return missing
+}
@@ -0,0 +1,75 @@
+package templates
+
+import (
+	"testing"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalog(t *testing.T) {
+	list := List()
+	require.Len(t, list, 2)
+
+	for _, tmpl := range list {
+		require.NotEmpty(t, tmpl.Name.In(FallbackLocale), tmpl.ID)
+		for _, c := range tmpl.Build(uuid.New(), "ru") {
+			require.NotEmpty(t, c.Name, tmpl.ID)
+			require.Contains(t, []string{"income", "expense"}, c.Type, c.Name)
+			require.NotEmpty(t, c.Color, c.Name)
+		}
+	}
+
+	_, ok := Get(Default)
+	require.True(t, ok)
+}
+
+func TestCategoriesAreLocalizedAndNested(t *testing.T) {
+	tmpl, ok := Get("detailed")
+	require.True(t, ok)
+
+	userID := uuid.New()
+	categories := tmpl.Build(userID, "de-AT")
+
+	byName := make(map[string]domain.Category)
+	for _, c := range categories {
+		require.Equal(t, userID, c.UserID)
+		byName[c.Name] = c
+	}
+	food, ok := byName["Essen"]
+	require.True(t, ok)
+	coffee, ok := byName["Kaffee"]
+	require.True(t, ok)
+	require.Equal(t, food.ID, *coffee.ParentID)
+	require.Equal(t, food.Type, coffee.Type)
+	require.Equal(t, food.Color, coffee.Color)
+
+	require.Equal(t, "Food", Text{"en": "Food"}.In("pt-BR"))
+}
+
+func TestLocale(t *testing.T) {
+	require.Equal(t, "ru", Locale("ru-RU,ru;q=0.9,en;q=0.8"))
+	require.Equal(t, "de", Locale("de"))
+	require.Equal(t, FallbackLocale, Locale(""))
+}
+
+func TestMissing(t *testing.T) {
+	tmpl, _ := Get("detailed")
+	categories := tmpl.Build(uuid.New(), "en")
+
+	food := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "food", Type: "expense"}
+	coffee := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, ParentID: &food.ID, Name: "Coffee", Type: "expense"}
+
+	missing := Missing(categories, []domain.Category{food, coffee})
+	require.Len(t, missing, len(categories)-2)
+
+	for _, c := range missing {
+		require.NotEqual(t, "Food", c.Name)
+		require.NotEqual(t, "Coffee", c.Name)
+		if c.Name == "Groceries" {
+			require.Equal(t, food.ID, *c.ParentID)
+		}
+	}
+}
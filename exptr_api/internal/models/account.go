@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+)
+
+// AccountImportResponse counts what an account archive restored.
+type AccountImportResponse struct {
+	response.Response
+	Version             int `json:"version"`
+	Categories          int `json:"categories"`
+	Operations          int `json:"operations"`
+	ImportProfiles      int `json:"import_profiles"`
+	DuplicateDismissals int `json:"duplicate_dismissals"`
+	Attachments         int `json:"attachments"`
+}
+
+// AccountDeletionRequest confirms an account deletion with the current password.
+type AccountDeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// AccountDeletion describes a pending deletion. Both times are empty when
+// no deletion was requested.
+type AccountDeletion struct {
+	RequestedAt *time.Time `json:"requested_at"`
+	DeleteAfter *time.Time `json:"delete_after"`
+}
+
+type AccountDeletionResponse struct {
+	response.Response
+	Deletion AccountDeletion `json:"deletion"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+)
+
+type GetAttachmentsResponse struct {
+	response.Response
+	Attachments []domain.Attachment `json:"attachments"`
+}
+
+// CreateAttachmentsResponse lists the attachments of the uploaded files.
+// Files the operation already had count as Existing and are listed with
+// their earlier attachment.
+type CreateAttachmentsResponse struct {
+	response.Response
+	Attachments []domain.Attachment `json:"attachments"`
+	Created     int                 `json:"created"`
+	Existing    int                 `json:"existing"`
+}
@@ -5,23 +5,79 @@ import (
 
 	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
+
+	"github.com/google/uuid"
 )
 
 type CategoryRequest struct {
-	UserID    string    `json:"user_id"`
-	Name      string    `json:"name"`
-	Type      string    `json:"type"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Color     string    `json:"color"`
-	Icon      string    `json:"icon"`
+	UserID    string     `json:"user_id"`
+	ParentID  *uuid.UUID `json:"parent_id"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	Color     string     `json:"color"`
+	Icon      string     `json:"icon"`
 }
 
 type CategoryResponse struct {
 	response.Response
 }
 
+// GetCategoriesResponse lists categories flat. Tree nests the same
+// categories under their parents and is only filled when asked for.
 type GetCategoriesResponse struct {
 	response.Response
-	Categories []domain.Category `json:"categories"`
+	Categories []domain.Category    `json:"categories"`
+	Tree       []*categorytree.Node `json:"tree,omitempty"`
+}
+
+// MoveCategoryRequest puts a category and its subtree under ParentID, or
+// makes it a top-level category when ParentID is null.
+type MoveCategoryRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"`
+}
+
+type MoveCategoryResponse struct {
+	response.Response
+	Category *domain.Category `json:"category"`
+}
+
+// DeleteCategoryResponse counts the operations moved to another category or
+// to the trash together with the deleted one.
+type DeleteCategoryResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+}
+
+type MergeCategoriesRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+}
+
+// MergeCategoriesResponse returns the surviving category and how many
+// operations moved into it.
+type MergeCategoriesResponse struct {
+	response.Response
+	Category   *domain.Category `json:"category"`
+	Operations int              `json:"operations"`
+}
+
+// CategoryTemplate previews a bundled template in the requested language.
+type CategoryTemplate struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Categories  []*categorytree.Node `json:"categories"`
+}
+
+type GetCategoryTemplatesResponse struct {
+	response.Response
+	Templates []CategoryTemplate `json:"templates"`
+}
+
+// ApplyCategoryTemplateResponse counts the categories the template added.
+type ApplyCategoryTemplateResponse struct {
+	response.Response
+	Created int `json:"created"`
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+
+	"github.com/google/uuid"
+)
+
+type GetDuplicatesResponse struct {
+	response.Response
+	Pairs []dedup.Pair `json:"pairs"`
+}
+
+// MergeDuplicatesRequest keeps KeepID and deletes DropID.
+type MergeDuplicatesRequest struct {
+	KeepID uuid.UUID `json:"keep_id" validate:"required"`
+	DropID uuid.UUID `json:"drop_id" validate:"required,nefield=KeepID"`
+}
+
+type MergeDuplicatesResponse struct {
+	response.Response
+	Operation *domain.Operation `json:"operation"`
+}
+
+// DismissDuplicateRequest marks two operations as not duplicates.
+type DismissDuplicateRequest struct {
+	OperationID uuid.UUID `json:"operation_id" validate:"required"`
+	OtherID     uuid.UUID `json:"other_id" validate:"required,nefield=OperationID"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+
+	"github.com/google/uuid"
+)
+
+type ImportProfileRequest struct {
+	Name    string            `json:"name" validate:"required"`
+	Bank    string            `json:"bank"`
+	Mapping domain.CSVMapping `json:"mapping"`
+}
+
+type ImportProfileResponse struct {
+	response.Response
+	Profile *domain.ImportProfile `json:"profile,omitempty"`
+}
+
+type GetImportProfilesResponse struct {
+	response.Response
+	Profiles []domain.ImportProfile `json:"profiles"`
+}
+
+// ImportRow reports what happened to a statement row in a preview or an import.
+// Rows that look like an operation already stored point to it in DuplicateOf
+// with the match Score. Suggestion is the category proposed for a row the
+// statement and the rules left without one.
+type ImportRow struct {
+	Line        int                 `json:"line"`
+	Status      string              `json:"status"`
+	Error       string              `json:"error,omitempty"`
+	ExternalID  string              `json:"external_id,omitempty"`
+	DuplicateOf *uuid.UUID          `json:"duplicate_of,omitempty"`
+	Score       float64             `json:"score,omitempty"`
+	Suggestion  *CategorySuggestion `json:"suggestion,omitempty"`
+	Operation   OperationRequest    `json:"operation"`
+}
+
+// ImportResponse reports the outcome of every statement row. In a dry run
+// nothing is stored and ready rows show what would be imported. Rows that
+// were imported before, or look like an operation already stored, are
+// skipped. Statements that carry balances are checked against their entries
+// in Balances, and Categories shows how source categories are mapped.
+type ImportResponse struct {
+	response.Response
+	DryRun     bool              `json:"dry_run"`
+	Total      int               `json:"total"`
+	Imported   int               `json:"imported"`
+	Failed     int               `json:"failed"`
+	Skipped    int               `json:"skipped"`
+	Rows       []ImportRow       `json:"rows"`
+	Balances   []BalanceCheck    `json:"balances,omitempty"`
+	Categories []CategoryMapping `json:"categories,omitempty"`
+}
+
+// CategoryMapping shows which category the rows of a source category go to.
+// Create marks a category that does not exist yet and is added on import;
+// a mapping without CategoryID leaves its rows to the default category.
+type CategoryMapping struct {
+	Name       string     `json:"name"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Category   string     `json:"category,omitempty"`
+	Create     bool       `json:"create"`
+	Operations int        `json:"operations"`
+}
+
+// BalanceCheck compares a statement's closing balance with its opening
+// balance plus the net of the parsed entries, all in minor units.
+type BalanceCheck struct {
+	Statement  string `json:"statement,omitempty"`
+	Currency   string `json:"currency"`
+	Opening    int    `json:"opening"`
+	Closing    int    `json:"closing"`
+	Net        int    `json:"net"`
+	Difference int    `json:"difference"`
+	Balanced   bool   `json:"balanced"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+)
+
+type GetItemsResponse struct {
+	response.Response
+	Items []report.ItemPurchase `json:"items"`
+}
+
+type GetItemPricesResponse struct {
+	response.Response
+	Prices *report.PriceHistory `json:"prices"`
+}
@@ -5,24 +5,88 @@ import (
 
 	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/report"
 
 	"github.com/google/uuid"
 )
 
+// OperationRequest creates or updates an operation. SettledAmount and
+// SettledCurrency hold what the bank actually charged for a foreign-currency
+// operation; EffectiveRate is derived from them when omitted.
+//
+// Tags are names; unknown ones are created. On update nil keeps the current
+// tags and an empty list removes them.
+//
+// CategoryID may be omitted on create when a rule sets it or when splits
+// are given. Splits spread the operation over several categories and must
+// add up to Amount; CategoryID then defaults to the first split's.
+// On update nil keeps the current splits and an empty list removes them.
+//
+// PayeeID links the operation to one of the user's payees. When it is
+// omitted the payee is found from Name, and created for a new merchant.
+//
+// Items are the lines of the receipt. On update nil keeps the current items
+// and an empty list removes them.
 type OperationRequest struct {
-	UserID     uuid.UUID `json:"user_id" validate:"required"`
+	UserID          uuid.UUID      `json:"user_id" validate:"required"`
+	CategoryID      uuid.UUID      `json:"category_id"`
+	Amount          int            `json:"amount" validate:"required"`
+	Currency        string         `json:"currency" validate:"required"`
+	SettledAmount   *int           `json:"settled_amount,omitempty"`
+	SettledCurrency string         `json:"settled_currency,omitempty" validate:"required_with=SettledAmount"`
+	EffectiveRate   *float64       `json:"effective_rate,omitempty"`
+	Name            string         `json:"name" validate:"required"`
+	Comment         string         `json:"comment"`
+	Type            string         `json:"type" validate:"required"`
+	Account         string         `json:"account,omitempty" validate:"max=255"`
+	CreatedAt       time.Time      `json:"created_at" validate:"required"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	ExternalID      string         `json:"external_id,omitempty"`
+	Tags            []string       `json:"tags,omitempty" validate:"max=20,dive,max=64"`
+	Splits          []SplitRequest `json:"splits,omitempty" validate:"omitempty,min=2,max=50,dive"`
+	PayeeID         *uuid.UUID     `json:"payee_id,omitempty"`
+	Items           []ItemRequest  `json:"items,omitempty" validate:"max=200,dive"`
+}
+
+// SplitRequest is one line of a split operation, in the operation's currency.
+type SplitRequest struct {
 	CategoryID uuid.UUID `json:"category_id" validate:"required"`
 	Amount     int       `json:"amount" validate:"required"`
-	Currency   string    `json:"currency" validate:"required"`
-	Name       string    `json:"name" validate:"required"`
-	Comment    string    `json:"comment"`
-	Type       string    `json:"type" validate:"required"`
-	CreatedAt  time.Time `json:"created_at" validate:"required"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	Note       string    `json:"note,omitempty" validate:"max=255"`
+}
+
+// ItemRequest is one receipt line, its UnitPrice in the operation's
+// currency. A zero Quantity counts as one; a negative UnitPrice is a
+// discount. CategoryID, unless it names one of the user's categories, is
+// dropped.
+type ItemRequest struct {
+	Description string     `json:"description" validate:"required,max=255"`
+	Quantity    float64    `json:"quantity" validate:"gte=0"`
+	UnitPrice   int        `json:"unit_price"`
+	CategoryID  *uuid.UUID `json:"category_id,omitempty"`
 }
 
+// OperationFilter narrows the operations returned by storage and reports.
+// Zero values are ignored; To is exclusive. CategoryIDs match their
+// subcategories as well, and split operations with a line in any of them.
+// TagIDs match operations carrying any of the tags, or all of them with
+// AllTags. PayeeIDs match operations of any of the payees.
+type OperationFilter struct {
+	From        time.Time
+	To          time.Time
+	CategoryIDs []uuid.UUID
+	Type        string
+	TagIDs      []uuid.UUID
+	AllTags     bool
+	PayeeIDs    []uuid.UUID
+}
+
+// CreateOperationResponse carries the category suggestion made for an
+// operation sent without a category. When the suggestion was not confident
+// enough the operation is refused and the client may offer it to the user.
 type CreateOperationResponse struct {
 	response.Response
+	Suggestion *CategorySuggestion `json:"suggestion,omitempty"`
 }
 
 type GetOperationsByUserIDResponse struct {
@@ -33,3 +97,38 @@ type GetOperationsByUserIDResponse struct {
 type UpdateOperationResponse struct {
 	response.Response
 }
+
+type GetFXReportResponse struct {
+	response.Response
+	FX *report.FXReport `json:"fx"`
+}
+
+// CategorySuggestion is a category proposed for an operation. Confidence
+// ranges from 0 to 1; Source tells whether a model or a plain match of
+// category names proposed it.
+type CategorySuggestion struct {
+	CategoryID uuid.UUID `json:"category_id"`
+	Name       string    `json:"name"`
+	Confidence float64   `json:"confidence"`
+	Source     string    `json:"source"`
+}
+
+// SuggestCategoryRequest describes an operation to suggest a category for.
+type SuggestCategoryRequest struct {
+	Name     string `json:"name" validate:"required"`
+	Comment  string `json:"comment"`
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+	Type     string `json:"type"`
+}
+
+// SuggestCategoryResponse holds the suggestion, if any. Confident tells
+// whether creating the operation without a category would apply it. Ranked
+// lists the most probable categories learned from the user's history, with
+// their probabilities as Confidence.
+type SuggestCategoryResponse struct {
+	response.Response
+	Suggestion *CategorySuggestion  `json:"suggestion"`
+	Confident  bool                 `json:"confident"`
+	Ranked     []CategorySuggestion `json:"ranked"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+
+	"github.com/google/uuid"
+)
+
+// PayeeRequest creates or updates a payee. Aliases are operation names as
+// the bank writes them and are normalized before they are stored. On create
+// no aliases means the name is the only one; on update nil keeps the
+// current aliases.
+type PayeeRequest struct {
+	Name    string   `json:"name" validate:"required,max=255"`
+	Aliases []string `json:"aliases" validate:"max=50,dive,max=255"`
+}
+
+// PayeeUsage is a payee with the number of live operations linked to it.
+type PayeeUsage struct {
+	domain.Payee
+	Operations int `json:"operations"`
+}
+
+type GetPayeesResponse struct {
+	response.Response
+	Payees []PayeeUsage `json:"payees"`
+}
+
+type PayeeResponse struct {
+	response.Response
+	Payee *domain.Payee `json:"payee"`
+}
+
+type MergePayeesRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+}
+
+// MergePayeesResponse counts the operations moved to the target payee.
+type MergePayeesResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+}
+
+// DetectPayeesResponse counts the operations linked to a payee.
+type DetectPayeesResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+}
+
+type GetPayeeReportResponse struct {
+	response.Response
+	Report *report.PayeeReport `json:"report"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "alex_gorbunov_exptr_api/internal/lib/api/response"
+
+// QuickEntryRequest is a free-text note such as "coffee 4.50 eur yesterday
+// #work". With Commit the operation is created right away, otherwise the
+// parsed draft is only returned for confirmation. Timezone is the IANA name
+// relative dates are resolved in, UTC by default.
+type QuickEntryRequest struct {
+	Text     string `json:"text" validate:"required,max=500"`
+	Commit   bool   `json:"commit"`
+	Timezone string `json:"timezone"`
+}
+
+// QuickEntryResponse returns the operation understood from the note and
+// whether it was created. Suggestion is the category proposed when the note
+// and the rules left the operation without one.
+type QuickEntryResponse struct {
+	response.Response
+	Draft      *OperationRequest   `json:"draft,omitempty"`
+	Suggestion *CategorySuggestion `json:"suggestion,omitempty"`
+	Created    bool                `json:"created"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+)
+
+type GetRatesResponse struct {
+	response.Response
+	Rates []domain.ExchangeRate `json:"rates"`
+}
+
+type UploadRatesResponse struct {
+	response.Response
+	Imported int `json:"imported"`
+}
+
+type GetCurrenciesResponse struct {
+	response.Response
+	Currencies []currency.Currency `json:"currencies"`
+}
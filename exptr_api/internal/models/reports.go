@@ -0,0 +1,11 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+)
+
+type GetReportResponse struct {
+	response.Response
+	Report *report.Summary `json:"report"`
+}
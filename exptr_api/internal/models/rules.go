@@ -0,0 +1,38 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+)
+
+// RuleRequest creates or updates a rule. Enabled defaults to true.
+type RuleRequest struct {
+	Name       string                `json:"name" validate:"required,max=255"`
+	Priority   int                   `json:"priority"`
+	Enabled    *bool                 `json:"enabled"`
+	Stop       bool                  `json:"stop"`
+	Conditions domain.RuleConditions `json:"conditions"`
+	Actions    domain.RuleActions    `json:"actions"`
+}
+
+type RuleResponse struct {
+	response.Response
+	Rule *domain.Rule `json:"rule"`
+}
+
+type GetRulesResponse struct {
+	response.Response
+	Rules []domain.Rule `json:"rules"`
+}
+
+// RulePreviewResponse lists the operations a rule would match.
+type RulePreviewResponse struct {
+	response.Response
+	Operations []domain.Operation `json:"operations"`
+}
+
+// ApplyRuleResponse counts the operations a rule was applied to.
+type ApplyRuleResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+
+	"github.com/google/uuid"
+)
+
+type TagRequest struct {
+	Name  string `json:"name" validate:"required,max=64"`
+	Color string `json:"color" validate:"max=255"`
+}
+
+// TagUsage is a tag with the number of live operations carrying it.
+type TagUsage struct {
+	domain.Tag
+	Operations int `json:"operations"`
+}
+
+type GetTagsResponse struct {
+	response.Response
+	Tags []TagUsage `json:"tags"`
+}
+
+type TagResponse struct {
+	response.Response
+	Tag *domain.Tag `json:"tag"`
+}
+
+type MergeTagsRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+}
+
+// MergeTagsResponse counts the operations that carried the merged tag.
+type MergeTagsResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+}
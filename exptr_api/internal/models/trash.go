@@ -0,0 +1,32 @@
+package models
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+)
+
+// GetTrashResponse lists soft-deleted items, most recently deleted first.
+type GetTrashResponse struct {
+	response.Response
+	Operations []domain.Operation `json:"operations"`
+	Categories []domain.Category  `json:"categories"`
+}
+
+type RestoreOperationResponse struct {
+	response.Response
+	Operation *domain.Operation `json:"operation"`
+}
+
+// RestoreCategoryResponse counts the operations restored with the category.
+type RestoreCategoryResponse struct {
+	response.Response
+	Category   *domain.Category `json:"category"`
+	Operations int              `json:"operations"`
+}
+
+// PurgeTrashResponse counts what was permanently deleted.
+type PurgeTrashResponse struct {
+	response.Response
+	Operations int `json:"operations"`
+	Categories int `json:"categories"`
+}
@@ -4,9 +4,14 @@ import (
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
 )
 
+// SignUpRequest may pick the category template the account starts with
+// ("none" for no categories) and the language of its names, which defaults
+// to the Accept-Language header.
 type SignUpRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	Template string `json:"template"`
+	Locale   string `json:"locale"`
 }
 
 type LoginRequest struct {
@@ -18,3 +23,12 @@ type LoginResponse struct {
 	Token string `json:"token"`
 	response.Response
 }
+
+type UserSettings struct {
+	BaseCurrency string `json:"base_currency" validate:"required,len=3"`
+}
+
+type UserSettingsResponse struct {
+	response.Response
+	Settings UserSettings `json:"settings"`
+}
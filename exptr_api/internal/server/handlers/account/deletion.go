@@ -0,0 +1,233 @@
+package account
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+	"alex_gorbunov_exptr_api/pkg/hasher"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type GetDeletionHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+}
+
+type RequestDeletionHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+	RequestAccountDeletion(userID uuid.UUID, deleteAfter time.Time) (*domain.User, error)
+}
+
+type CancelDeletionHandler interface {
+	CancelAccountDeletion(userID uuid.UUID) error
+}
+
+// GetDeletion godoc
+// @Summary      Get the pending account deletion
+// @Description  Returns when deletion was requested and when the account will be purged, both empty if no deletion is pending
+// @Tags         account
+// @Produce      json
+// @Success      200  {object}  models.AccountDeletionResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /account/deletion [get]
+func GetDeletion(log *slog.Logger, getDeletionHandler GetDeletionHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.account.deletion.GetDeletion"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		user, err := getDeletionHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get account deletion"))
+			return
+		}
+
+		render.JSON(w, r, models.AccountDeletionResponse{
+			Response: response.OK(),
+			Deletion: deletion(user),
+		})
+	}
+}
+
+// RequestDeletion godoc
+// @Summary      Request account deletion
+// @Description  Schedules the account to be purged for good once the grace period ends. Until then the account works as usual and the request can be cancelled. Requesting again keeps the original schedule.
+// @Tags         account
+// @Accept       json
+// @Produce      json
+// @Param        request body models.AccountDeletionRequest true "password confirmation"
+// @Success      200  {object}  models.AccountDeletionResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      403  {string} 	string "wrong password"
+// @Failure      500  {string}  string "server error"
+// @Router       /account/deletion [post]
+func RequestDeletion(log *slog.Logger, requestDeletionHandler RequestDeletionHandler, gracePeriod time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.account.deletion.RequestDeletion"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		var req models.AccountDeletionRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		user, err := requestDeletionHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to request account deletion"))
+			return
+		}
+
+		if !hasher.CheckPasswordHash(req.Password, user.Password) {
+			log.Error("passwords do not match")
+			w.WriteHeader(http.StatusForbidden)
+			render.JSON(w, r, response.Error("wrong password"))
+			return
+		}
+
+		user, err = requestDeletionHandler.RequestAccountDeletion(userID, time.Now().Add(gracePeriod))
+		if err != nil {
+			log.Error("failed to request account deletion", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to request account deletion"))
+			return
+		}
+
+		log.Info("account deletion requested", slog.Time("delete_after", *user.DeleteAfter))
+		render.JSON(w, r, models.AccountDeletionResponse{
+			Response: response.OK(),
+			Deletion: deletion(user),
+		})
+	}
+}
+
+// CancelDeletion godoc
+// @Summary      Cancel account deletion
+// @Description  Withdraws a pending deletion request
+// @Tags         account
+// @Produce      json
+// @Success      200  {object}  models.AccountDeletionResponse
+// @Failure      404  {string} 	string "no deletion pending"
+// @Failure      500  {string}  string "server error"
+// @Router       /account/deletion [delete]
+func CancelDeletion(log *slog.Logger, cancelDeletionHandler CancelDeletionHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.account.deletion.CancelDeletion"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		if err := cancelDeletionHandler.CancelAccountDeletion(userID); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("no deletion pending"))
+				return
+			}
+			log.Error("failed to cancel account deletion", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to cancel account deletion"))
+			return
+		}
+
+		log.Info("account deletion cancelled")
+		render.JSON(w, r, models.AccountDeletionResponse{
+			Response: response.OK(),
+		})
+	}
+}
+
+func deletion(user *domain.User) models.AccountDeletion {
+	return models.AccountDeletion{
+		RequestedAt: user.DeletionRequestedAt,
+		DeleteAfter: user.DeleteAfter,
+	}
+}
@@ -0,0 +1,80 @@
+package account
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/archive"
+	"alex_gorbunov_exptr_api/internal/lib/attachment"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type ExportAccountHandler interface {
+	ExportAccount(userID uuid.UUID) (*archive.Archive, error)
+}
+
+// Export godoc
+// @Summary      Download an archive of the account
+// @Description  Zip with a versioned manifest and JSON files of the profile, settings, categories, operations, import profiles and session metadata, plus the attached files
+// @Tags         account
+// @Produce      application/zip
+// @Success      200  {file}  file
+// @Failure      500  {string}  string "server error"
+// @Router       /account/export [get]
+func Export(log *slog.Logger, exportAccountHandler ExportAccountHandler, blobs blob.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.account.export.Export"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		data, err := exportAccountHandler.ExportAccount(userID)
+		if err != nil {
+			log.Error("failed to export account", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to export account"))
+			return
+		}
+		data.Open = func(checksum string) (io.ReadCloser, error) {
+			return blobs.Get(r.Context(), attachment.Key(userID, checksum))
+		}
+
+		filename := fmt.Sprintf("exptr-account-%s.zip", time.Now().Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+		if err := archive.Write(w, data); err != nil {
+			log.Error("failed to write archive", sl.Error(err))
+			return
+		}
+
+		log.Info("account exported", slog.Int("operations", len(data.Operations)))
+	}
+}
@@ -0,0 +1,167 @@
+package account
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/archive"
+	"alex_gorbunov_exptr_api/internal/lib/attachment"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+const maxArchiveSize = 200 << 20
+
+type ImportAccountHandler interface {
+	RestoreAccount(userID uuid.UUID, data *archive.Archive) error
+}
+
+// Import godoc
+// @Summary      Restore an account archive
+// @Description  Loads an archive made by /account/export into the current account, which must have no operations yet. Existing categories are moved to the trash when the archive has its own. Every entity gets a new id. Attached files are checked like uploads and stored before anything else is restored.
+// @Tags         account
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "account archive"
+// @Success      200  {object}  models.AccountImportResponse
+// @Failure      400  {string} 	string "invalid archive"
+// @Failure      409  {string} 	string "account is not empty"
+// @Failure      500  {string}  string "server error"
+// @Router       /account/import [post]
+func Import(log *slog.Logger, importAccountHandler ImportAccountHandler, blobs blob.Store, maxFileSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.account.import.Import"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxArchiveSize)
+
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			log.Error("missing file", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("missing file"))
+			return
+		}
+		defer file.Close()
+
+		data, err := archive.Read(file, header.Size)
+		if err != nil {
+			log.Error("failed to read archive", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		data.Remap(userID)
+
+		if err := restoreFiles(r.Context(), blobs, userID, data, maxFileSize); err != nil {
+			if errors.Is(err, errBadFile) {
+				log.Error("invalid attachment in archive", sl.Error(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+			log.Error("failed to store attachments", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to restore account"))
+			return
+		}
+
+		err = importAccountHandler.RestoreAccount(userID, data)
+		if errors.Is(err, storage.ErrAccountNotEmpty) {
+			log.Error("account is not empty")
+			w.WriteHeader(http.StatusConflict)
+			render.JSON(w, r, response.Error("account already has operations"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to restore account", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to restore account"))
+			return
+		}
+
+		log.Info("account restored", slog.Int("operations", len(data.Operations)))
+		render.JSON(w, r, models.AccountImportResponse{
+			Response:            response.OK(),
+			Version:             data.Manifest.Version,
+			Categories:          len(data.Categories),
+			Operations:          len(data.Operations),
+			ImportProfiles:      len(data.ImportProfiles),
+			DuplicateDismissals: len(data.DuplicateDismissals),
+			Attachments:         len(data.Attachments),
+		})
+	}
+}
+
+var errBadFile = errors.New("invalid attachment")
+
+// restoreFiles checks each attached file of the archive the way an upload is
+// checked, stores it with its thumbnail and takes the content type, size and
+// thumbnail of the attachments from the file rather than from the archive.
+// Files left behind by a failed restore are removed by the sweep.
+func restoreFiles(ctx context.Context, blobs blob.Store, userID uuid.UUID, data *archive.Archive, maxSize int64) error {
+	checked := make(map[string]*attachment.Upload)
+	for i := range data.Attachments {
+		a := &data.Attachments[i]
+		up, ok := checked[a.Checksum]
+		if !ok {
+			rc, err := data.Open(a.Checksum)
+			if err != nil {
+				return err
+			}
+			content, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("%w %s: %v", errBadFile, a.FileName, err)
+			}
+
+			up, err = attachment.Inspect(bytes.NewReader(content), maxSize)
+			if err != nil {
+				return fmt.Errorf("%w %s: %v", errBadFile, a.FileName, err)
+			}
+			if up.Checksum != a.Checksum {
+				return fmt.Errorf("%w %s: checksum mismatch", errBadFile, a.FileName)
+			}
+			if err := attachment.Save(ctx, blobs, userID, up, bytes.NewReader(content)); err != nil {
+				return err
+			}
+			checked[a.Checksum] = up
+		}
+
+		a.ContentType, a.Size, a.Thumbnail = up.ContentType, up.Size, up.Thumbnail != nil
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/attachment"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// maxFiles bounds the files of one upload.
+const maxFiles = 10
+
+// multipartMemory is how much of an upload is held in memory; the rest of
+// the files are spooled to temporary files.
+const multipartMemory = 8 << 20
+
+type CreateAttachmentHandler interface {
+	GetAttachments(userID, operationID uuid.UUID) ([]domain.Attachment, error)
+	GetAttachmentChecksums(userID uuid.UUID, checksums []string) (map[string]bool, error)
+	CreateAttachment(attachment *domain.Attachment) (bool, error)
+}
+
+// Create godoc
+// @Summary      Attach files to an operation
+// @Description  Uploads one or more receipts or documents (JPEG, PNG, GIF, WebP or PDF) as "file" parts. The type is detected from the content. A file the operation already has is not attached again, and a file the user stored before is not stored twice. Images get a thumbnail.
+// @Tags         attachments
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id path string true "Operation ID"
+// @Param        file formData file true "receipt or document; repeat for several files"
+// @Success      200  {object}  models.CreateAttachmentsResponse
+// @Failure      400  {string} 	string "missing file"
+// @Failure      404  {string} 	string "operation not found"
+// @Failure      413  {string} 	string "file is too large"
+// @Failure      415  {string} 	string "file type is not supported"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/{id}/attachments [post]
+func Create(log *slog.Logger, createAttachmentHandler CreateAttachmentHandler, blobs blob.Store, maxSize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.attachments.create.Create"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		operationID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		if _, err := createAttachmentHandler.GetAttachments(userID, operationID); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("operation not found"))
+				return
+			}
+			log.Error("failed to get operation", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to attach files"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxFiles*maxSize+multipartMemory)
+		if err := r.ParseMultipartForm(multipartMemory); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, response.Error("upload is too large"))
+				return
+			}
+			log.Error("failed to parse form", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("missing file"))
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		headers := r.MultipartForm.File["file"]
+		if len(headers) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("missing file"))
+			return
+		}
+		if len(headers) > maxFiles {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(fmt.Sprintf("at most %d files at a time", maxFiles)))
+			return
+		}
+
+		res := models.CreateAttachmentsResponse{Response: response.OK()}
+		for _, header := range headers {
+			a, created, err := attach(c, createAttachmentHandler, blobs, maxSize, userID, operationID, header)
+			if err != nil {
+				status, msg := http.StatusInternalServerError, "failed to attach file"
+				switch {
+				case errors.Is(err, attachment.ErrTooLarge):
+					status, msg = http.StatusRequestEntityTooLarge, fmt.Sprintf("%s: %s", header.Filename, attachment.ErrTooLarge)
+				case errors.Is(err, attachment.ErrUnsupported), errors.Is(err, attachment.ErrEmpty):
+					status, msg = http.StatusUnsupportedMediaType, fmt.Sprintf("%s: %s", header.Filename, err)
+				case errors.Is(err, storage.ErrItemNotFound):
+					status, msg = http.StatusNotFound, "operation not found"
+				default:
+					log.Error("failed to attach file", slog.String("file_name", header.Filename), sl.Error(err))
+				}
+				w.WriteHeader(status)
+				render.JSON(w, r, response.Error(msg))
+				return
+			}
+
+			res.Attachments = append(res.Attachments, *a)
+			if created {
+				res.Created++
+			} else {
+				res.Existing++
+			}
+		}
+
+		log.Info("files attached",
+			slog.String("operation_id", operationID.String()),
+			slog.Int("created", res.Created),
+			slog.Int("existing", res.Existing),
+		)
+		render.JSON(w, r, res)
+	}
+}
+
+// attach checks one uploaded file, stores it unless the user already has it
+// and attaches it to the operation.
+func attach(c *gin.Context, h CreateAttachmentHandler, blobs blob.Store, maxSize int64, userID, operationID uuid.UUID, header *multipart.FileHeader) (*domain.Attachment, bool, error) {
+	if header.Size > maxSize {
+		return nil, false, attachment.ErrTooLarge
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, false, err
+	}
+	defer file.Close()
+
+	up, err := attachment.Inspect(file, maxSize)
+	if err != nil {
+		return nil, false, err
+	}
+
+	stored, err := h.GetAttachmentChecksums(userID, []string{up.Checksum})
+	if err != nil {
+		return nil, false, err
+	}
+	if !stored[up.Checksum] {
+		if err := attachment.Save(c.Request.Context(), blobs, userID, up, file); err != nil {
+			return nil, false, err
+		}
+	}
+
+	a := &domain.Attachment{
+		UserID:      userID,
+		OperationID: operationID,
+		FileName:    fileName(header.Filename),
+		ContentType: up.ContentType,
+		Size:        up.Size,
+		Checksum:    up.Checksum,
+		Thumbnail:   up.Thumbnail != nil,
+	}
+	created, err := h.CreateAttachment(a)
+	if err != nil {
+		return nil, false, err
+	}
+	return a, created, nil
+}
+
+// fileName keeps the base name the client sent, cut to fit the column.
+func fileName(name string) string {
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, `\`, "/")))
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
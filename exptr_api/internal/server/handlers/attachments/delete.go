@@ -0,0 +1,81 @@
+package attachments
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type DeleteAttachmentHandler interface {
+	DeleteAttachment(userID, id uuid.UUID) (*domain.Attachment, error)
+}
+
+// Delete godoc
+// @Summary      Delete attachment
+// @Description  Detaches a file from its operation. The stored file is removed by the next sweep once nothing refers to it.
+// @Tags         attachments
+// @Produce      json
+// @Param        id path string true "Attachment ID"
+// @Success      200  {object}  response.Response
+// @Failure      404  {string} 	string "attachment not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /attachments/{id} [delete]
+func Delete(log *slog.Logger, deleteAttachmentHandler DeleteAttachmentHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.attachments.delete.Delete"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		a, err := deleteAttachmentHandler.DeleteAttachment(userID, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("attachment not found"))
+				return
+			}
+			log.Error("failed to delete attachment", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to delete attachment"))
+			return
+		}
+
+		log.Info("attachment deleted", slog.String("id", id.String()), slog.String("operation_id", a.OperationID.String()))
+		render.JSON(w, r, response.OK())
+	}
+}
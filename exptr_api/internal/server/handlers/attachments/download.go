@@ -0,0 +1,138 @@
+package attachments
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/attachment"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetAttachmentHandler interface {
+	GetAttachment(userID, id uuid.UUID) (*domain.Attachment, error)
+}
+
+// Download godoc
+// @Summary      Download an attachment
+// @Description  Streams the attached file with its original name. Pass inline=true to let the browser show it instead of saving it.
+// @Tags         attachments
+// @Produce      octet-stream
+// @Param        id path string true "Attachment ID"
+// @Param        inline query bool false "show in the browser"
+// @Success      200  {file}  file
+// @Failure      404  {string} 	string "attachment not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /attachments/{id} [get]
+func Download(log *slog.Logger, getAttachmentHandler GetAttachmentHandler, blobs blob.Store) gin.HandlerFunc {
+	return serve(log, "handlers.attachments.download.Download", getAttachmentHandler, blobs, false)
+}
+
+// Thumbnail godoc
+// @Summary      Download the thumbnail of an attachment
+// @Description  JPEG at most 256 pixels on the longer side, for images only
+// @Tags         attachments
+// @Produce      jpeg
+// @Param        id path string true "Attachment ID"
+// @Success      200  {file}  file
+// @Failure      404  {string} 	string "attachment has no thumbnail"
+// @Failure      500  {string}  string "server error"
+// @Router       /attachments/{id}/thumbnail [get]
+func Thumbnail(log *slog.Logger, getAttachmentHandler GetAttachmentHandler, blobs blob.Store) gin.HandlerFunc {
+	return serve(log, "handlers.attachments.download.Thumbnail", getAttachmentHandler, blobs, true)
+}
+
+func serve(log *slog.Logger, fn string, getAttachmentHandler GetAttachmentHandler, blobs blob.Store, thumbnail bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		a, err := getAttachmentHandler.GetAttachment(userID, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("attachment not found"))
+				return
+			}
+			log.Error("failed to get attachment", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get attachment"))
+			return
+		}
+
+		key, contentType, size := attachment.Key(userID, a.Checksum), a.ContentType, a.Size
+		if thumbnail {
+			if !a.Thumbnail {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("attachment has no thumbnail"))
+				return
+			}
+			key, contentType, size = attachment.ThumbnailKey(userID, a.Checksum), "image/jpeg", -1
+		}
+
+		body, err := blobs.Get(r.Context(), key)
+		if err != nil {
+			// A missing blob means the store and the database disagree.
+			log.Error("failed to open attachment", slog.String("key", key), sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get attachment"))
+			return
+		}
+		defer body.Close()
+
+		disposition := "attachment"
+		if thumbnail || c.Query("inline") == "true" {
+			disposition = "inline"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.FileName}))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		if size >= 0 {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		}
+		w.WriteHeader(http.StatusOK)
+
+		if _, err := io.Copy(w, body); err != nil {
+			log.Error("failed to send attachment", sl.Error(err))
+		}
+	}
+}
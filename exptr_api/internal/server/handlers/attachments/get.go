@@ -0,0 +1,84 @@
+package attachments
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetAttachmentsHandler interface {
+	GetAttachments(userID, operationID uuid.UUID) ([]domain.Attachment, error)
+}
+
+// GetAll godoc
+// @Summary      Get attachments of an operation
+// @Description  Lists the files attached to an operation in the order they were added
+// @Tags         attachments
+// @Produce      json
+// @Param        id path string true "Operation ID"
+// @Success      200  {object}  models.GetAttachmentsResponse
+// @Failure      404  {string} 	string "operation not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/{id}/attachments [get]
+func GetAll(log *slog.Logger, getAttachmentsHandler GetAttachmentsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.attachments.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		operationID, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		list, err := getAttachmentsHandler.GetAttachments(userID, operationID)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("operation not found"))
+				return
+			}
+			log.Error("failed to get attachments", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get attachments"))
+			return
+		}
+
+		render.JSON(w, r, models.GetAttachmentsResponse{
+			Response:    response.OK(),
+			Attachments: list,
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
 	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
 	"errors"
 	"io"
 	"log/slog"
@@ -27,6 +28,7 @@ type CreateCategoryHandler interface {
 // @Param        data body models.CategoryRequest true "Update category"
 // @Success      200  {object}  models.CategoryResponse
 // @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "parent category not found"
 // @Failure      500  {string}  string "server error"
 // @Router       /categories/new [post]
 func New(log *slog.Logger, createCategoryHandler CreateCategoryHandler) gin.HandlerFunc {
@@ -67,6 +69,12 @@ func New(log *slog.Logger, createCategoryHandler CreateCategoryHandler) gin.Hand
 
 		err = createCategoryHandler.CreateCategory(&req)
 
+		if errors.Is(err, storage.ErrItemNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			render.JSON(w, r, response.Error("parent category not found"))
+			return
+		}
+
 		if err != nil {
 			log.Error("failed to create category", sl.Error(err), slog.String("op", op))
 			w.WriteHeader(http.StatusInternalServerError)
@@ -2,7 +2,12 @@ package categories
 
 import (
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -11,28 +16,57 @@ import (
 	"github.com/google/uuid"
 )
 
+// Delete modes decide what happens to the operations of a deleted category.
+const (
+	DeleteModeRefuse   = "refuse"
+	DeleteModeReassign = "reassign"
+	DeleteModeTrash    = "trash"
+)
+
 type DeleteCategoryHandler interface {
-	DeleteCategory(id uuid.UUID) error
+	DeleteCategory(userID, id uuid.UUID) (int, error)
+	DeleteUnusedCategory(userID, id uuid.UUID) error
+	ReassignCategory(userID, id, targetID uuid.UUID, merge bool) (int, error)
 }
 
 // Delete godoc
 // @Summary      Delete category by id
-// @Description  Delete category by id
+// @Description  Deletes a category. mode=refuse (default) fails while operations use it, mode=reassign moves them to target_id first and mode=trash moves the category and its operations to the trash, where they can be restored together. Split lines of other operations booked on the category block refuse and trash modes.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Category ID"
-// @Success      200  {object}  response.Response
+// @Param        mode query string false "refuse, reassign or trash"
+// @Param        target_id query string false "category receiving the operations in reassign mode"
+// @Success      200  {object}  models.DeleteCategoryResponse
 // @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "category not found"
+// @Failure      409  {string} 	string "category is in use"
 // @Failure      500  {string}  string "server error"
 // @Router       /categories/{id} [delete]
-func Delete(log *slog.Logger, deleteCategoryHandler DeleteCategoryHandler) gin.HandlerFunc {
+func Delete(log *slog.Logger, deleteCategoryHandler DeleteCategoryHandler, suggester *categorizer.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		const op = "handlers.categories.delete.DeleteCategory"
 
 		r := c.Request
 		w := c.Writer
 
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
 		param := c.Param("id")
 		if param == "" {
 			log.Error("empty category id")
@@ -49,15 +83,49 @@ func Delete(log *slog.Logger, deleteCategoryHandler DeleteCategoryHandler) gin.H
 			return
 		}
 
-		err = deleteCategoryHandler.DeleteCategory(id)
+		var moved int
+		switch mode := c.DefaultQuery("mode", DeleteModeRefuse); mode {
+		case DeleteModeRefuse:
+			err = deleteCategoryHandler.DeleteUnusedCategory(userID, id)
+		case DeleteModeReassign:
+			targetID, parseErr := uuid.Parse(c.Query("target_id"))
+			if parseErr != nil || targetID == id {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("target_id must be another category"))
+				return
+			}
+			moved, err = deleteCategoryHandler.ReassignCategory(userID, id, targetID, false)
+		case DeleteModeTrash:
+			moved, err = deleteCategoryHandler.DeleteCategory(userID, id)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("unknown delete mode "+mode))
+			return
+		}
 		if err != nil {
-			log.Error("failed to delete category", sl.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			render.JSON(w, r, response.Error("failed to delete category"))
+			switch {
+			case errors.Is(err, storage.ErrItemNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found"))
+			case errors.Is(err, storage.ErrCategoryInUse):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error("category is in use"))
+			default:
+				log.Error("failed to delete category", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to delete category"))
+			}
 			return
 		}
 
-		log.Info("category deleted")
-		render.JSON(w, r, response.OK())
+		if suggester != nil && moved > 0 {
+			suggester.Reset(userID)
+		}
+
+		log.Info("category deleted", slog.Int("operations", moved))
+		render.JSON(w, r, models.DeleteCategoryResponse{
+			Response:   response.OK(),
+			Operations: moved,
+		})
 	}
 }
@@ -3,9 +3,11 @@ package categories
 import (
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
 	"alex_gorbunov_exptr_api/internal/models"
 	"alex_gorbunov_exptr_api/internal/server/middleware/token"
@@ -21,10 +23,11 @@ type GetCategoriesHandler interface {
 
 // GetAll godoc
 // @Summary      get all categories
-// @Description  get all categories
+// @Description  get all categories. With tree=true the response also nests them under their parents.
 // @Tags         categories
 // @Accept       json
 // @Produce      json
+// @Param        tree query bool false "include the category tree"
 // @Success      200  {object}  models.GetCategoriesResponse
 // @Failure      400  {string} 	string "empty request body"
 // @Failure      500  {string}  string "server error"
@@ -61,10 +64,15 @@ func GetAll(log *slog.Logger, getAllCategoriesHandler GetCategoriesHandler) gin.
 			return
 		}
 
-		log.Info("all categories received")
-		render.JSON(w, r, models.GetCategoriesResponse{
+		resp := models.GetCategoriesResponse{
 			Response:   response.OK(),
 			Categories: categories,
-		})
+		}
+		if tree, _ := strconv.ParseBool(c.Query("tree")); tree {
+			resp.Tree = categorytree.New(categories).Nodes()
+		}
+
+		log.Info("all categories received")
+		render.JSON(w, r, resp)
 	}
 }
@@ -0,0 +1,137 @@
+package categories
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type MergeCategoriesHandler interface {
+	ReassignCategory(userID, id, targetID uuid.UUID, merge bool) (int, error)
+	GetCategoryByID(id uuid.UUID) (*domain.Category, error)
+}
+
+// Merge godoc
+// @Summary      Merge a category into another one
+// @Description  Moves every operation of the category, including those in the trash, and the import profiles defaulting to it into the target category, then deletes it. Runs in one transaction.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Category ID"
+// @Param        data body models.MergeCategoriesRequest true "target category"
+// @Success      200  {object}  models.MergeCategoriesResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "category not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /categories/{id}/merge [post]
+func Merge(log *slog.Logger, mergeCategoriesHandler MergeCategoriesHandler, suggester *categorizer.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.categories.merge.Merge"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		var req models.MergeCategoriesRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		if req.TargetID == id {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("cannot merge a category into itself"))
+			return
+		}
+
+		moved, err := mergeCategoriesHandler.ReassignCategory(userID, id, req.TargetID, true)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found"))
+				return
+			}
+			log.Error("failed to merge categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to merge categories"))
+			return
+		}
+
+		if suggester != nil && moved > 0 {
+			suggester.Reset(userID)
+		}
+
+		target, err := mergeCategoriesHandler.GetCategoryByID(req.TargetID)
+		if err != nil {
+			log.Error("failed to get merged category", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get merged category"))
+			return
+		}
+
+		log.Info("categories merged", slog.String("source", id.String()),
+			slog.String("target", req.TargetID.String()), slog.Int("operations", moved))
+		render.JSON(w, r, models.MergeCategoriesResponse{
+			Response:   response.OK(),
+			Category:   target,
+			Operations: moved,
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package categories
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type MoveCategoryHandler interface {
+	MoveCategory(userID, id uuid.UUID, parentID *uuid.UUID) (*domain.Category, error)
+}
+
+// Move godoc
+// @Summary      Move a category with its subcategories
+// @Description  Nests the category under parent_id, or makes it top-level when parent_id is null. Its subcategories move along.
+// @Tags         categories
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Category ID"
+// @Param        data body models.MoveCategoryRequest true "new parent"
+// @Success      200  {object}  models.MoveCategoryResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "category not found"
+// @Failure      409  {string} 	string "category cannot be nested under itself"
+// @Failure      500  {string}  string "server error"
+// @Router       /categories/{id}/parent [put]
+func Move(log *slog.Logger, moveCategoryHandler MoveCategoryHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.categories.move.Move"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		var req models.MoveCategoryRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		category, err := moveCategoryHandler.MoveCategory(userID, id, req.ParentID)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrItemNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found"))
+			case errors.Is(err, storage.ErrCategoryCycle):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error(storage.ErrCategoryCycle.Error()))
+			default:
+				log.Error("failed to move category", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to move category"))
+			}
+			return
+		}
+
+		log.Info("category moved", slog.String("id", id.String()))
+		render.JSON(w, r, models.MoveCategoryResponse{
+			Response: response.OK(),
+			Category: category,
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package categories
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/templates"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type ApplyTemplateHandler interface {
+	ApplyCategoryTemplate(userID uuid.UUID, categories []domain.Category) (int, error)
+}
+
+// Templates godoc
+// @Summary      List category templates
+// @Description  Bundled category sets with their categories named in locale, which defaults to the Accept-Language header
+// @Tags         categories
+// @Produce      json
+// @Param        locale query string false "language of the names, e.g. en or ru"
+// @Success      200  {object}  models.GetCategoryTemplatesResponse
+// @Router       /categories/templates [get]
+func Templates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		r := c.Request
+		w := c.Writer
+
+		locale := templateLocale(c)
+
+		list := make([]models.CategoryTemplate, 0)
+		for _, t := range templates.List() {
+			list = append(list, models.CategoryTemplate{
+				ID:          t.ID,
+				Name:        t.Name.In(locale),
+				Description: t.Description.In(locale),
+				Categories:  categorytree.New(t.Build(uuid.Nil, locale)).Nodes(),
+			})
+		}
+
+		render.JSON(w, r, models.GetCategoryTemplatesResponse{
+			Response:  response.OK(),
+			Templates: list,
+		})
+	}
+}
+
+// ApplyTemplate godoc
+// @Summary      Apply a category template
+// @Description  Adds the template categories the account does not have yet. Categories with the same name, type and parent are kept as they are.
+// @Tags         categories
+// @Produce      json
+// @Param        id path string true "Template ID"
+// @Param        locale query string false "language of the names, e.g. en or ru"
+// @Success      200  {object}  models.ApplyCategoryTemplateResponse
+// @Failure      404  {string} 	string "template not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /categories/templates/{id}/apply [post]
+func ApplyTemplate(log *slog.Logger, applyTemplateHandler ApplyTemplateHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.categories.templates.ApplyTemplate"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		template, ok := templates.Get(c.Param("id"))
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			render.JSON(w, r, response.Error("template not found"))
+			return
+		}
+
+		created, err := applyTemplateHandler.ApplyCategoryTemplate(userID, template.Build(userID, templateLocale(c)))
+		if err != nil {
+			log.Error("failed to apply category template", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to apply category template"))
+			return
+		}
+
+		log.Info("category template applied", slog.String("template", template.ID), slog.Int("created", created))
+		render.JSON(w, r, models.ApplyCategoryTemplateResponse{
+			Response: response.OK(),
+			Created:  created,
+		})
+	}
+}
+
+func templateLocale(c *gin.Context) string {
+	if locale := c.Query("locale"); locale != "" {
+		return locale
+	}
+	return templates.Locale(c.GetHeader("Accept-Language"))
+}
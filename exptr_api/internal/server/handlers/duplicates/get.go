@@ -0,0 +1,105 @@
+package duplicates
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+// defaultPeriod is searched when no from date is given.
+const defaultPeriod = 90 * 24 * time.Hour
+
+type GetDuplicatesHandler interface {
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
+	GetDuplicateDismissals(userID uuid.UUID) (map[dedup.Key]bool, error)
+}
+
+// GetAll godoc
+// @Summary      List suspected duplicate operations
+// @Description  Scores operation pairs by amount, date proximity, payee name and bank reference. Dismissed pairs are left out.
+// @Tags         operations
+// @Accept       json
+// @Produce      json
+// @Param        from query string false "start date, YYYY-MM-DD, 90 days ago by default"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {object}  models.GetDuplicatesResponse
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/duplicates [get]
+func GetAll(log *slog.Logger, getDuplicatesHandler GetDuplicatesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.duplicates.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		if filter.From.IsZero() {
+			filter.From = time.Now().UTC().Add(-defaultPeriod).Truncate(24 * time.Hour)
+		}
+
+		operations, err := getDuplicatesHandler.GetOperations(userID, filter)
+		if err != nil {
+			log.Error("failed to get operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get operations"))
+			return
+		}
+
+		dismissed, err := getDuplicatesHandler.GetDuplicateDismissals(userID)
+		if err != nil {
+			log.Error("failed to get dismissed duplicates", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get dismissed duplicates"))
+			return
+		}
+
+		pairs := dedup.Find(operations, dedup.Options{Dismissed: dismissed})
+
+		log.Info("duplicates found", slog.Int("pairs", len(pairs)))
+		render.JSON(w, r, models.GetDuplicatesResponse{
+			Response: response.OK(),
+			Pairs:    pairs,
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package duplicates
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type MergeDuplicatesHandler interface {
+	MergeOperations(userID, keepID, dropID uuid.UUID) (*domain.Operation, error)
+}
+
+// Merge godoc
+// @Summary      Merge two duplicate operations
+// @Description  Keeps one operation, copies details it lacks from the other and deletes the other
+// @Tags         operations
+// @Accept       json
+// @Produce      json
+// @Param        data body models.MergeDuplicatesRequest true "operations to keep and drop"
+// @Success      200  {object}  models.MergeDuplicatesResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "operation not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/duplicates/merge [post]
+func Merge(log *slog.Logger, mergeDuplicatesHandler MergeDuplicatesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.duplicates.merge.Merge"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		var req models.MergeDuplicatesRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		operation, err := mergeDuplicatesHandler.MergeOperations(userID, req.KeepID, req.DropID)
+		if errors.Is(err, storage.ErrItemNotFound) {
+			log.Error("operation not found", sl.Error(err))
+			w.WriteHeader(http.StatusNotFound)
+			render.JSON(w, r, response.Error("operation not found"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to merge operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to merge operations"))
+			return
+		}
+
+		log.Info("operations merged")
+		render.JSON(w, r, models.MergeDuplicatesResponse{
+			Response:  response.OK(),
+			Operation: operation,
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package importprofiles
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type CreateImportProfileHandler interface {
+	CreateImportProfile(profile *domain.ImportProfile) error
+}
+
+// New godoc
+// @Summary      create import profile
+// @Description  save a CSV column mapping for a bank
+// @Tags         imports
+// @Accept       json
+// @Produce      json
+// @Param        data body models.ImportProfileRequest true "import profile"
+// @Success      200  {object}  models.ImportProfileResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      400  {string} 	string "default category not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /imports/profiles/new [post]
+func New(log *slog.Logger, createImportProfileHandler CreateImportProfileHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.importprofiles.create.New"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		var req models.ImportProfileRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		profile := &domain.ImportProfile{
+			UserID:  userID,
+			Name:    req.Name,
+			Bank:    req.Bank,
+			Mapping: req.Mapping,
+		}
+
+		if err := createImportProfileHandler.CreateImportProfile(profile); err != nil {
+			if errors.Is(err, storage.ErrDefaultCategory) {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+			log.Error("failed to create import profile", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create import profile"))
+			return
+		}
+
+		log.Info("import profile created", slog.String("id", profile.ID.String()))
+		render.JSON(w, r, models.ImportProfileResponse{
+			Response: response.OK(),
+			Profile:  profile,
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package importprofiles
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type DeleteImportProfileHandler interface {
+	DeleteImportProfile(id, userID uuid.UUID) error
+}
+
+// Delete godoc
+// @Summary      Delete import profile by id
+// @Description  Delete import profile by id
+// @Tags         imports
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "profile id"
+// @Success      200  {object}  response.Response
+// @Failure      400  {string} 	string "invalid id format"
+// @Failure      500  {string}  string "server error"
+// @Router       /imports/profiles/{id} [delete]
+func Delete(log *slog.Logger, deleteImportProfileHandler DeleteImportProfileHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.importprofiles.delete.Delete"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		if err := deleteImportProfileHandler.DeleteImportProfile(id, userID); err != nil {
+			log.Error("failed to delete import profile", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to delete import profile"))
+			return
+		}
+
+		log.Info("import profile deleted")
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,69 @@
+package importprofiles
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetImportProfilesHandler interface {
+	GetImportProfiles(userID uuid.UUID) ([]domain.ImportProfile, error)
+}
+
+// GetAll godoc
+// @Summary      get all import profiles
+// @Description  get all saved CSV import profiles of the current user
+// @Tags         imports
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  models.GetImportProfilesResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /imports/profiles [get]
+func GetAll(log *slog.Logger, getImportProfilesHandler GetImportProfilesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.importprofiles.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		profiles, err := getImportProfilesHandler.GetImportProfiles(userID)
+		if err != nil {
+			log.Error("failed to get import profiles", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get import profiles"))
+			return
+		}
+
+		log.Info("import profiles received")
+		render.JSON(w, r, models.GetImportProfilesResponse{
+			Response: response.OK(),
+			Profiles: profiles,
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package importprofiles
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type UpdateImportProfileHandler interface {
+	UpdateImportProfile(profile *domain.ImportProfile) error
+}
+
+// Update godoc
+// @Summary      update import profile
+// @Description  update a saved CSV column mapping
+// @Tags         imports
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "profile id"
+// @Param        data body models.ImportProfileRequest true "import profile"
+// @Success      200  {object}  models.ImportProfileResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      400  {string} 	string "default category not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /imports/profiles/{id} [put]
+func Update(log *slog.Logger, updateImportProfileHandler UpdateImportProfileHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.importprofiles.update.Update"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		var req models.ImportProfileRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		profile := &domain.ImportProfile{
+			BaseEntity: domain.BaseEntity{ID: id},
+			UserID:     userID,
+			Name:       req.Name,
+			Bank:       req.Bank,
+			Mapping:    req.Mapping,
+		}
+
+		if err := updateImportProfileHandler.UpdateImportProfile(profile); err != nil {
+			if errors.Is(err, storage.ErrDefaultCategory) {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
+			log.Error("failed to update import profile", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to update import profile"))
+			return
+		}
+
+		log.Info("import profile updated")
+		render.JSON(w, r, models.ImportProfileResponse{
+			Response: response.OK(),
+			Profile:  profile,
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package imports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+	"alex_gorbunov_exptr_api/internal/lib/importer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+const maxStatementSize = 20 << 20
+
+// suggestBudget bounds the time a statement may spend waiting for category
+// suggestions from the model; later rows get name-based suggestions only.
+const suggestBudget = 3 * time.Second
+
+type ImportHandler interface {
+	ProfileGetter
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
+	GetRules(userID uuid.UUID) ([]domain.Rule, error)
+	GetExistingExternalIDs(userID uuid.UUID, externalIDs []string) ([]string, error)
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
+	ImportOperations(categories []domain.Category, operations []models.OperationRequest) error
+}
+
+// New godoc
+// @Summary      Import a bank statement
+// @Description  Parse a statement and insert its rows in one transaction, or preview them with dry_run
+// @Description  Rows left without a category by the statement and the rules get a suggested one, applied when confident
+// @Tags         imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "statement file"
+// @Param        format formData string false "statement format: csv (default), ofx, qfx, qif, camt053, mt940, ledger, hledger, beancount, or an app export: ynab, mint, zenmoney, moneymanager, firefly"
+// @Param        profile_id formData string false "saved CSV import profile"
+// @Param        mapping formData string false "CSV mapping as JSON when no profile is used"
+// @Param        default_currency formData string false "currency of rows without one"
+// @Param        default_category_id formData string false "category of rows without one"
+// @Param        date_format formData string false "QIF or app export date pattern, MM/DD/YYYY by default"
+// @Param        category_map formData string false "JSON object mapping source category names to category ids"
+// @Param        create_categories formData bool false "create missing categories, the default for app exports"
+// @Param        dry_run formData bool false "preview without storing"
+// @Param        import_suspected formData bool false "also import rows that look like operations already stored"
+// @Param        allow_unbalanced formData bool false "import CAMT.053 or MT940 statements whose balances do not match"
+// @Success      200  {object}  models.ImportResponse
+// @Failure      400  {string} 	string "invalid statement"
+// @Failure      400  {string} 	string "default category not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /imports [post]
+func New(log *slog.Logger, importHandler ImportHandler, suggester *categorizer.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.imports.create.New"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxStatementSize)
+
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			log.Error("missing file", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("missing file"))
+			return
+		}
+		defer file.Close()
+
+		dryRun, _ := strconv.ParseBool(c.PostForm("dry_run"))
+
+		opts := importer.Options{
+			UserID:          userID,
+			DefaultCurrency: c.PostForm("default_currency"),
+		}
+		if raw := c.PostForm("default_category_id"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				log.Error("invalid default category id", sl.Error(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid default category id"))
+				return
+			}
+			opts.DefaultCategoryID = &id
+		}
+
+		format := c.PostForm("format")
+		parser, err := parserFor(c, format, userID, importHandler)
+		if err != nil {
+			log.Error("failed to select parser", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		records, balances, err := parse(parser, file)
+		if err != nil {
+			log.Error("failed to parse statement", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		allowUnbalanced, _ := strconv.ParseBool(c.PostForm("allow_unbalanced"))
+		if !dryRun && !allowUnbalanced && !balanced(balances) {
+			log.Error("statement balances do not match")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			render.JSON(w, r, models.ImportResponse{
+				Response: response.Error("statement balances do not match the entries"),
+				Balances: balances,
+			})
+			return
+		}
+
+		opts.Categories, err = importHandler.GetCategories(userID)
+		if err != nil {
+			log.Error("failed to get categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get categories"))
+			return
+		}
+
+		userRules, err := importHandler.GetRules(userID)
+		if err != nil {
+			log.Error("failed to get rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rules"))
+			return
+		}
+		if opts.Rules, err = rules.Compile(userRules); err != nil {
+			log.Error("failed to compile rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rules"))
+			return
+		}
+
+		if csv, ok := parser.(importer.CSVParser); ok {
+			if opts.DefaultCurrency == "" {
+				opts.DefaultCurrency = csv.Mapping.DefaultCurrency
+			}
+			if opts.DefaultCategoryID == nil {
+				opts.DefaultCategoryID = csv.Mapping.DefaultCategoryID
+			}
+		}
+		if id := opts.DefaultCategoryID; id != nil && !ownsCategory(opts.Categories, *id) {
+			log.Error("default category not found", slog.String("category_id", id.String()))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("default category not found"))
+			return
+		}
+
+		overrides, err := categoryOverrides(c.PostForm("category_map"))
+		if err != nil {
+			log.Error("invalid category map", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+		createCategories, err := strconv.ParseBool(c.DefaultPostForm("create_categories", strconv.FormatBool(importer.IsApp(format))))
+		if err != nil {
+			log.Error("invalid create_categories", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid create_categories"))
+			return
+		}
+		mapping, created := importer.MapCategories(records, opts, overrides, createCategories)
+		importer.ApplyCategoryMapping(records, mapping)
+		opts.Categories = append(opts.Categories, created...)
+
+		if suggester != nil {
+			ctx, cancel := context.WithTimeout(r.Context(), suggestBudget)
+			defer cancel()
+			opts.Suggester = rowSuggester{ctx: ctx, service: suggester, categories: opts.Categories}
+		}
+
+		rows := importer.Prepare(records, opts)
+
+		existing, err := importHandler.GetExistingExternalIDs(userID, importer.ExternalIDs(rows))
+		if err != nil {
+			log.Error("failed to check imported operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to check imported operations"))
+			return
+		}
+		importer.MarkDuplicates(rows, existing)
+
+		if importSuspected, _ := strconv.ParseBool(c.PostForm("import_suspected")); !importSuspected {
+			if err := markSuspected(rows, userID, importHandler); err != nil {
+				log.Error("failed to check duplicate operations", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to check duplicate operations"))
+				return
+			}
+		}
+
+		resp := summarize(rows, dryRun)
+		resp.Balances = balances
+		resp.Categories = mapping
+
+		if !dryRun {
+			ready := importer.Ready(rows)
+			if err := importHandler.ImportOperations(usedCategories(created, ready), ready); err != nil {
+				log.Error("failed to import operations", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to import operations"))
+				return
+			}
+			markImported(rows)
+			if suggester != nil {
+				suggester.Reset(userID)
+			}
+		}
+
+		log.Info("statement imported",
+			slog.Bool("dry_run", dryRun),
+			slog.Int("total", resp.Total),
+			slog.Int("failed", resp.Failed),
+			slog.Int("skipped", resp.Skipped),
+		)
+		render.JSON(w, r, resp)
+	}
+}
+
+// parse reads the statement records. Formats that carry balances also return
+// a check of every statement against its entries.
+func parse(parser importer.Parser, r io.Reader) ([]importer.Record, []models.BalanceCheck, error) {
+	bp, ok := parser.(importer.BalanceParser)
+	if !ok {
+		records, err := parser.Parse(r)
+		return records, nil, err
+	}
+
+	statements, err := bp.ParseStatements(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var records []importer.Record
+	balances := make([]models.BalanceCheck, 0, len(statements))
+	for _, s := range statements {
+		records = append(records, s.Records...)
+		balances = append(balances, s.Check())
+	}
+
+	return records, balances, nil
+}
+
+// markSuspected compares ready rows with the operations stored around the
+// statement period.
+func markSuspected(rows []models.ImportRow, userID uuid.UUID, importHandler ImportHandler) error {
+	from, to := importer.Span(rows)
+	if from.IsZero() {
+		return nil
+	}
+
+	stored, err := importHandler.GetOperations(userID, models.OperationFilter{
+		From: from.Add(-dedup.DefaultWindow),
+		To:   to.Add(dedup.DefaultWindow + 24*time.Hour),
+	})
+	if err != nil {
+		return err
+	}
+
+	importer.MarkSuspected(rows, stored, dedup.Options{})
+	return nil
+}
+
+// categoryOverrides reads the category_map field: source category names
+// mapped to the ids of existing categories.
+func categoryOverrides(raw string) (map[string]uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	var names map[string]string
+	if err := json.Unmarshal([]byte(raw), &names); err != nil {
+		return nil, fmt.Errorf("invalid category map")
+	}
+
+	overrides := make(map[string]uuid.UUID, len(names))
+	for name, value := range names {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid category id %q", value)
+		}
+		overrides[name] = id
+	}
+
+	return overrides, nil
+}
+
+// ownsCategory reports whether id is one of the user's categories.
+func ownsCategory(categories []domain.Category, id uuid.UUID) bool {
+	for _, c := range categories {
+		if c.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// usedCategories drops new categories whose rows all failed or were skipped.
+func usedCategories(created []domain.Category, operations []models.OperationRequest) []domain.Category {
+	used := make(map[uuid.UUID]bool, len(operations))
+	for _, op := range operations {
+		used[op.CategoryID] = true
+		for _, split := range op.Splits {
+			used[split.CategoryID] = true
+		}
+	}
+
+	var result []domain.Category
+	for _, c := range created {
+		if used[c.ID] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func balanced(balances []models.BalanceCheck) bool {
+	for _, b := range balances {
+		if !b.Balanced {
+			return false
+		}
+	}
+	return true
+}
+
+func summarize(rows []models.ImportRow, dryRun bool) models.ImportResponse {
+	resp := models.ImportResponse{
+		Response: response.OK(),
+		DryRun:   dryRun,
+		Total:    len(rows),
+		Rows:     rows,
+	}
+	for _, row := range rows {
+		switch {
+		case row.Status == importer.StatusError:
+			resp.Failed++
+		case row.Status == importer.StatusDuplicate, row.Status == importer.StatusSuspected:
+			resp.Skipped++
+		case !dryRun:
+			resp.Imported++
+		}
+	}
+	return resp
+}
+
+func markImported(rows []models.ImportRow) {
+	for i := range rows {
+		if rows[i].Status == importer.StatusReady {
+			rows[i].Status = importer.StatusImported
+		}
+	}
+}
+
+// rowSuggester suggests categories for statement rows among the user's
+// categories, including the ones created for this import.
+type rowSuggester struct {
+	ctx        context.Context
+	service    *categorizer.Service
+	categories []domain.Category
+}
+
+func (s rowSuggester) Suggest(op models.OperationRequest) (models.CategorySuggestion, bool) {
+	return s.service.Suggest(s.ctx, categorizer.FromRequest(&op), s.categories)
+}
+
+func (s rowSuggester) Confident(suggestion models.CategorySuggestion) bool {
+	return s.service.Confident(suggestion)
+}
@@ -0,0 +1,118 @@
+package imports
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/logger/handlers/slogdiscard"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeImportStore struct {
+	categories []domain.Category
+	imported   []models.OperationRequest
+}
+
+func (s *fakeImportStore) GetImportProfile(uuid.UUID, uuid.UUID) (*domain.ImportProfile, error) {
+	return nil, nil
+}
+
+func (s *fakeImportStore) GetCategories(uuid.UUID) ([]domain.Category, error) {
+	return s.categories, nil
+}
+
+func (s *fakeImportStore) GetRules(uuid.UUID) ([]domain.Rule, error) {
+	return nil, nil
+}
+
+func (s *fakeImportStore) GetExistingExternalIDs(uuid.UUID, []string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *fakeImportStore) GetOperations(uuid.UUID, models.OperationFilter) ([]domain.Operation, error) {
+	return nil, nil
+}
+
+func (s *fakeImportStore) ImportOperations(_ []domain.Category, operations []models.OperationRequest) error {
+	s.imported = append(s.imported, operations...)
+	return nil
+}
+
+func TestImportRejectsForeignDefaultCategory(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userID := uuid.New()
+	own := domain.Category{BaseEntity: domain.BaseEntity{ID: uuid.New()}, UserID: userID, Name: "Groceries", Type: "expense"}
+	foreign := uuid.New()
+
+	cases := []struct {
+		name       string
+		form       map[string]string
+		statusCode int
+		imported   int
+	}{
+		{
+			name:       "own category",
+			form:       map[string]string{"default_category_id": own.ID.String()},
+			statusCode: http.StatusOK,
+			imported:   1,
+		},
+		{
+			name:       "foreign category in the form",
+			form:       map[string]string{"default_category_id": foreign.String()},
+			statusCode: http.StatusBadRequest,
+		},
+		{
+			name: "foreign category in the mapping",
+			form: map[string]string{
+				"mapping": `{"date_format":"YYYY-MM-DD","default_category_id":"` + foreign.String() + `",` +
+					`"columns":{"date":"Date","amount":"Amount","name":"Payee"}}`,
+			},
+			statusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeImportStore{categories: []domain.Category{own}}
+			router := gin.New()
+			router.POST("/imports", func(c *gin.Context) {
+				c.Set(token.UserIDKey, userID.String())
+			}, New(slogdiscard.NewDiscardLogger(), store, nil))
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fields := map[string]string{
+				"default_currency": "EUR",
+				"mapping":          `{"date_format":"YYYY-MM-DD","columns":{"date":"Date","amount":"Amount","name":"Payee"}}`,
+			}
+			for k, v := range tc.form {
+				fields[k] = v
+			}
+			for k, v := range fields {
+				require.NoError(t, mw.WriteField(k, v))
+			}
+			fw, err := mw.CreateFormFile("file", "statement.csv")
+			require.NoError(t, err)
+			_, err = fw.Write([]byte("Date,Payee,Amount\n2024-01-02,LIDL,-12.50\n"))
+			require.NoError(t, err)
+			require.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/imports", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code, rr.Body.String())
+			require.Len(t, store.imported, tc.imported)
+		})
+	}
+}
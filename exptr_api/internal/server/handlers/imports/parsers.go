@@ -0,0 +1,89 @@
+package imports
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/importer"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatOFX = "ofx"
+	FormatQFX = "qfx"
+	FormatQIF = "qif"
+
+	FormatCAMT  = "camt053"
+	FormatMT940 = "mt940"
+
+	FormatLedger    = "ledger"
+	FormatHLedger   = "hledger"
+	FormatBeancount = "beancount"
+)
+
+type ProfileGetter interface {
+	GetImportProfile(id, userID uuid.UUID) (*domain.ImportProfile, error)
+}
+
+// parserFor picks the statement parser for the requested format. CSV files
+// are read with a saved profile or with a mapping sent along with the file.
+func parserFor(c *gin.Context, format string, userID uuid.UUID, profiles ProfileGetter) (importer.Parser, error) {
+	switch format {
+	case FormatCSV, "":
+		mapping, err := csvMapping(c, userID, profiles)
+		if err != nil {
+			return nil, err
+		}
+		return importer.CSVParser{Mapping: *mapping}, nil
+	case FormatOFX, FormatQFX:
+		return importer.OFXParser{}, nil
+	case FormatQIF:
+		return importer.QIFParser{
+			DateFormat:       c.PostForm("date_format"),
+			DecimalSeparator: c.PostForm("decimal_separator"),
+			Currency:         c.PostForm("default_currency"),
+		}, nil
+	case FormatCAMT:
+		return importer.CAMTParser{}, nil
+	case FormatMT940:
+		return importer.MT940Parser{}, nil
+	case FormatLedger, FormatHLedger:
+		return importer.JournalParser{}, nil
+	case FormatBeancount:
+		return importer.JournalParser{Beancount: true}, nil
+	case importer.AppYNAB, importer.AppMint, importer.AppZenMoney, importer.AppMoneyManager, importer.AppFirefly:
+		return importer.AppParser{App: format, DateFormat: c.PostForm("date_format")}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func csvMapping(c *gin.Context, userID uuid.UUID, profiles ProfileGetter) (*domain.CSVMapping, error) {
+	if raw := c.PostForm("profile_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid profile id")
+		}
+		profile, err := profiles.GetImportProfile(id, userID)
+		if err != nil {
+			return nil, fmt.Errorf("import profile not found")
+		}
+		return &profile.Mapping, nil
+	}
+
+	raw := c.PostForm("mapping")
+	if raw == "" {
+		return nil, fmt.Errorf("profile_id or mapping is required")
+	}
+
+	var mapping domain.CSVMapping
+	if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+		return nil, fmt.Errorf("invalid mapping")
+	}
+
+	return &mapping, nil
+}
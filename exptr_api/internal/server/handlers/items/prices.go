@@ -0,0 +1,121 @@
+package items
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type ItemPricesHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+	GetItemOperations(userID uuid.UUID, query string, filter models.OperationFilter, limit int) ([]domain.Operation, error)
+	GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error)
+}
+
+// Prices godoc
+// @Summary      Chart the unit price of an item
+// @Description  Converts the unit price of every receipt line containing the words of q into the user's base currency with the rate on its date, and lists the purchases oldest first with price statistics overall, per payee (cheapest first) and per month
+// @Tags         items
+// @Produce      json
+// @Param        q query string true "words the description must contain"
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {object}  models.GetItemPricesResponse
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /items/prices [get]
+func Prices(log *slog.Logger, itemPricesHandler ItemPricesHandler, converter report.Converter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.items.prices.Prices"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("q is required"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		user, err := itemPricesHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get user"))
+			return
+		}
+
+		operations, err := itemPricesHandler.GetItemOperations(userID, q, filter, 0)
+		if err != nil {
+			log.Error("failed to search items", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to search items"))
+			return
+		}
+
+		payees, err := payeeList(itemPricesHandler, userID)
+		if err != nil {
+			log.Error("failed to get payees", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get payees"))
+			return
+		}
+
+		history, err := report.BuildPrices(report.Purchases(operations, payees), user.BaseCurrency, converter)
+		if err != nil {
+			log.Error("failed to build price history", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to build price history"))
+			return
+		}
+
+		log.Info("price history built", slog.Int("purchases", len(history.Purchases)))
+		render.JSON(w, r, models.GetItemPricesResponse{
+			Response: response.OK(),
+			Prices:   history,
+		})
+	}
+}
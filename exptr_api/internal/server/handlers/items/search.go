@@ -0,0 +1,128 @@
+package items
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type SearchItemsHandler interface {
+	GetItemOperations(userID uuid.UUID, query string, filter models.OperationFilter, limit int) ([]domain.Operation, error)
+	GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error)
+}
+
+// Search godoc
+// @Summary      Search receipt items
+// @Description  Lists receipt lines containing every word of q across the user's operations, newest first. Descriptions are compared ignoring case and punctuation, so "milk 1l" finds "MILK 1 L".
+// @Tags         items
+// @Produce      json
+// @Param        q query string false "words the description must contain"
+// @Param        limit query int false "most recent operations to look at, 100 by default and at most 1000"
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {object}  models.GetItemsResponse
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /items [get]
+func Search(log *slog.Logger, searchItemsHandler SearchItemsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.items.search.Search"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		limit := defaultLimit
+		if raw := c.Query("limit"); raw != "" {
+			limit, err = strconv.Atoi(raw)
+			if err != nil || limit < 1 || limit > maxLimit {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid limit"))
+				return
+			}
+		}
+
+		operations, err := searchItemsHandler.GetItemOperations(userID, c.Query("q"), filter, limit)
+		if err != nil {
+			log.Error("failed to search items", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to search items"))
+			return
+		}
+
+		payees, err := payeeList(searchItemsHandler, userID)
+		if err != nil {
+			log.Error("failed to get payees", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get payees"))
+			return
+		}
+
+		render.JSON(w, r, models.GetItemsResponse{
+			Response: response.OK(),
+			Items:    report.Purchases(operations, payees),
+		})
+	}
+}
+
+type payeeLister interface {
+	GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error)
+}
+
+func payeeList(h payeeLister, userID uuid.UUID) ([]domain.Payee, error) {
+	usage, err := h.GetPayees(userID)
+	if err != nil {
+		return nil, err
+	}
+	payees := make([]domain.Payee, 0, len(usage))
+	for _, u := range usage {
+		payees = append(payees, u.Payee)
+	}
+	return payees, nil
+}
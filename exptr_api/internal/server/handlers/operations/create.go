@@ -1,9 +1,13 @@
 package operations
 
 import (
+	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
 	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
 	"errors"
 	"io"
 	"log/slog"
@@ -12,25 +16,31 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 //go:generate mockery --name=CreateOperationHandler
 type CreateOperationHandler interface {
 	CreateOperation(models.OperationRequest) error
+	GetRules(userID uuid.UUID) ([]domain.Rule, error)
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
 }
 
 // New godoc
 // @Summary      Create new operation
-// @Description  Create new operation
+// @Description  Create new operation. The user's rules run on it first; a category they set is only used when the request has none.
+// @Description  Without a category after the rules one is suggested and used when confident, otherwise the request fails with the suggestion attached.
 // @Tags         operations
 // @Accept       json
 // @Produce      json
 // @Param        data body models.OperationRequest  true  "Create operation"
 // @Success      200  {object}  models.CreateOperationResponse
 // @Failure      400  {string} 	string "empty request body"
+// @Failure      400  {object} 	models.CreateOperationResponse "category is required"
+// @Failure      400  {string} 	string "split category not found"
 // @Failure      500  {string}  string "server error"
 // @Router       /operations/new [post]
-func New(log *slog.Logger, createOperationHandler CreateOperationHandler) gin.HandlerFunc {
+func New(log *slog.Logger, createOperationHandler CreateOperationHandler, suggester *categorizer.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		const op = "handlers.operations.create.CreateOperation"
 
@@ -66,19 +76,87 @@ func New(log *slog.Logger, createOperationHandler CreateOperationHandler) gin.Ha
 			return
 		}
 
+		if err := normalizeCurrencies(&req); err != nil {
+			log.Error("invalid currency", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if err := checkSplits(&req); err != nil {
+			log.Error("invalid splits", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		userRules, err := createOperationHandler.GetRules(req.UserID)
+		if err != nil {
+			log.Error("failed to get rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create operation"))
+			return
+		}
+
+		set, err := rules.Compile(userRules)
+		if err != nil {
+			log.Error("failed to compile rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create operation"))
+			return
+		}
+		set.Evaluate(rules.FromRequest(&req)).Apply(&req)
+
+		var suggestion *models.CategorySuggestion
+		if req.CategoryID == uuid.Nil && suggester != nil {
+			categories, err := createOperationHandler.GetCategories(req.UserID)
+			if err != nil {
+				log.Error("failed to get categories", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to create operation"))
+				return
+			}
+
+			if s, ok := suggester.Suggest(r.Context(), categorizer.FromRequest(&req), categories); ok {
+				suggestion = &s
+				if suggester.Confident(s) {
+					req.CategoryID = s.CategoryID
+				}
+			}
+		}
+
+		if req.CategoryID == uuid.Nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, models.CreateOperationResponse{
+				Response:   response.Error("category is required"),
+				Suggestion: suggestion,
+			})
+			return
+		}
+
 		err = createOperationHandler.CreateOperation(req)
 
 		if err != nil {
+			if errors.Is(err, storage.ErrSplitCategory) {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
 			log.Error("failed to create operation", sl.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("failed to create operation"))
 			return
 		}
 
+		if suggester != nil && len(req.Splits) == 0 {
+			suggester.Learn(categorizer.FromRequest(&req), req.CategoryID)
+		}
+
 		log.Info("operation created", slog.Any("operation", req))
 
 		render.JSON(w, r, models.CreateOperationResponse{
-			Response: response.OK(),
+			Response:   response.OK(),
+			Suggestion: suggestion,
 		})
 	}
 }
@@ -1,9 +1,12 @@
 package operations
 
 import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
 	"alex_gorbunov_exptr_api/internal/lib/logger/handlers/slogdiscard"
 	"alex_gorbunov_exptr_api/internal/models"
 	"alex_gorbunov_exptr_api/internal/server/handlers/operations/mocks"
+	"alex_gorbunov_exptr_api/internal/storage"
 	"bytes"
 	"encoding/json"
 	"net/http"
@@ -23,13 +26,26 @@ func TestCreateOperationHandler(t *testing.T) {
 	userID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
 	categoryID := uuid.MustParse("22222222-2222-2222-2222-222222222222")
 
+	spotify := domain.Rule{
+		BaseEntity: domain.BaseEntity{ID: uuid.New()},
+		Name:       "Spotify",
+		Enabled:    true,
+		Conditions: domain.RuleConditions{Name: &domain.TextCondition{Contains: "spotify"}},
+		Actions:    domain.RuleActions{CategoryID: &categoryID},
+	}
+	categories := []domain.Category{{BaseEntity: domain.BaseEntity{ID: categoryID}, Name: "Groceries", Type: "expense"}}
+	suggester := categorizer.New(nil, categorizer.Options{MinConfidence: 0.5})
+
 	cases := []struct {
-		name       string
-		input      string
-		mockError  error
-		setupMock  bool
-		statusCode int
-		respError  string
+		name          string
+		input         string
+		rules         []domain.Rule
+		getRules      bool
+		getCategories bool
+		mockError     error
+		setupMock     bool
+		statusCode    int
+		respError     string
 	}{
 		{
 			name: "success",
@@ -43,6 +59,7 @@ func TestCreateOperationHandler(t *testing.T) {
 				"type":"expense",
 				"created_at":"2024-01-01T00:00:00Z"
 			}`,
+			getRules:   true,
 			setupMock:  true,
 			mockError:  nil,
 			statusCode: http.StatusOK,
@@ -62,6 +79,109 @@ func TestCreateOperationHandler(t *testing.T) {
 			statusCode: http.StatusBadRequest,
 			respError:  "failed to decode request",
 		},
+		{
+			name: "split without category",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"amount":100,
+				"currency":"USD",
+				"name":"Supermarket",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z",
+				"splits":[
+					{"category_id":"22222222-2222-2222-2222-222222222222","amount":70},
+					{"category_id":"22222222-2222-2222-2222-222222222222","amount":30,"note":"detergent"}
+				]
+			}`,
+			getRules:   true,
+			setupMock:  true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "split on a foreign category",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"amount":100,
+				"currency":"USD",
+				"name":"Supermarket",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z",
+				"splits":[
+					{"category_id":"22222222-2222-2222-2222-222222222222","amount":70},
+					{"category_id":"33333333-3333-3333-3333-333333333333","amount":30,"note":"detergent"}
+				]
+			}`,
+			getRules:   true,
+			setupMock:  true,
+			mockError:  storage.ErrSplitCategory,
+			statusCode: http.StatusBadRequest,
+			respError:  "split category not found",
+		},
+		{
+			name: "category from rule",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"amount":999,
+				"currency":"EUR",
+				"name":"SPOTIFY P1234",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z"
+			}`,
+			rules:      []domain.Rule{spotify},
+			getRules:   true,
+			setupMock:  true,
+			statusCode: http.StatusOK,
+		},
+		{
+			name: "no category and no matching rule",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"amount":999,
+				"currency":"EUR",
+				"name":"Corner shop",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z"
+			}`,
+			rules:         []domain.Rule{spotify},
+			getRules:      true,
+			getCategories: true,
+			statusCode:    http.StatusBadRequest,
+			respError:     "category is required",
+		},
+		{
+			name: "category suggested",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"amount":2500,
+				"currency":"EUR",
+				"name":"Groceries at Lidl",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z"
+			}`,
+			getRules:      true,
+			getCategories: true,
+			setupMock:     true,
+			statusCode:    http.StatusOK,
+		},
+		{
+			name: "splits do not add up",
+			input: `{
+				"user_id":"11111111-1111-1111-1111-111111111111",
+				"category_id":"22222222-2222-2222-2222-222222222222",
+				"amount":100,
+				"currency":"USD",
+				"name":"Supermarket",
+				"type":"expense",
+				"created_at":"2024-01-01T00:00:00Z",
+				"splits":[
+					{"category_id":"22222222-2222-2222-2222-222222222222","amount":70},
+					{"category_id":"33333333-3333-3333-3333-333333333333","amount":20}
+				]
+			}`,
+			setupMock:  false,
+			statusCode: http.StatusBadRequest,
+			respError:  "splits do not add up to the amount",
+		},
 		{
 			name: "missing required field",
 			input: `{
@@ -77,6 +197,12 @@ func TestCreateOperationHandler(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			createOperationMock := mocks.NewCreateOperationHandler(t)
 
+			if tc.getRules {
+				createOperationMock.On("GetRules", userID).Return(tc.rules, nil).Once()
+			}
+			if tc.getCategories {
+				createOperationMock.On("GetCategories", userID).Return(categories, nil).Once()
+			}
 			if tc.setupMock {
 				createOperationMock.On("CreateOperation", mock.MatchedBy(func(op models.OperationRequest) bool {
 					return op.UserID == userID && op.CategoryID == categoryID
@@ -84,7 +210,7 @@ func TestCreateOperationHandler(t *testing.T) {
 			}
 
 			log := slogdiscard.NewDiscardLogger()
-			handler := New(log, createOperationMock)
+			handler := New(log, createOperationMock, suggester)
 
 			w := httptest.NewRecorder()
 			c, _ := gin.CreateTestContext(w)
@@ -135,11 +261,12 @@ func TestCreateOperationHandler_Integration(t *testing.T) {
 			op.Comment == expectedOperation.Comment &&
 			op.Type == expectedOperation.Type
 	})).Return(nil).Once()
+	createOperationMock.On("GetRules", userID).Return(nil, nil).Once()
 
 	log := slogdiscard.NewDiscardLogger()
 
 	router := gin.New()
-	router.POST("/operations/new", New(log, createOperationMock))
+	router.POST("/operations/new", New(log, createOperationMock, nil))
 
 	input := `{
 		"user_id":"11111111-1111-1111-1111-111111111111",
@@ -0,0 +1,35 @@
+package operations
+
+import (
+	"errors"
+
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/models"
+)
+
+// normalizeCurrencies validates the currencies of a request against the ISO
+// 4217 catalog and derives the effective rate of a settled operation.
+func normalizeCurrencies(req *models.OperationRequest) error {
+	req.Currency = currency.Normalize(req.Currency)
+	if !currency.IsValid(req.Currency) {
+		return errors.New("unknown currency")
+	}
+
+	if req.SettledAmount == nil {
+		req.SettledCurrency = ""
+		req.EffectiveRate = nil
+		return nil
+	}
+
+	req.SettledCurrency = currency.Normalize(req.SettledCurrency)
+	if !currency.IsValid(req.SettledCurrency) {
+		return errors.New("unknown settled currency")
+	}
+
+	if req.EffectiveRate == nil && req.Amount != 0 {
+		rate := currency.ToMajor(*req.SettledAmount, req.SettledCurrency) / currency.ToMajor(req.Amount, req.Currency)
+		req.EffectiveRate = &rate
+	}
+
+	return nil
+}
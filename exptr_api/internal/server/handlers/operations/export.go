@@ -0,0 +1,148 @@
+package operations
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/export"
+	"alex_gorbunov_exptr_api/internal/lib/importer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type ExportOperationsHandler interface {
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
+	EachOperation(userID uuid.UUID, filter models.OperationFilter, each func(*domain.Operation) error) error
+}
+
+// Export godoc
+// @Summary      Export operations as CSV, XLSX or a plain-text accounting journal
+// @Description  Streams the filtered operations with category names resolved
+// @Tags         operations
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      text/plain
+// @Param        format query string false "csv (default), xlsx, ledger, hledger or beancount"
+// @Param        columns query string false "comma separated columns: date, type, name, category, amount, currency, settled_amount, settled_currency, account, comment, external_id"
+// @Param        locale query string false "number and date formatting, e.g. en, en-US, de, fr, ru"
+// @Param        date_format query string false "date pattern such as DD.MM.YYYY, overrides the locale"
+// @Param        decimal_separator query string false "decimal separator, overrides the locale"
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {file}  file
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/export [get]
+func Export(log *slog.Logger, exportOperationsHandler ExportOperationsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.operations.export.Export"
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		opts := export.Options{
+			Columns: export.ParseColumns(c.Query("columns")),
+			Locale:  export.DefaultLocale,
+		}
+		if tag := c.Query("locale"); tag != "" {
+			locale, ok := export.LookupLocale(tag)
+			if !ok {
+				log.Error("unknown locale", slog.String("locale", tag))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(fmt.Sprintf("unknown locale %q", tag)))
+				return
+			}
+			opts.Locale = locale
+		}
+		if pattern := c.Query("date_format"); pattern != "" {
+			opts.Locale.DateLayout = importer.DateLayout(pattern)
+		}
+		if sep := c.Query("decimal_separator"); sep != "" {
+			opts.Locale.Decimal = sep
+		}
+
+		categories, err := exportOperationsHandler.GetCategories(userID)
+		if err != nil {
+			log.Error("failed to get categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get categories"))
+			return
+		}
+		opts.Categories = make(map[uuid.UUID]string, len(categories))
+		for _, category := range categories {
+			opts.Categories[category.ID] = category.Name
+		}
+
+		format := c.DefaultQuery("format", export.FormatCSV)
+		filename := fmt.Sprintf("operations-%s.%s", time.Now().Format("2006-01-02"), export.Extension(format))
+		w.Header().Set("Content-Type", export.ContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+		writer, err := export.NewWriter(w, format, opts)
+		if err != nil {
+			log.Error("failed to start export", sl.Error(err))
+			w.Header().Del("Content-Disposition")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		// The header is sent with the first rows, so a failure past this
+		// point can only be logged; the client gets a truncated file.
+		var count int
+		err = exportOperationsHandler.EachOperation(userID, filter, func(operation *domain.Operation) error {
+			count++
+			return writer.Write(operation)
+		})
+		if err != nil {
+			log.Error("failed to export operations", slog.String("op", op), sl.Error(err))
+			return
+		}
+
+		if err := writer.Close(); err != nil {
+			log.Error("failed to finish export", slog.String("op", op), sl.Error(err))
+			return
+		}
+
+		log.Info("operations exported", slog.String("format", format), slog.Int("operations", count))
+	}
+}
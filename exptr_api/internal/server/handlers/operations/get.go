@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
 	"alex_gorbunov_exptr_api/internal/models"
@@ -16,15 +17,22 @@ import (
 )
 
 type GetOperationHandler interface {
-	GetOperationsByUserID(userID uuid.UUID) ([]domain.Operation, error)
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
 }
 
 // GetAll godoc
 // @Summary      Get all current user operations
-// @Description  Get all current user operations
+// @Description  Get current user operations, oldest first, optionally filtered
 // @Tags         operations
 // @Accept       json
 // @Produce      json
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
 // @Success      200  {object}  models.GetOperationsByUserIDResponse
 // @Failure      400  {string} 	string "empty request body"
 // @Failure      500  {string}  string "server error"
@@ -52,7 +60,15 @@ func GetAll(log *slog.Logger, getAllOperationHandler GetOperationHandler) gin.Ha
 			return
 		}
 
-		operations, err := getAllOperationHandler.GetOperationsByUserID(targetUserID)
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		operations, err := getAllOperationHandler.GetOperations(targetUserID, filter)
 		if err != nil {
 			log.Error("failed to get all operations", sl.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
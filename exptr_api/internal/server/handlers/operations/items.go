@@ -0,0 +1,22 @@
+package operations
+
+import (
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// checkItems applies the limits of the items field on update, where the
+// request as a whole is not validated.
+func checkItems(req *models.OperationRequest) error {
+	validate := validator.New()
+	if err := validate.Var(req.Items, "max=200"); err != nil {
+		return err
+	}
+	for _, item := range req.Items {
+		if err := validate.Struct(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,9 +3,12 @@
 package mocks
 
 import (
+	domain "alex_gorbunov_exptr_api/internal/domain"
 	models "alex_gorbunov_exptr_api/internal/models"
 
 	mock "github.com/stretchr/testify/mock"
+
+	uuid "github.com/google/uuid"
 )
 
 // CreateOperationHandler is an autogenerated mock type for the CreateOperationHandler type
@@ -31,6 +34,66 @@ func (_m *CreateOperationHandler) CreateOperation(_a0 models.OperationRequest) e
 	return r0
 }
 
+// GetCategories provides a mock function with given fields: userID
+func (_m *CreateOperationHandler) GetCategories(userID uuid.UUID) ([]domain.Category, error) {
+	ret := _m.Called(userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategories")
+	}
+
+	var r0 []domain.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]domain.Category, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []domain.Category); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRules provides a mock function with given fields: userID
+func (_m *CreateOperationHandler) GetRules(userID uuid.UUID) ([]domain.Rule, error) {
+	ret := _m.Called(userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRules")
+	}
+
+	var r0 []domain.Rule
+	var r1 error
+	if rf, ok := ret.Get(0).(func(uuid.UUID) ([]domain.Rule, error)); ok {
+		return rf(userID)
+	}
+	if rf, ok := ret.Get(0).(func(uuid.UUID) []domain.Rule); ok {
+		r0 = rf(userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]domain.Rule)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(uuid.UUID) error); ok {
+		r1 = rf(userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // NewCreateOperationHandler creates a new instance of CreateOperationHandler. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewCreateOperationHandler(t interface {
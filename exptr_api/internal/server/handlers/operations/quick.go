@@ -0,0 +1,214 @@
+package operations
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/quickentry"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type QuickEntryHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
+	GetRules(userID uuid.UUID) ([]domain.Rule, error)
+	CreateOperation(models.OperationRequest) error
+}
+
+// Quick godoc
+// @Summary      Quick entry
+// @Description  Parse a free-text note in English or Russian, such as "coffee 4.50 eur yesterday #work" or "зарплата +3000", into an operation.
+// @Description  The amount's sign or words like salary make it income; #words become tags and @category names the category, which otherwise comes from the rules or a suggestion.
+// @Description  Without commit the draft is returned for confirmation; with commit it is created.
+// @Tags         operations
+// @Accept       json
+// @Produce      json
+// @Param        data body models.QuickEntryRequest  true  "Note"
+// @Success      200  {object}  models.QuickEntryResponse
+// @Failure      400  {string} 	string "no amount found"
+// @Failure      400  {object} 	models.QuickEntryResponse "category is required"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/quick [post]
+func Quick(log *slog.Logger, quickEntryHandler QuickEntryHandler, suggester *categorizer.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.operations.quick.Quick"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		var req models.QuickEntryRequest
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		loc := time.UTC
+		if req.Timezone != "" {
+			if loc, err = time.LoadLocation(req.Timezone); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid timezone"))
+				return
+			}
+		}
+
+		user, err := quickEntryHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		entry, err := quickentry.Parse(req.Text, quickentry.Options{
+			Now:             time.Now().In(loc),
+			DefaultCurrency: user.BaseCurrency,
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		draft := models.OperationRequest{
+			UserID:    userID,
+			Amount:    entry.Amount,
+			Currency:  entry.Currency,
+			Name:      entry.Name,
+			Type:      entry.Type,
+			CreatedAt: entry.Date,
+			Tags:      entry.Tags,
+		}
+		if draft.Currency == "" {
+			draft.Currency = user.BaseCurrency
+		}
+
+		categories, err := quickEntryHandler.GetCategories(userID)
+		if err != nil {
+			log.Error("failed to get categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get categories"))
+			return
+		}
+		if entry.Category != "" {
+			id, ok := categoryByName(categories, entry.Category)
+			if !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(fmt.Sprintf("category %q not found", entry.Category)))
+				return
+			}
+			draft.CategoryID = id
+		}
+
+		userRules, err := quickEntryHandler.GetRules(userID)
+		if err != nil {
+			log.Error("failed to get rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rules"))
+			return
+		}
+		set, err := rules.Compile(userRules)
+		if err != nil {
+			log.Error("failed to compile rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rules"))
+			return
+		}
+		set.Evaluate(rules.FromRequest(&draft)).Apply(&draft)
+
+		resp := models.QuickEntryResponse{Response: response.OK(), Draft: &draft}
+		if draft.CategoryID == uuid.Nil && suggester != nil {
+			if s, ok := suggester.Suggest(r.Context(), categorizer.FromRequest(&draft), categories); ok {
+				resp.Suggestion = &s
+				if suggester.Confident(s) {
+					draft.CategoryID = s.CategoryID
+				}
+			}
+		}
+
+		if !req.Commit {
+			render.JSON(w, r, resp)
+			return
+		}
+
+		if draft.CategoryID == uuid.Nil {
+			resp.Response = response.Error("category is required")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, resp)
+			return
+		}
+
+		if err := quickEntryHandler.CreateOperation(draft); err != nil {
+			log.Error("failed to create operation", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create operation"))
+			return
+		}
+		if suggester != nil {
+			suggester.Learn(categorizer.FromRequest(&draft), draft.CategoryID)
+		}
+		resp.Created = true
+
+		log.Info("quick entry created", slog.Any("operation", draft))
+		render.JSON(w, r, resp)
+	}
+}
+
+// categoryByName finds a category ignoring case and extra spaces.
+func categoryByName(categories []domain.Category, name string) (uuid.UUID, bool) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	for _, c := range categories {
+		if strings.Join(strings.Fields(strings.ToLower(c.Name)), " ") == name {
+			return c.ID, true
+		}
+	}
+	return uuid.Nil, false
+}
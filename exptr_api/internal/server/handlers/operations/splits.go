@@ -0,0 +1,30 @@
+package operations
+
+import (
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// checkSplits makes sure the splits of a request add up to its amount and
+// books an operation without a category on its first split's category.
+func checkSplits(req *models.OperationRequest) error {
+	if len(req.Splits) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, split := range req.Splits {
+		total += split.Amount
+	}
+	if total != req.Amount {
+		return storage.ErrSplitMismatch
+	}
+
+	if req.CategoryID == uuid.Nil {
+		req.CategoryID = req.Splits[0].CategoryID
+	}
+
+	return nil
+}
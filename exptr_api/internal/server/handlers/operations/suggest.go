@@ -0,0 +1,134 @@
+package operations
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultRanked = 3
+	maxRanked     = 10
+)
+
+type SuggestCategoryHandler interface {
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
+}
+
+// SuggestCategory godoc
+// @Summary      Suggest a category
+// @Description  Suggest one of the user's categories for an operation without creating it, and rank the k most probable ones learned from the user's history
+// @Tags         operations
+// @Accept       json
+// @Produce      json
+// @Param        data body models.SuggestCategoryRequest  true  "Operation to categorize"
+// @Param        k query int false "number of ranked categories, 3 by default and at most 10"
+// @Success      200  {object}  models.SuggestCategoryResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      400  {string} 	string "invalid k"
+// @Failure      500  {string}  string "server error"
+// @Router       /operations/suggest-category [post]
+func SuggestCategory(log *slog.Logger, suggestCategoryHandler SuggestCategoryHandler, suggester *categorizer.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.operations.suggest.SuggestCategory"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		k := defaultRanked
+		if raw := c.Query("k"); raw != "" {
+			k, err = strconv.Atoi(raw)
+			if err != nil || k < 1 || k > maxRanked {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid k"))
+				return
+			}
+		}
+
+		var req models.SuggestCategoryRequest
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		categories, err := suggestCategoryHandler.GetCategories(userID)
+		if err != nil {
+			log.Error("failed to get categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get categories"))
+			return
+		}
+
+		in := categorizer.Input{
+			UserID:   userID,
+			Name:     req.Name,
+			Comment:  req.Comment,
+			Amount:   req.Amount,
+			Currency: req.Currency,
+			Type:     req.Type,
+		}
+
+		ranked, err := suggester.Rank(in, categories, k)
+		if err != nil {
+			log.Error("failed to rank categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to rank categories"))
+			return
+		}
+
+		resp := models.SuggestCategoryResponse{Response: response.OK(), Ranked: ranked}
+		if suggestion, ok := suggester.Suggest(r.Context(), in, categories); ok {
+			resp.Suggestion = &suggestion
+			resp.Confident = suggester.Confident(suggestion)
+		}
+
+		render.JSON(w, r, resp)
+	}
+}
@@ -1,9 +1,12 @@
 package operations
 
 import (
+	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
 	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
 	"errors"
 	"io"
 	"log/slog"
@@ -16,6 +19,7 @@ import (
 
 type UpdateOperationHandler interface {
 	UpdateOperation(id uuid.UUID, operation *models.OperationRequest) error
+	GetOperationByID(id uuid.UUID) (*domain.Operation, error)
 }
 
 // UpdateOperation godoc
@@ -27,9 +31,11 @@ type UpdateOperationHandler interface {
 // @Param        id path string true "operation id" data body models.OperationRequest
 // @Success      200  {object}  models.UpdateOperationResponse
 // @Failure      400  {string} 	string "empty request body"
+// @Failure      400  {string} 	string "splits do not add up to the amount"
+// @Failure      400  {string} 	string "split category not found"
 // @Failure      500  {string}  string "server error"
 // @Router       /operations/{id} [put]
-func Update(log *slog.Logger, updateOperationHandler UpdateOperationHandler) gin.HandlerFunc {
+func Update(log *slog.Logger, updateOperationHandler UpdateOperationHandler, suggester *categorizer.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		const op = "handlers.operations.updated.UpdateOperation"
 
@@ -70,14 +76,64 @@ func Update(log *slog.Logger, updateOperationHandler UpdateOperationHandler) gin
 			return
 		}
 
+		if err := normalizeCurrencies(&req); err != nil {
+			log.Error("invalid currency", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if err := checkSplits(&req); err != nil {
+			log.Error("invalid splits", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if err := checkItems(&req); err != nil {
+			log.Error("invalid items", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		// The operation as it was is only needed to correct what the
+		// categorizer learned from it.
+		var previous *domain.Operation
+		if suggester != nil {
+			previous, err = updateOperationHandler.GetOperationByID(id)
+			if err != nil {
+				log.Error("failed to get operation", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to update operation"))
+				return
+			}
+		}
+
 		err = updateOperationHandler.UpdateOperation(id, &req)
 		if err != nil {
+			if errors.Is(err, storage.ErrSplitMismatch) || errors.Is(err, storage.ErrSplitCategory) {
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error(err.Error()))
+				return
+			}
 			log.Error("failed to update operation", sl.Error(err))
 			w.WriteHeader(http.StatusInternalServerError)
 			render.JSON(w, r, response.Error("failed to update operation"))
 			return
 		}
 
+		// Split operations were never learned under a single category, so
+		// there is nothing to unlearn for them.
+		if previous != nil && len(previous.Splits) == 0 && len(req.Splits) == 0 {
+			// The body's user_id is not validated on update, so the
+			// classifier is always the stored owner's.
+			old := categorizer.Input{UserID: previous.UserID, Name: previous.Name, Comment: previous.Comment}
+			in := categorizer.FromRequest(&req)
+			in.UserID = previous.UserID
+			suggester.Recategorize(old, previous.CategoryID, in, req.CategoryID)
+		}
+
 		log.Info("operation updated")
 		render.JSON(w, r, models.UpdateOperationResponse{
 			Response: response.OK(),
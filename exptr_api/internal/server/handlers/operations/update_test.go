@@ -0,0 +1,122 @@
+package operations
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/handlers/slogdiscard"
+	"alex_gorbunov_exptr_api/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeOperationStore struct {
+	operation domain.Operation
+}
+
+func (s *fakeOperationStore) GetOperationByID(uuid.UUID) (*domain.Operation, error) {
+	op := s.operation
+	return &op, nil
+}
+
+func (s *fakeOperationStore) UpdateOperation(uuid.UUID, *models.OperationRequest) error {
+	return nil
+}
+
+type fakeHistory struct {
+	operations []domain.Operation
+}
+
+func (h fakeHistory) GetTrainingOperations(uuid.UUID, int) ([]domain.Operation, error) {
+	return h.operations, nil
+}
+
+func TestUpdateOperationHandlerRetrainsTheOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	owner, stranger := uuid.New(), uuid.New()
+	categories := []domain.Category{
+		{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Groceries", Type: "expense"},
+		{BaseEntity: domain.BaseEntity{ID: uuid.New()}, Name: "Eating out", Type: "expense"},
+	}
+	groceries, eatingOut := categories[0].ID, categories[1].ID
+
+	cases := []struct {
+		name   string
+		userID string
+	}{
+		{"user_id left out", ""},
+		{"another user's id", `"user_id":"` + stranger.String() + `",`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sushi := domain.Operation{UserID: owner, CategoryID: eatingOut, Name: "Sushi bar"}
+			learner := categorizer.NewLearner(fakeHistory{operations: []domain.Operation{sushi}}, categorizer.LearnerOptions{})
+			suggester := categorizer.New(nil, categorizer.Options{Learner: learner})
+
+			// Load both classifiers so a correction to either would stick.
+			in := categorizer.Input{UserID: owner, Name: "Sushi bar", Type: "expense"}
+			for _, userID := range []uuid.UUID{owner, stranger} {
+				in.UserID = userID
+				_, err := learner.Rank(in, categories, 1)
+				require.NoError(t, err)
+			}
+
+			router := gin.New()
+			router.PUT("/operations/:id", Update(slogdiscard.NewDiscardLogger(), &fakeOperationStore{operation: sushi}, suggester))
+
+			body := `{` + tc.userID + `"category_id":"` + groceries.String() + `","amount":100,"currency":"USD","name":"Sushi bar","type":"expense"}`
+			req := httptest.NewRequest(http.MethodPut, "/operations/"+uuid.NewString(), strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			in.UserID = owner
+			ranked, err := learner.Rank(in, categories, 1)
+			require.NoError(t, err)
+			require.Equal(t, groceries, ranked[0].CategoryID)
+
+			in.UserID = stranger
+			ranked, err = learner.Rank(in, categories, 1)
+			require.NoError(t, err)
+			require.Equal(t, eatingOut, ranked[0].CategoryID)
+		})
+	}
+}
+
+func TestUpdateOperationHandlerValidatesItems(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		name  string
+		items string
+		field string
+	}{
+		{"missing description", `[{"quantity":1}]`, "Description"},
+		{"long description", `[{"description":"` + strings.Repeat("я", 256) + `"}]`, "Description"},
+		{"negative quantity", `[{"description":"Milk","quantity":-1}]`, "Quantity"},
+		{"too many items", `[` + strings.Repeat(`{"description":"Milk"},`, 200) + `{"description":"Milk"}]`, "max"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := gin.New()
+			// The request must be rejected before storage is touched.
+			router.PUT("/operations/:id", Update(slogdiscard.NewDiscardLogger(), nil, nil))
+
+			body := `{"amount":100,"currency":"USD","items":` + tc.items + `}`
+			req := httptest.NewRequest(http.MethodPut, "/operations/11111111-1111-1111-1111-111111111111", strings.NewReader(body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+			require.Contains(t, rr.Body.String(), tc.field)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package payees
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type CreatePayeeHandler interface {
+	CreatePayee(payee *domain.Payee, aliases []string) error
+}
+
+// New godoc
+// @Summary      Create payee
+// @Description  Creates a payee. Aliases are operation names as the bank writes them, such as "POS 1234 LIDL BERLIN"; card numbers, terminal ids and places are stripped. Without aliases the name is used.
+// @Tags         payees
+// @Accept       json
+// @Produce      json
+// @Param        data body models.PayeeRequest true "payee"
+// @Success      200  {object}  models.PayeeResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      409  {string} 	string "alias belongs to another payee"
+// @Failure      500  {string}  string "server error"
+// @Router       /payees/new [post]
+func New(log *slog.Logger, createPayeeHandler CreatePayeeHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.payees.create.New"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		req, ok := decodePayeeRequest(log, c)
+		if !ok {
+			return
+		}
+
+		payee := &domain.Payee{
+			UserID: userID,
+			Name:   strings.TrimSpace(req.Name),
+		}
+
+		if err := createPayeeHandler.CreatePayee(payee, req.Aliases); err != nil {
+			if errors.Is(err, storage.ErrPayeeAliasTaken) {
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error(storage.ErrPayeeAliasTaken.Error()))
+				return
+			}
+			log.Error("failed to create payee", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create payee"))
+			return
+		}
+
+		log.Info("payee created", slog.String("id", payee.ID.String()))
+		render.JSON(w, r, models.PayeeResponse{
+			Response: response.OK(),
+			Payee:    payee,
+		})
+	}
+}
+
+// decodePayeeRequest reads and validates a payee from the request body,
+// writing the error response itself when it fails.
+func decodePayeeRequest(log *slog.Logger, c *gin.Context) (models.PayeeRequest, bool) {
+	r := c.Request
+	w := c.Writer
+
+	var req models.PayeeRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("empty request body")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("empty request body"))
+		return req, false
+	}
+
+	if err != nil {
+		log.Error("failed to decode request", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request"))
+		return req, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+		log.Error("validation failed", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(validateErr.Error()))
+		return req, false
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("payee name is empty"))
+		return req, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,67 @@
+package payees
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type DetectPayeesHandler interface {
+	DetectPayees(userID uuid.UUID) (int, error)
+}
+
+// Detect godoc
+// @Summary      Link operations to payees
+// @Description  Finds the payee of every operation that has none from its name, creating payees for merchants not seen before
+// @Tags         payees
+// @Produce      json
+// @Success      200  {object}  models.DetectPayeesResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /payees/detect [post]
+func Detect(log *slog.Logger, detectPayeesHandler DetectPayeesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.payees.detect.Detect"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		linked, err := detectPayeesHandler.DetectPayees(userID)
+		if err != nil {
+			log.Error("failed to detect payees", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to detect payees"))
+			return
+		}
+
+		log.Info("payees detected", slog.Int("operations", linked))
+		render.JSON(w, r, models.DetectPayeesResponse{
+			Response:   response.OK(),
+			Operations: linked,
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package payees
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetPayeesHandler interface {
+	GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error)
+}
+
+// GetAll godoc
+// @Summary      Get payees
+// @Description  Lists the current user's payees by name with their aliases and the number of operations linked to each
+// @Tags         payees
+// @Produce      json
+// @Success      200  {object}  models.GetPayeesResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /payees [get]
+func GetAll(log *slog.Logger, getPayeesHandler GetPayeesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.payees.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		payees, err := getPayeesHandler.GetPayees(userID)
+		if err != nil {
+			log.Error("failed to get payees", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get payees"))
+			return
+		}
+
+		log.Info("payees received", slog.Int("count", len(payees)))
+		render.JSON(w, r, models.GetPayeesResponse{
+			Response: response.OK(),
+			Payees:   payees,
+		})
+	}
+}
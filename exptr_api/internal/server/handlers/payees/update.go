@@ -0,0 +1,99 @@
+package payees
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type UpdatePayeeHandler interface {
+	UpdatePayee(userID, id uuid.UUID, name string, aliases []string) (*domain.Payee, error)
+}
+
+// Update godoc
+// @Summary      Update payee
+// @Description  Renames a payee and, when aliases are given, replaces them. An alias of another payee is refused; merge the payees instead.
+// @Tags         payees
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Payee ID"
+// @Param        data body models.PayeeRequest true "payee"
+// @Success      200  {object}  models.PayeeResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "payee not found"
+// @Failure      409  {string} 	string "alias belongs to another payee"
+// @Failure      500  {string}  string "server error"
+// @Router       /payees/{id} [put]
+func Update(log *slog.Logger, updatePayeeHandler UpdatePayeeHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.payees.update.Update"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		req, ok := decodePayeeRequest(log, c)
+		if !ok {
+			return
+		}
+
+		payee, err := updatePayeeHandler.UpdatePayee(userID, id, strings.TrimSpace(req.Name), req.Aliases)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrItemNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("payee not found"))
+			case errors.Is(err, storage.ErrPayeeAliasTaken):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error(storage.ErrPayeeAliasTaken.Error()))
+			default:
+				log.Error("failed to update payee", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to update payee"))
+			}
+			return
+		}
+
+		log.Info("payee updated", slog.String("id", id.String()))
+		render.JSON(w, r, models.PayeeResponse{
+			Response: response.OK(),
+			Payee:    payee,
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package rates
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+)
+
+type GetRatesHandler interface {
+	GetExchangeRates(base string, on time.Time) ([]domain.ExchangeRate, error)
+}
+
+// GetAll godoc
+// @Summary      Get exchange rates
+// @Description  Get the latest exchange rates of a base currency known on a date
+// @Tags         rates
+// @Accept       json
+// @Produce      json
+// @Param        base query string false "base currency, EUR by default"
+// @Param        date query string false "YYYY-MM-DD, today by default"
+// @Success      200  {object}  models.GetRatesResponse
+// @Failure      400  {string} 	string "invalid date"
+// @Failure      500  {string}  string "server error"
+// @Router       /rates [get]
+func GetAll(log *slog.Logger, getRatesHandler GetRatesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rates.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		base := currency.Normalize(c.DefaultQuery("base", "EUR"))
+		if !currency.IsValid(base) {
+			log.Error("unknown currency", slog.String("base", base))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("unknown currency"))
+			return
+		}
+
+		on := time.Now()
+		if date := c.Query("date"); date != "" {
+			t, err := time.Parse("2006-01-02", date)
+			if err != nil {
+				log.Error("invalid date", sl.Error(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid date"))
+				return
+			}
+			on = t
+		}
+
+		rates, err := getRatesHandler.GetExchangeRates(base, on)
+		if err != nil {
+			log.Error("failed to get rates", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rates"))
+			return
+		}
+
+		log.Info("rates received")
+		render.JSON(w, r, models.GetRatesResponse{
+			Response: response.OK(),
+			Rates:    rates,
+		})
+	}
+}
+
+// Currencies godoc
+// @Summary      List supported currencies
+// @Description  List the ISO 4217 currencies operations can be recorded in
+// @Tags         rates
+// @Produce      json
+// @Success      200  {object}  models.GetCurrenciesResponse
+// @Router       /currencies [get]
+func Currencies() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		render.JSON(c.Writer, c.Request, models.GetCurrenciesResponse{
+			Response:   response.OK(),
+			Currencies: currency.All(),
+		})
+	}
+}
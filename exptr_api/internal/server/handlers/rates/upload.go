@@ -0,0 +1,124 @@
+package rates
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+const maxRatesFileSize = 10 << 20
+
+type UploadRatesHandler interface {
+	SaveExchangeRates(rates []domain.ExchangeRate) error
+}
+
+// Upload godoc
+// @Summary      Upload exchange rates
+// @Description  Load rates from a CSV file (date,base,quote,rate) or an ECB-style XML feed.
+// @Description  Rates are shared by all users, so only the configured uploaders may load them.
+// @Tags         rates
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "CSV or XML file"
+// @Success      200  {object}  models.UploadRatesResponse
+// @Failure      400  {string} 	string "invalid file"
+// @Failure      403  {string} 	string "rates upload is not allowed"
+// @Failure      500  {string}  string "server error"
+// @Router       /rates/upload [post]
+func Upload(log *slog.Logger, uploadRatesHandler UploadRatesHandler, uploaders []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rates.upload.Upload"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		if !allowed(uploaders, userID) {
+			log.Error("rates upload refused", slog.String("user_id", userID.String()))
+			w.WriteHeader(http.StatusForbidden)
+			render.JSON(w, r, response.Error("rates upload is not allowed"))
+			return
+		}
+
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			log.Error("missing file", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("missing file"))
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxRatesFileSize))
+		if err != nil {
+			log.Error("failed to read file", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to read file"))
+			return
+		}
+
+		var parsed []domain.ExchangeRate
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+			parsed, err = rates.ParseECB(bytes.NewReader(data))
+		} else {
+			parsed, err = rates.ParseCSV(bytes.NewReader(data))
+		}
+		if err != nil {
+			log.Error("failed to parse rates", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		if err := uploadRatesHandler.SaveExchangeRates(parsed); err != nil {
+			log.Error("failed to save rates", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to save rates"))
+			return
+		}
+
+		log.Info("rates uploaded", slog.Int("count", len(parsed)))
+		render.JSON(w, r, models.UploadRatesResponse{
+			Response: response.OK(),
+			Imported: len(parsed),
+		})
+	}
+}
+
+// allowed reports whether userID is one of the configured uploaders.
+func allowed(uploaders []string, userID uuid.UUID) bool {
+	for _, id := range uploaders {
+		if parsed, err := uuid.Parse(id); err == nil && parsed == userID {
+			return true
+		}
+	}
+	return false
+}
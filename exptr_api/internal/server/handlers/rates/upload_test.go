@@ -0,0 +1,67 @@
+package rates
+
+import (
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/logger/handlers/slogdiscard"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRateStore struct {
+	saved []domain.ExchangeRate
+}
+
+func (s *fakeRateStore) SaveExchangeRates(rates []domain.ExchangeRate) error {
+	s.saved = append(s.saved, rates...)
+	return nil
+}
+
+func TestUploadIsLimitedToUploaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	admin, user := uuid.New(), uuid.New()
+
+	cases := []struct {
+		name       string
+		userID     uuid.UUID
+		statusCode int
+		saved      int
+	}{
+		{"uploader", admin, http.StatusOK, 1},
+		{"other user", user, http.StatusForbidden, 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &fakeRateStore{}
+			router := gin.New()
+			router.POST("/rates/upload", func(c *gin.Context) {
+				c.Set(token.UserIDKey, tc.userID.String())
+			}, Upload(slogdiscard.NewDiscardLogger(), store, []string{admin.String()}))
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			fw, err := mw.CreateFormFile("file", "rates.csv")
+			require.NoError(t, err)
+			_, err = fw.Write([]byte("date,base,quote,rate\n2024-03-01,EUR,USD,1.0834\n"))
+			require.NoError(t, err)
+			require.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/rates/upload", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.statusCode, rr.Code)
+			require.Len(t, store.saved, tc.saved)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type PayeeReportHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
+	GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error)
+}
+
+// Payees godoc
+// @Summary      Get income and expense totals per payee in the user's base currency
+// @Description  Converts every operation with the exchange rate on its date and sums it per payee, those spent most with first
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {object}  models.GetPayeeReportResponse
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /reports/payees [get]
+func Payees(log *slog.Logger, payeeReportHandler PayeeReportHandler, converter report.Converter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.reports.payees.Payees"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		user, err := payeeReportHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get user"))
+			return
+		}
+
+		operations, err := payeeReportHandler.GetOperations(userID, filter)
+		if err != nil {
+			log.Error("failed to get operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get operations"))
+			return
+		}
+
+		usage, err := payeeReportHandler.GetPayees(userID)
+		if err != nil {
+			log.Error("failed to get payees", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get payees"))
+			return
+		}
+		payees := make([]domain.Payee, 0, len(usage))
+		for _, u := range usage {
+			payees = append(payees, u.Payee)
+		}
+
+		payeeReport, err := report.BuildPayees(operations, payees, user.BaseCurrency, converter)
+		if err != nil {
+			log.Error("failed to build report", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to build report"))
+			return
+		}
+
+		log.Info("payee report built", slog.Int("payees", len(payeeReport.Payees)))
+		render.JSON(w, r, models.GetPayeeReportResponse{
+			Response: response.OK(),
+			Report:   payeeReport,
+		})
+	}
+}
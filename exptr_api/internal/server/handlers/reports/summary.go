@@ -0,0 +1,113 @@
+package reports
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/report"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type SummaryReportHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
+	GetCategories(userID uuid.UUID) ([]domain.Category, error)
+}
+
+// Summary godoc
+// @Summary      Get income and expense totals in the user's base currency
+// @Description  Converts every operation with the exchange rate on its date and sums it per category
+// @Tags         reports
+// @Accept       json
+// @Produce      json
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        type query string false "income or expense"
+// @Param        category_id query string false "comma separated category ids"
+// @Param        tag_id query string false "comma separated tag ids"
+// @Param        tag_match query string false "any (default) or all of tag_id"
+// @Param        payee_id query string false "comma separated payee ids"
+// @Success      200  {object}  models.GetReportResponse
+// @Failure      400  {string} 	string "invalid filter"
+// @Failure      500  {string}  string "server error"
+// @Router       /reports/summary [get]
+func Summary(log *slog.Logger, summaryReportHandler SummaryReportHandler, converter report.Converter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.reports.summary.Summary"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		user, err := summaryReportHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get user"))
+			return
+		}
+
+		operations, err := summaryReportHandler.GetOperations(userID, filter)
+		if err != nil {
+			log.Error("failed to get operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get operations"))
+			return
+		}
+
+		categories, err := summaryReportHandler.GetCategories(userID)
+		if err != nil {
+			log.Error("failed to get categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get categories"))
+			return
+		}
+
+		summary, err := report.Build(operations, categories, user.BaseCurrency, converter)
+		if err != nil {
+			log.Error("failed to build report", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to build report"))
+			return
+		}
+
+		log.Info("summary report built", slog.Int("operations", summary.Count))
+		render.JSON(w, r, models.GetReportResponse{
+			Response: response.OK(),
+			Report:   summary,
+		})
+	}
+}
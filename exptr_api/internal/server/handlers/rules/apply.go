@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type ApplyRuleHandler interface {
+	PreviewRuleHandler
+	ApplyRuleActions(userID uuid.UUID, actions domain.RuleActions, operationIDs []uuid.UUID) (int, error)
+}
+
+// Apply godoc
+// @Summary      Apply a rule retroactively
+// @Description  Applies the rule's actions to the stored operations it matches: sets their category, adds its tags and renames them. Split operations keep their category.
+// @Tags         rules
+// @Produce      json
+// @Param        id path string true "Rule ID"
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        category_id query string false "comma separated category ids"
+// @Success      200  {object}  models.ApplyRuleResponse
+// @Failure      404  {string} 	string "rule not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /rules/{id}/apply [post]
+func Apply(log *slog.Logger, applyRuleHandler ApplyRuleHandler, suggester *categorizer.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rules.apply.Apply"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		rule, err := applyRuleHandler.GetRule(userID, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("rule not found"))
+				return
+			}
+			log.Error("failed to get rule", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rule"))
+			return
+		}
+
+		operations, err := applyRuleHandler.GetOperations(userID, filter)
+		if err != nil {
+			log.Error("failed to get operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get operations"))
+			return
+		}
+
+		matched, err := match(*rule, operations)
+		if err != nil {
+			log.Error("invalid rule", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		ids := make([]uuid.UUID, 0, len(matched))
+		for _, op := range matched {
+			ids = append(ids, op.ID)
+		}
+
+		applied, err := applyRuleHandler.ApplyRuleActions(userID, rule.Actions, ids)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found"))
+				return
+			}
+			log.Error("failed to apply rule", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to apply rule"))
+			return
+		}
+
+		if suggester != nil && applied > 0 && rule.Actions.CategoryID != nil {
+			suggester.Reset(userID)
+		}
+
+		log.Info("rule applied", slog.String("id", id.String()), slog.Int("operations", applied))
+		render.JSON(w, r, models.ApplyRuleResponse{
+			Response:   response.OK(),
+			Operations: applied,
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type CreateRuleHandler interface {
+	CreateRule(rule *domain.Rule) error
+}
+
+// New godoc
+// @Summary      Create rule
+// @Description  Creates a categorization rule. Rules run by ascending priority on every created or imported operation; all conditions of a rule must match.
+// @Tags         rules
+// @Accept       json
+// @Produce      json
+// @Param        data body models.RuleRequest true "rule"
+// @Success      200  {object}  models.RuleResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "category not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /rules/new [post]
+func New(log *slog.Logger, createRuleHandler CreateRuleHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rules.create.New"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		rule, ok := decodeRule(log, c, userID)
+		if !ok {
+			return
+		}
+
+		if err := createRuleHandler.CreateRule(&rule); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found"))
+				return
+			}
+			log.Error("failed to create rule", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create rule"))
+			return
+		}
+
+		log.Info("rule created", slog.String("id", rule.ID.String()))
+		render.JSON(w, r, models.RuleResponse{
+			Response: response.OK(),
+			Rule:     &rule,
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetRulesHandler interface {
+	GetRules(userID uuid.UUID) ([]domain.Rule, error)
+}
+
+// GetAll godoc
+// @Summary      Get rules
+// @Description  Lists the current user's categorization rules in the order they run
+// @Tags         rules
+// @Produce      json
+// @Success      200  {object}  models.GetRulesResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /rules [get]
+func GetAll(log *slog.Logger, getRulesHandler GetRulesHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rules.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		rules, err := getRulesHandler.GetRules(userID)
+		if err != nil {
+			log.Error("failed to get rules", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get rules"))
+			return
+		}
+
+		log.Info("rules received", slog.Int("count", len(rules)))
+		render.JSON(w, r, models.GetRulesResponse{
+			Response: response.OK(),
+			Rules:    rules,
+		})
+	}
+}
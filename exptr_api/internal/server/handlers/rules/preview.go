@@ -0,0 +1,142 @@
+package rules
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/query"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type PreviewRuleHandler interface {
+	GetRule(userID, id uuid.UUID) (*domain.Rule, error)
+	GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error)
+}
+
+// Preview godoc
+// @Summary      Preview a rule
+// @Description  Lists the stored operations a rule would match without changing them. Without an id the rule is read from the body, so it can be tried before saving.
+// @Tags         rules
+// @Accept       json
+// @Produce      json
+// @Param        id path string false "Rule ID"
+// @Param        data body models.RuleRequest false "unsaved rule"
+// @Param        from query string false "start date, YYYY-MM-DD"
+// @Param        to query string false "end date inclusive, YYYY-MM-DD"
+// @Param        category_id query string false "comma separated category ids"
+// @Success      200  {object}  models.RulePreviewResponse
+// @Failure      400  {string} 	string "invalid rule"
+// @Failure      404  {string} 	string "rule not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /rules/preview [post]
+// @Router       /rules/{id}/preview [get]
+func Preview(log *slog.Logger, previewRuleHandler PreviewRuleHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rules.preview.Preview"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		filter, err := query.OperationFilter(c)
+		if err != nil {
+			log.Error("invalid filter", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		var rule domain.Rule
+		if param := c.Param("id"); param != "" {
+			id, err := uuid.Parse(param)
+			if err != nil {
+				log.Error("invalid id format", sl.Error(err))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("invalid id format"))
+				return
+			}
+			stored, err := previewRuleHandler.GetRule(userID, id)
+			if err != nil {
+				if errors.Is(err, storage.ErrItemNotFound) {
+					w.WriteHeader(http.StatusNotFound)
+					render.JSON(w, r, response.Error("rule not found"))
+					return
+				}
+				log.Error("failed to get rule", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to get rule"))
+				return
+			}
+			rule = *stored
+		} else if rule, ok = decodeRule(log, c, userID); !ok {
+			return
+		}
+
+		operations, err := previewRuleHandler.GetOperations(userID, filter)
+		if err != nil {
+			log.Error("failed to get operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get operations"))
+			return
+		}
+
+		matched, err := match(rule, operations)
+		if err != nil {
+			log.Error("invalid rule", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(err.Error()))
+			return
+		}
+
+		log.Info("rule previewed", slog.Int("operations", len(matched)))
+		render.JSON(w, r, models.RulePreviewResponse{
+			Response:   response.OK(),
+			Operations: matched,
+		})
+	}
+}
+
+// match returns the operations the rule matches, whether or not it is
+// enabled.
+func match(rule domain.Rule, operations []domain.Operation) ([]domain.Operation, error) {
+	rule.Enabled = true
+	set, err := rules.Compile([]domain.Rule{rule})
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]domain.Operation, 0)
+	for i := range operations {
+		if len(set.Evaluate(rules.FromOperation(&operations[i])).Matched) > 0 {
+			matched = append(matched, operations[i])
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/rules"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+// decodeRule reads a rule of userID from the request body and checks it,
+// writing the error response itself when that fails.
+func decodeRule(log *slog.Logger, c *gin.Context, userID uuid.UUID) (domain.Rule, bool) {
+	r := c.Request
+	w := c.Writer
+
+	var req models.RuleRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("empty request body")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("empty request body"))
+		return domain.Rule{}, false
+	}
+
+	if err != nil {
+		log.Error("failed to decode request", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request"))
+		return domain.Rule{}, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+		log.Error("validation failed", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(validateErr.Error()))
+		return domain.Rule{}, false
+	}
+
+	rule := domain.Rule{
+		UserID:     userID,
+		Name:       req.Name,
+		Priority:   req.Priority,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+		Stop:       req.Stop,
+		Conditions: req.Conditions,
+		Actions:    req.Actions,
+	}
+
+	if err := rules.Check(rule); err != nil {
+		log.Error("invalid rule", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(err.Error()))
+		return domain.Rule{}, false
+	}
+
+	return rule, true
+}
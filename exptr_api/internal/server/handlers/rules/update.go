@@ -0,0 +1,93 @@
+package rules
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type UpdateRuleHandler interface {
+	UpdateRule(rule *domain.Rule) error
+}
+
+// Update godoc
+// @Summary      Update rule
+// @Description  Replaces the settings of a rule
+// @Tags         rules
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Rule ID"
+// @Param        data body models.RuleRequest true "rule"
+// @Success      200  {object}  models.RuleResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "rule not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /rules/{id} [put]
+func Update(log *slog.Logger, updateRuleHandler UpdateRuleHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.rules.update.Update"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		rule, ok := decodeRule(log, c, userID)
+		if !ok {
+			return
+		}
+		rule.ID = id
+
+		if err := updateRuleHandler.UpdateRule(&rule); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("rule or category not found"))
+				return
+			}
+			log.Error("failed to update rule", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to update rule"))
+			return
+		}
+
+		log.Info("rule updated", slog.String("id", id.String()))
+		render.JSON(w, r, models.RuleResponse{
+			Response: response.OK(),
+			Rule:     &rule,
+		})
+	}
+}
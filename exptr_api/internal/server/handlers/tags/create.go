@@ -0,0 +1,132 @@
+package tags
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type CreateTagHandler interface {
+	CreateTag(tag *domain.Tag) error
+}
+
+// New godoc
+// @Summary      Create tag
+// @Description  Creates a tag. Names are unique per user regardless of case.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        data body models.TagRequest true "tag"
+// @Success      200  {object}  models.TagResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      409  {string} 	string "tag already exists"
+// @Failure      500  {string}  string "server error"
+// @Router       /tags/new [post]
+func New(log *slog.Logger, createTagHandler CreateTagHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.tags.create.New"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		req, ok := decodeTagRequest(log, c)
+		if !ok {
+			return
+		}
+
+		tag := &domain.Tag{
+			UserID: userID,
+			Name:   req.Name,
+			Color:  req.Color,
+		}
+
+		if err := createTagHandler.CreateTag(tag); err != nil {
+			if errors.Is(err, storage.ErrTagExists) {
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error("tag already exists"))
+				return
+			}
+			log.Error("failed to create tag", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to create tag"))
+			return
+		}
+
+		log.Info("tag created", slog.String("id", tag.ID.String()))
+		render.JSON(w, r, models.TagResponse{
+			Response: response.OK(),
+			Tag:      tag,
+		})
+	}
+}
+
+// decodeTagRequest reads and validates a tag from the request body, writing
+// the error response itself when it fails.
+func decodeTagRequest(log *slog.Logger, c *gin.Context) (models.TagRequest, bool) {
+	r := c.Request
+	w := c.Writer
+
+	var req models.TagRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if errors.Is(err, io.EOF) {
+		log.Error("empty request body")
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("empty request body"))
+		return req, false
+	}
+
+	if err != nil {
+		log.Error("failed to decode request", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("failed to decode request"))
+		return req, false
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		validateErr := err.(validator.ValidationErrors)
+		log.Error("validation failed", sl.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error(validateErr.Error()))
+		return req, false
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		render.JSON(w, r, response.Error("tag name is empty"))
+		return req, false
+	}
+
+	return req, true
+}
@@ -0,0 +1,79 @@
+package tags
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type DeleteTagHandler interface {
+	DeleteTag(userID, id uuid.UUID) error
+}
+
+// Delete godoc
+// @Summary      Delete tag
+// @Description  Deletes a tag and removes it from every operation. The operations themselves are kept.
+// @Tags         tags
+// @Produce      json
+// @Param        id path string true "Tag ID"
+// @Success      200  {object}  response.Response
+// @Failure      404  {string} 	string "tag not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /tags/{id} [delete]
+func Delete(log *slog.Logger, deleteTagHandler DeleteTagHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.tags.delete.Delete"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		if err := deleteTagHandler.DeleteTag(userID, id); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("tag not found"))
+				return
+			}
+			log.Error("failed to delete tag", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to delete tag"))
+			return
+		}
+
+		log.Info("tag deleted", slog.String("id", id.String()))
+		render.JSON(w, r, response.OK())
+	}
+}
@@ -0,0 +1,67 @@
+package tags
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetTagsHandler interface {
+	GetTags(userID uuid.UUID) ([]models.TagUsage, error)
+}
+
+// GetAll godoc
+// @Summary      Get tags
+// @Description  Lists the current user's tags by name with the number of operations carrying each
+// @Tags         tags
+// @Produce      json
+// @Success      200  {object}  models.GetTagsResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /tags [get]
+func GetAll(log *slog.Logger, getTagsHandler GetTagsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.tags.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		tags, err := getTagsHandler.GetTags(userID)
+		if err != nil {
+			log.Error("failed to get tags", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get tags"))
+			return
+		}
+
+		log.Info("tags received", slog.Int("count", len(tags)))
+		render.JSON(w, r, models.GetTagsResponse{
+			Response: response.OK(),
+			Tags:     tags,
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package tags
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type MergeTagsHandler interface {
+	MergeTags(userID, id, targetID uuid.UUID) (int, error)
+}
+
+// Merge godoc
+// @Summary      Merge a tag into another one
+// @Description  Tags every operation carrying the tag with the target tag instead, then deletes it
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Tag ID"
+// @Param        data body models.MergeTagsRequest true "target tag"
+// @Success      200  {object}  models.MergeTagsResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "tag not found"
+// @Failure      500  {string}  string "server error"
+// @Router       /tags/{id}/merge [post]
+func Merge(log *slog.Logger, mergeTagsHandler MergeTagsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.tags.merge.Merge"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		var req models.MergeTagsRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		if req.TargetID == id {
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("cannot merge a tag into itself"))
+			return
+		}
+
+		moved, err := mergeTagsHandler.MergeTags(userID, id, req.TargetID)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("tag not found"))
+				return
+			}
+			log.Error("failed to merge tags", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to merge tags"))
+			return
+		}
+
+		log.Info("tags merged", slog.String("source", id.String()),
+			slog.String("target", req.TargetID.String()), slog.Int("operations", moved))
+		render.JSON(w, r, models.MergeTagsResponse{
+			Response:   response.OK(),
+			Operations: moved,
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package tags
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type UpdateTagHandler interface {
+	UpdateTag(userID, id uuid.UUID, name, color string) (*domain.Tag, error)
+}
+
+// Update godoc
+// @Summary      Rename tag
+// @Description  Renames or recolors a tag. Renaming it to the name of another tag is refused; merge the tags instead.
+// @Tags         tags
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Tag ID"
+// @Param        data body models.TagRequest true "tag"
+// @Success      200  {object}  models.TagResponse
+// @Failure      400  {string} 	string "empty request body"
+// @Failure      404  {string} 	string "tag not found"
+// @Failure      409  {string} 	string "tag already exists"
+// @Failure      500  {string}  string "server error"
+// @Router       /tags/{id} [put]
+func Update(log *slog.Logger, updateTagHandler UpdateTagHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.tags.update.Update"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		req, ok := decodeTagRequest(log, c)
+		if !ok {
+			return
+		}
+
+		tag, err := updateTagHandler.UpdateTag(userID, id, req.Name, req.Color)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrItemNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("tag not found"))
+			case errors.Is(err, storage.ErrTagExists):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error("tag already exists"))
+			default:
+				log.Error("failed to update tag", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to update tag"))
+			}
+			return
+		}
+
+		log.Info("tag updated", slog.String("id", id.String()))
+		render.JSON(w, r, models.TagResponse{
+			Response: response.OK(),
+			Tag:      tag,
+		})
+	}
+}
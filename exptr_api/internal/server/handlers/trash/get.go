@@ -0,0 +1,77 @@
+package trash
+
+import (
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type GetTrashHandler interface {
+	GetDeletedOperations(userID uuid.UUID) ([]domain.Operation, error)
+	GetDeletedCategories(userID uuid.UUID) ([]domain.Category, error)
+}
+
+// GetAll godoc
+// @Summary      List deleted operations and categories
+// @Description  Soft-deleted items of the current user, most recently deleted first. Items stay here until the retention period purges them.
+// @Tags         trash
+// @Produce      json
+// @Success      200  {object}  models.GetTrashResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /trash [get]
+func GetAll(log *slog.Logger, getTrashHandler GetTrashHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.get.GetAll"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		operations, err := getTrashHandler.GetDeletedOperations(userID)
+		if err != nil {
+			log.Error("failed to get deleted operations", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get trash"))
+			return
+		}
+
+		categories, err := getTrashHandler.GetDeletedCategories(userID)
+		if err != nil {
+			log.Error("failed to get deleted categories", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get trash"))
+			return
+		}
+
+		render.JSON(w, r, models.GetTrashResponse{
+			Response:   response.OK(),
+			Operations: operations,
+			Categories: categories,
+		})
+	}
+}
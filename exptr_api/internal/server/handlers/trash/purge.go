@@ -0,0 +1,211 @@
+package trash
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type PurgeOperationHandler interface {
+	PurgeOperation(userID, id uuid.UUID) error
+}
+
+type PurgeCategoryHandler interface {
+	PurgeCategory(userID, id uuid.UUID) (int, error)
+}
+
+type EmptyTrashHandler interface {
+	EmptyTrash(userID uuid.UUID) (operations, categories int, err error)
+}
+
+// PurgeOperation godoc
+// @Summary      Permanently delete an operation from the trash
+// @Description  Permanently delete an operation from the trash
+// @Tags         trash
+// @Produce      json
+// @Param        id path string true "Operation ID"
+// @Success      200  {object}  models.PurgeTrashResponse
+// @Failure      400  {string} 	string "invalid id format"
+// @Failure      404  {string} 	string "operation not found in trash"
+// @Failure      500  {string}  string "server error"
+// @Router       /trash/operations/{id} [delete]
+func PurgeOperation(log *slog.Logger, purgeOperationHandler PurgeOperationHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.purge.PurgeOperation"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		if err := purgeOperationHandler.PurgeOperation(userID, id); err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("operation not found in trash"))
+				return
+			}
+			log.Error("failed to purge operation", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to purge operation"))
+			return
+		}
+
+		log.Info("operation purged", slog.String("id", id.String()))
+		render.JSON(w, r, models.PurgeTrashResponse{
+			Response:   response.OK(),
+			Operations: 1,
+		})
+	}
+}
+
+// PurgeCategory godoc
+// @Summary      Permanently delete a category from the trash
+// @Description  Permanently deletes the category and its deleted operations. Refused while live operations still use the category.
+// @Tags         trash
+// @Produce      json
+// @Param        id path string true "Category ID"
+// @Success      200  {object}  models.PurgeTrashResponse
+// @Failure      400  {string} 	string "invalid id format"
+// @Failure      404  {string} 	string "category not found in trash"
+// @Failure      409  {string} 	string "category is in use"
+// @Failure      500  {string}  string "server error"
+// @Router       /trash/categories/{id} [delete]
+func PurgeCategory(log *slog.Logger, purgeCategoryHandler PurgeCategoryHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.purge.PurgeCategory"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		operations, err := purgeCategoryHandler.PurgeCategory(userID, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrItemNotFound):
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found in trash"))
+			case errors.Is(err, storage.ErrCategoryInUse):
+				w.WriteHeader(http.StatusConflict)
+				render.JSON(w, r, response.Error("category is in use"))
+			default:
+				log.Error("failed to purge category", sl.Error(err))
+				w.WriteHeader(http.StatusInternalServerError)
+				render.JSON(w, r, response.Error("failed to purge category"))
+			}
+			return
+		}
+
+		log.Info("category purged", slog.String("id", id.String()), slog.Int("operations", operations))
+		render.JSON(w, r, models.PurgeTrashResponse{
+			Response:   response.OK(),
+			Operations: operations,
+			Categories: 1,
+		})
+	}
+}
+
+// Empty godoc
+// @Summary      Empty the trash
+// @Description  Permanently deletes all deleted operations and every deleted category no live operation uses
+// @Tags         trash
+// @Produce      json
+// @Success      200  {object}  models.PurgeTrashResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /trash [delete]
+func Empty(log *slog.Logger, emptyTrashHandler EmptyTrashHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.purge.Empty"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		operations, categories, err := emptyTrashHandler.EmptyTrash(userID)
+		if err != nil {
+			log.Error("failed to empty trash", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to empty trash"))
+			return
+		}
+
+		log.Info("trash emptied", slog.Int("operations", operations), slog.Int("categories", categories))
+		render.JSON(w, r, models.PurgeTrashResponse{
+			Response:   response.OK(),
+			Operations: operations,
+			Categories: categories,
+		})
+	}
+}
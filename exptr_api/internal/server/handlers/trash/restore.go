@@ -0,0 +1,159 @@
+package trash
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/google/uuid"
+)
+
+type RestoreOperationHandler interface {
+	RestoreOperation(userID, id uuid.UUID) (*domain.Operation, error)
+}
+
+type RestoreCategoryHandler interface {
+	RestoreCategory(userID, id uuid.UUID, withOperations bool) (*domain.Category, int, error)
+}
+
+// RestoreOperation godoc
+// @Summary      Restore a deleted operation
+// @Description  Restores the operation and, if it was deleted too, its category
+// @Tags         trash
+// @Produce      json
+// @Param        id path string true "Operation ID"
+// @Success      200  {object}  models.RestoreOperationResponse
+// @Failure      400  {string} 	string "invalid id format"
+// @Failure      404  {string} 	string "operation not found in trash"
+// @Failure      500  {string}  string "server error"
+// @Router       /trash/operations/{id}/restore [post]
+func RestoreOperation(log *slog.Logger, restoreOperationHandler RestoreOperationHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.restore.RestoreOperation"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		operation, err := restoreOperationHandler.RestoreOperation(userID, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("operation not found in trash"))
+				return
+			}
+			log.Error("failed to restore operation", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to restore operation"))
+			return
+		}
+
+		log.Info("operation restored", slog.String("id", id.String()))
+		render.JSON(w, r, models.RestoreOperationResponse{
+			Response:  response.OK(),
+			Operation: operation,
+		})
+	}
+}
+
+// RestoreCategory godoc
+// @Summary      Restore a deleted category
+// @Description  Restores the category. With operations=true the operations deleted together with it are restored as well.
+// @Tags         trash
+// @Produce      json
+// @Param        id path string true "Category ID"
+// @Param        operations query bool false "also restore operations deleted with the category"
+// @Success      200  {object}  models.RestoreCategoryResponse
+// @Failure      400  {string} 	string "invalid id format"
+// @Failure      404  {string} 	string "category not found in trash"
+// @Failure      500  {string}  string "server error"
+// @Router       /trash/categories/{id}/restore [post]
+func RestoreCategory(log *slog.Logger, restoreCategoryHandler RestoreCategoryHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const fn = "handlers.trash.restore.RestoreCategory"
+		log := log.With(slog.String("fn", fn))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		id, err := uuid.Parse(c.Param("id"))
+		if err != nil {
+			log.Error("invalid id format", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("invalid id format"))
+			return
+		}
+
+		withOperations, _ := strconv.ParseBool(c.Query("operations"))
+
+		category, restored, err := restoreCategoryHandler.RestoreCategory(userID, id, withOperations)
+		if err != nil {
+			if errors.Is(err, storage.ErrItemNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				render.JSON(w, r, response.Error("category not found in trash"))
+				return
+			}
+			log.Error("failed to restore category", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to restore category"))
+			return
+		}
+
+		log.Info("category restored", slog.String("id", id.String()), slog.Int("operations", restored))
+		render.JSON(w, r, models.RestoreCategoryResponse{
+			Response:   response.OK(),
+			Category:   category,
+			Operations: restored,
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package users
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/api/response"
+	"alex_gorbunov_exptr_api/internal/lib/currency"
+	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/server/middleware/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator"
+	"github.com/google/uuid"
+)
+
+type GetSettingsHandler interface {
+	GetUserByID(id uuid.UUID) (*domain.User, error)
+}
+
+type UpdateSettingsHandler interface {
+	UpdateUserBaseCurrency(id uuid.UUID, currency string) error
+}
+
+// GetSettings godoc
+// @Summary      Get current user settings
+// @Description  Get current user settings
+// @Tags         users
+// @Produce      json
+// @Success      200  {object}  models.UserSettingsResponse
+// @Failure      500  {string}  string "server error"
+// @Router       /users/settings [get]
+func GetSettings(log *slog.Logger, getSettingsHandler GetSettingsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.users.settings.GetSettings"
+		log := log.With(slog.String("op", op))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		user, err := getSettingsHandler.GetUserByID(userID)
+		if err != nil {
+			log.Error("failed to get user", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to get settings"))
+			return
+		}
+
+		render.JSON(w, r, models.UserSettingsResponse{
+			Response: response.OK(),
+			Settings: models.UserSettings{BaseCurrency: user.BaseCurrency},
+		})
+	}
+}
+
+// UpdateSettings godoc
+// @Summary      Update current user settings
+// @Description  Update current user settings such as the reporting base currency
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        data body models.UserSettings true "settings"
+// @Success      200  {object}  models.UserSettingsResponse
+// @Failure      400  {string}  string "unknown currency"
+// @Failure      500  {string}  string "server error"
+// @Router       /users/settings [put]
+func UpdateSettings(log *slog.Logger, updateSettingsHandler UpdateSettingsHandler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		const op = "handlers.users.settings.UpdateSettings"
+		log := log.With(slog.String("op", op))
+
+		r := c.Request
+		w := c.Writer
+
+		userIDStr, ok := token.GetUserIDFromContext(c)
+		if !ok {
+			log.Error("failed to get user id from context")
+			w.WriteHeader(http.StatusUnauthorized)
+			render.JSON(w, r, response.Error("unauthorized"))
+			return
+		}
+
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			log.Error("failed to parse user id", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("server error"))
+			return
+		}
+
+		var req models.UserSettings
+
+		err = render.DecodeJSON(r.Body, &req)
+
+		if errors.Is(err, io.EOF) {
+			log.Error("empty request body")
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("empty request body"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("validation failed", sl.Error(err))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error(validateErr.Error()))
+			return
+		}
+
+		req.BaseCurrency = currency.Normalize(req.BaseCurrency)
+		if !currency.IsValid(req.BaseCurrency) {
+			log.Error("unknown currency", slog.String("currency", req.BaseCurrency))
+			w.WriteHeader(http.StatusBadRequest)
+			render.JSON(w, r, response.Error("unknown currency"))
+			return
+		}
+
+		if err := updateSettingsHandler.UpdateUserBaseCurrency(userID, req.BaseCurrency); err != nil {
+			log.Error("failed to update settings", sl.Error(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			render.JSON(w, r, response.Error("failed to update settings"))
+			return
+		}
+
+		log.Info("settings updated")
+		render.JSON(w, r, models.UserSettingsResponse{
+			Response: response.OK(),
+			Settings: req,
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/lib/api/response"
 	"alex_gorbunov_exptr_api/internal/lib/logger/sl"
+	"alex_gorbunov_exptr_api/internal/lib/templates"
 	"alex_gorbunov_exptr_api/internal/models"
 	"alex_gorbunov_exptr_api/pkg/hasher"
 
@@ -16,16 +17,17 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator"
+	"github.com/google/uuid"
 )
 
 type SignupHandler interface {
-	CreateUser(user *domain.User) error
+	CreateUserWithCategories(user *domain.User, categories []domain.Category) error
 	GetUserByEmail(email string) (*domain.User, error)
 }
 
 // Signup godoc
 // @Summary      Signup
-// @Description  Signup
+// @Description  Creates an account seeded with the categories of a template, "basic" unless another one or "none" is chosen
 // @Tags         users
 // @Accept       json
 // @Produce      json
@@ -73,6 +75,20 @@ func Signup(log *slog.Logger, signupHandler SignupHandler) gin.HandlerFunc {
 			return
 		}
 
+		if req.Template == "" {
+			req.Template = templates.Default
+		}
+		var template *templates.Template
+		if req.Template != templates.None {
+			var ok bool
+			if template, ok = templates.Get(req.Template); !ok {
+				log.Error("unknown category template", slog.String("template", req.Template))
+				w.WriteHeader(http.StatusBadRequest)
+				render.JSON(w, r, response.Error("unknown category template"))
+				return
+			}
+		}
+
 		_, err = signupHandler.GetUserByEmail(req.Email)
 		if err == nil {
 			log.Error("user with this email already exists")
@@ -90,11 +106,21 @@ func Signup(log *slog.Logger, signupHandler SignupHandler) gin.HandlerFunc {
 		}
 
 		user := &domain.User{
-			Email:    req.Email,
-			Password: passwordHash,
+			BaseEntity: domain.BaseEntity{ID: uuid.New()},
+			Email:      req.Email,
+			Password:   passwordHash,
+		}
+
+		var categories []domain.Category
+		if template != nil {
+			locale := req.Locale
+			if locale == "" {
+				locale = templates.Locale(r.Header.Get("Accept-Language"))
+			}
+			categories = template.Build(user.ID, locale)
 		}
 
-		err = signupHandler.CreateUser(user)
+		err = signupHandler.CreateUserWithCategories(user, categories)
 
 		if err != nil {
 			log.Error("failed to create user", sl.Error(err))
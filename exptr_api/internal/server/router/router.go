@@ -5,8 +5,24 @@ import (
 	"net/http"
 
 	_ "alex_gorbunov_exptr_api/docs"
+	"alex_gorbunov_exptr_api/internal/config"
+	"alex_gorbunov_exptr_api/internal/lib/blob"
+	"alex_gorbunov_exptr_api/internal/lib/categorizer"
+	"alex_gorbunov_exptr_api/internal/lib/rates"
+	"alex_gorbunov_exptr_api/internal/server/handlers/account"
+	"alex_gorbunov_exptr_api/internal/server/handlers/attachments"
 	"alex_gorbunov_exptr_api/internal/server/handlers/categories"
+	"alex_gorbunov_exptr_api/internal/server/handlers/duplicates"
+	"alex_gorbunov_exptr_api/internal/server/handlers/importprofiles"
+	"alex_gorbunov_exptr_api/internal/server/handlers/imports"
+	"alex_gorbunov_exptr_api/internal/server/handlers/items"
 	"alex_gorbunov_exptr_api/internal/server/handlers/operations"
+	"alex_gorbunov_exptr_api/internal/server/handlers/payees"
+	ratesHandlers "alex_gorbunov_exptr_api/internal/server/handlers/rates"
+	"alex_gorbunov_exptr_api/internal/server/handlers/reports"
+	"alex_gorbunov_exptr_api/internal/server/handlers/rules"
+	"alex_gorbunov_exptr_api/internal/server/handlers/tags"
+	"alex_gorbunov_exptr_api/internal/server/handlers/trash"
 	"alex_gorbunov_exptr_api/internal/server/handlers/users"
 	mLogger "alex_gorbunov_exptr_api/internal/server/middleware/logger"
 	"alex_gorbunov_exptr_api/internal/server/middleware/token"
@@ -18,9 +34,12 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-func Router(log *slog.Logger, storage *postgres.Storage) http.Handler {
+func Router(log *slog.Logger, storage *postgres.Storage, blobs blob.Store, cfg *config.Config) http.Handler {
 	router := gin.Default()
 
+	converter := rates.NewConverter(storage)
+	suggester := newSuggester(cfg.Categorizer, storage)
+
 	router.Use(mLogger.New(log))
 	router.Use(gin.Recovery())
 	router.Use(cors.Default())
@@ -32,19 +51,109 @@ func Router(log *slog.Logger, storage *postgres.Storage) http.Handler {
 		auth := v1.Group("/")
 		auth.Use(token.TokenValidationMiddleware(log, storage))
 		{
-			auth.POST("/operations/new", operations.New(log, storage))
+			auth.POST("/operations/new", operations.New(log, storage, suggester))
+			auth.POST("/operations/suggest-category", operations.SuggestCategory(log, storage, suggester))
+			auth.POST("/operations/quick", operations.Quick(log, storage, suggester))
 			auth.GET("/operations", operations.GetAll(log, storage))
-			auth.PUT("/operations/:id", operations.Update(log, storage))
+			auth.GET("/operations/export", operations.Export(log, storage))
+			auth.PUT("/operations/:id", operations.Update(log, storage, suggester))
 			auth.DELETE("/operations/:id", operations.Delete(log, storage))
+			auth.GET("/operations/duplicates", duplicates.GetAll(log, storage))
+			auth.POST("/operations/duplicates/merge", duplicates.Merge(log, storage))
+			auth.POST("/operations/duplicates/dismiss", duplicates.Dismiss(log, storage))
+			auth.GET("/operations/:id/attachments", attachments.GetAll(log, storage))
+			auth.POST("/operations/:id/attachments", attachments.Create(log, storage, blobs, cfg.Attachments.MaxSize))
+
+			auth.GET("/items", items.Search(log, storage))
+			auth.GET("/items/prices", items.Prices(log, storage, converter))
+
+			auth.GET("/attachments/:id", attachments.Download(log, storage, blobs))
+			auth.GET("/attachments/:id/thumbnail", attachments.Thumbnail(log, storage, blobs))
+			auth.DELETE("/attachments/:id", attachments.Delete(log, storage))
 
 			auth.GET("/categories", categories.GetAll(log, storage))
 			auth.POST("/categories/new", categories.New(log, storage))
 			auth.PUT("/categories/:id", categories.Update(log, storage))
-			auth.DELETE("/categories/:id", categories.Delete(log, storage))
+			auth.DELETE("/categories/:id", categories.Delete(log, storage, suggester))
+			auth.POST("/categories/:id/merge", categories.Merge(log, storage, suggester))
+			auth.PUT("/categories/:id/parent", categories.Move(log, storage))
+			auth.POST("/categories/templates/:id/apply", categories.ApplyTemplate(log, storage))
+
+			auth.GET("/rules", rules.GetAll(log, storage))
+			auth.POST("/rules/new", rules.New(log, storage))
+			auth.POST("/rules/preview", rules.Preview(log, storage))
+			auth.PUT("/rules/:id", rules.Update(log, storage))
+			auth.DELETE("/rules/:id", rules.Delete(log, storage))
+			auth.GET("/rules/:id/preview", rules.Preview(log, storage))
+			auth.POST("/rules/:id/apply", rules.Apply(log, storage, suggester))
+
+			auth.GET("/tags", tags.GetAll(log, storage))
+			auth.POST("/tags/new", tags.New(log, storage))
+			auth.PUT("/tags/:id", tags.Update(log, storage))
+			auth.DELETE("/tags/:id", tags.Delete(log, storage))
+			auth.POST("/tags/:id/merge", tags.Merge(log, storage))
+
+			auth.GET("/payees", payees.GetAll(log, storage))
+			auth.POST("/payees/new", payees.New(log, storage))
+			auth.POST("/payees/detect", payees.Detect(log, storage))
+			auth.PUT("/payees/:id", payees.Update(log, storage))
+			auth.DELETE("/payees/:id", payees.Delete(log, storage))
+			auth.POST("/payees/:id/merge", payees.Merge(log, storage))
+
+			auth.GET("/trash", trash.GetAll(log, storage))
+			auth.DELETE("/trash", trash.Empty(log, storage))
+			auth.POST("/trash/operations/:id/restore", trash.RestoreOperation(log, storage))
+			auth.DELETE("/trash/operations/:id", trash.PurgeOperation(log, storage))
+			auth.POST("/trash/categories/:id/restore", trash.RestoreCategory(log, storage))
+			auth.DELETE("/trash/categories/:id", trash.PurgeCategory(log, storage))
+
+			auth.POST("/imports", imports.New(log, storage, suggester))
+			auth.GET("/imports/profiles", importprofiles.GetAll(log, storage))
+			auth.POST("/imports/profiles/new", importprofiles.New(log, storage))
+			auth.PUT("/imports/profiles/:id", importprofiles.Update(log, storage))
+			auth.DELETE("/imports/profiles/:id", importprofiles.Delete(log, storage))
+
+			auth.GET("/reports/summary", reports.Summary(log, storage, converter))
+			auth.GET("/reports/fx", reports.FX(log, storage, converter))
+			auth.GET("/reports/payees", reports.Payees(log, storage, converter))
+
+			auth.GET("/rates", ratesHandlers.GetAll(log, storage))
+			auth.POST("/rates/upload", ratesHandlers.Upload(log, storage, cfg.Rates.Uploaders))
+
+			auth.GET("/account/export", account.Export(log, storage, blobs))
+			auth.POST("/account/import", account.Import(log, storage, blobs, cfg.Attachments.MaxSize))
+			auth.GET("/account/deletion", account.GetDeletion(log, storage))
+			auth.POST("/account/deletion", account.RequestDeletion(log, storage, cfg.Accounts.DeletionGracePeriod))
+			auth.DELETE("/account/deletion", account.CancelDeletion(log, storage))
+
+			auth.GET("/users/settings", users.GetSettings(log, storage))
+			auth.PUT("/users/settings", users.UpdateSettings(log, storage))
 		}
+		v1.GET("/currencies", ratesHandlers.Currencies())
+		v1.GET("/categories/templates", categories.Templates())
 		v1.POST("/users/signup", users.Signup(log, storage))
 		v1.POST("/users/login", users.Login(log, storage))
 	}
 
 	return router
 }
+
+// newSuggester returns a category suggester asking the configured model and,
+// unless disabled, a classifier trained on each user's history.
+func newSuggester(cfg config.Categorizer, storage *postgres.Storage) *categorizer.Service {
+	var model categorizer.Model
+	if cfg.Provider == "ollama" {
+		model = categorizer.Ollama{URL: cfg.URL, Model: cfg.Model}
+	}
+	var learner *categorizer.Learner
+	if cfg.Learn {
+		learner = categorizer.NewLearner(storage, categorizer.LearnerOptions{MaxAge: cfg.LearnMaxAge})
+	}
+	return categorizer.New(model, categorizer.Options{
+		Learner:       learner,
+		MinConfidence: cfg.MinConfidence,
+		Timeout:       cfg.Timeout,
+		CacheTTL:      cfg.CacheTTL,
+		Cooldown:      cfg.Cooldown,
+	})
+}
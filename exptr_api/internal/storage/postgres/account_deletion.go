@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequestAccountDeletion schedules the account to be purged after deleteAfter.
+// Asking again while a request is pending keeps the original schedule.
+func (s *Storage) RequestAccountDeletion(userID uuid.UUID, deleteAfter time.Time) (*domain.User, error) {
+	const fn = "storage.postgresql.RequestAccountDeletion"
+
+	result := s.db.Model(&domain.User{}).
+		Where("id = ? AND delete_after IS NULL", userID).
+		Updates(map[string]interface{}{
+			"deletion_requested_at": time.Now(),
+			"delete_after":          deleteAfter,
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	var user domain.User
+	if err := s.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &user, nil
+}
+
+// CancelAccountDeletion withdraws a pending deletion request.
+func (s *Storage) CancelAccountDeletion(userID uuid.UUID) error {
+	const fn = "storage.postgresql.CancelAccountDeletion"
+
+	result := s.db.Model(&domain.User{}).
+		Where("id = ? AND delete_after IS NOT NULL", userID).
+		Updates(map[string]interface{}{
+			"deletion_requested_at": nil,
+			"delete_after":          nil,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// GetAccountsDueForDeletion returns the ids of accounts whose grace period
+// ended before now, including accounts that were soft-deleted meanwhile.
+func (s *Storage) GetAccountsDueForDeletion(now time.Time) ([]uuid.UUID, error) {
+	const fn = "storage.postgresql.GetAccountsDueForDeletion"
+
+	var ids []uuid.UUID
+	result := s.db.Unscoped().Model(&domain.User{}).
+		Where("delete_after IS NOT NULL AND delete_after <= ?", now).
+		Order("delete_after").
+		Pluck("id", &ids)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return ids, nil
+}
+
+// PurgeAccount hard-deletes the user and everything they own in one
+// transaction and leaves an AccountTombstone behind. The account is only
+// purged if its deletion is still due, so a request cancelled after the
+// purge job listed it survives.
+func (s *Storage) PurgeAccount(userID uuid.UUID, now time.Time) (*domain.AccountTombstone, error) {
+	const fn = "storage.postgresql.PurgeAccount"
+
+	var tombstone domain.AccountTombstone
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped()
+
+		var user domain.User
+		err := tx.Where("id = ? AND delete_after IS NOT NULL AND delete_after <= ?", userID, now).
+			First(&user).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return storage.ErrItemNotFound
+			}
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.DuplicateDismissal{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Rule{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Attachment{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ?", userID).Delete(&domain.Operation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		tombstone.Operations = int(result.RowsAffected)
+
+		result = tx.Where("user_id = ?", userID).Delete(&domain.Category{})
+		if result.Error != nil {
+			return result.Error
+		}
+		tombstone.Categories = int(result.RowsAffected)
+
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Tag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.PayeeAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&domain.Payee{}).Error; err != nil {
+			return err
+		}
+
+		result = tx.Where("user_id = ?", userID).Delete(&domain.ImportProfile{})
+		if result.Error != nil {
+			return result.Error
+		}
+		tombstone.ImportProfiles = int(result.RowsAffected)
+
+		result = tx.Where("user_id = ?", userID).Delete(&domain.UserSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		tombstone.Sessions = int(result.RowsAffected)
+
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+
+		tombstone.UserID = user.ID
+		tombstone.EmailHash = EmailHash(user.Email)
+		tombstone.RequestedAt = user.DeletionRequestedAt
+		return tx.Create(&tombstone).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tombstone, nil
+}
+
+// EmailHash is the hex SHA-256 of a normalized email as kept in tombstones.
+func EmailHash(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/archive"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExportAccount collects everything the user owns for an account archive.
+// The attached files themselves are left to the caller, which knows the
+// blob store.
+func (s *Storage) ExportAccount(userID uuid.UUID) (*archive.Archive, error) {
+	const fn = "storage.postgresql.ExportAccount"
+
+	var a archive.Archive
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user domain.User
+		if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+		a.User = archive.User{
+			ID:           user.ID.String(),
+			Email:        user.Email,
+			BaseCurrency: user.BaseCurrency,
+			CreatedAt:    user.CreatedAt,
+		}
+
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&a.Categories).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("Tags").Preload("Splits", orderSplits).Preload("Items", orderItems).Where("user_id = ?", userID).Order("created_at").Find(&a.Operations).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&a.Tags).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("created_at").Find(&a.ImportProfiles).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Order("priority, created_at").Find(&a.Rules).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("Aliases").Where("user_id = ?", userID).Order("created_at").Find(&a.Payees).Error; err != nil {
+			return err
+		}
+		// Attachments of operations in the trash stay behind with them.
+		err := tx.Joins("JOIN operations ON operations.id = attachments.operation_id AND operations.deleted_at IS NULL").
+			Where("attachments.user_id = ?", userID).
+			Order("attachments.created_at").
+			Find(&a.Attachments).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Find(&a.DuplicateDismissals).Error; err != nil {
+			return err
+		}
+
+		var sessions []domain.UserSession
+		if err := tx.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			a.Sessions = append(a.Sessions, archive.Session{
+				CreatedAt: session.CreatedAt,
+				UpdatedAt: session.UpdatedAt,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &a, nil
+}
+
+// RestoreAccount loads a remapped archive into the user's account in one
+// transaction. The account must not have operations yet; the categories it
+// has, such as the ones seeded on signup, are moved to the trash and replaced
+// by the archived ones. Session metadata is informational and not restored,
+// and the files of attachments must be in the blob store already.
+func (s *Storage) RestoreAccount(userID uuid.UUID, a *archive.Archive) error {
+	const fn = "storage.postgresql.RestoreAccount"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var operations int64
+		if err := tx.Model(&domain.Operation{}).Where("user_id = ?", userID).Count(&operations).Error; err != nil {
+			return err
+		}
+		if operations > 0 {
+			return storage.ErrAccountNotEmpty
+		}
+
+		if len(a.Categories) > 0 {
+			if err := tx.Where("user_id = ?", userID).Delete(&domain.Category{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.Tags) > 0 {
+			if err := tx.Where("user_id = ?", userID).Delete(&domain.Tag{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.Payees) > 0 {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&domain.Payee{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if a.User.BaseCurrency != "" {
+			result := tx.Model(&domain.User{}).Where("id = ?", userID).Update("base_currency", a.User.BaseCurrency)
+			if result.Error != nil {
+				return result.Error
+			}
+		}
+
+		if len(a.Categories) > 0 {
+			// Parents are inserted before the subcategories referencing them.
+			categories := categorytree.New(a.Categories).Sorted()
+			if err := tx.CreateInBatches(&categories, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.Tags) > 0 {
+			if err := tx.CreateInBatches(&a.Tags, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.Payees) > 0 {
+			if err := tx.Omit(clause.Associations).CreateInBatches(&a.Payees, 200).Error; err != nil {
+				return err
+			}
+			var aliases []domain.PayeeAlias
+			for _, p := range a.Payees {
+				aliases = append(aliases, p.Aliases...)
+			}
+			if len(aliases) > 0 {
+				if err := tx.CreateInBatches(&aliases, 500).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(a.Operations) > 0 {
+			if err := tx.Omit(clause.Associations).CreateInBatches(&a.Operations, 200).Error; err != nil {
+				return err
+			}
+			var links []domain.OperationTag
+			var splits []domain.OperationSplit
+			var items []domain.OperationItem
+			for _, op := range a.Operations {
+				for _, t := range op.Tags {
+					links = append(links, domain.OperationTag{OperationID: op.ID, TagID: t.ID})
+				}
+				splits = append(splits, op.Splits...)
+				items = append(items, op.Items...)
+			}
+			if len(links) > 0 {
+				if err := tx.CreateInBatches(&links, 500).Error; err != nil {
+					return err
+				}
+			}
+			if len(splits) > 0 {
+				if err := tx.CreateInBatches(&splits, 500).Error; err != nil {
+					return err
+				}
+			}
+			if len(items) > 0 {
+				if err := tx.CreateInBatches(&items, 500).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if len(a.Attachments) > 0 {
+			if err := tx.CreateInBatches(&a.Attachments, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.ImportProfiles) > 0 {
+			if err := tx.CreateInBatches(&a.ImportProfiles, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.Rules) > 0 {
+			if err := tx.CreateInBatches(&a.Rules, 200).Error; err != nil {
+				return err
+			}
+		}
+		if len(a.DuplicateDismissals) > 0 {
+			if err := tx.CreateInBatches(&a.DuplicateDismissals, 200).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetAttachments returns the attachments of one of the user's operations in
+// the order they were added.
+func (s *Storage) GetAttachments(userID, operationID uuid.UUID) ([]domain.Attachment, error) {
+	const fn = "storage.postgresql.GetAttachments"
+
+	var attachments []domain.Attachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ownedOperation(tx, userID, operationID); err != nil {
+			return err
+		}
+		return tx.Where("operation_id = ?", operationID).Order("created_at, id").Find(&attachments).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachment returns one of the user's attachments.
+func (s *Storage) GetAttachment(userID, id uuid.UUID) (*domain.Attachment, error) {
+	const fn = "storage.postgresql.GetAttachment"
+
+	var attachment domain.Attachment
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).First(&attachment)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return &attachment, nil
+}
+
+// CreateAttachment attaches a file to one of the user's operations. When the
+// operation already has a file with the same checksum, nothing is added:
+// attachment is filled in with the existing one and created is false.
+func (s *Storage) CreateAttachment(attachment *domain.Attachment) (created bool, err error) {
+	const fn = "storage.postgresql.CreateAttachment"
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ownedOperation(tx, attachment.UserID, attachment.OperationID); err != nil {
+			return err
+		}
+
+		var existing domain.Attachment
+		result := tx.Where("operation_id = ? AND checksum = ?", attachment.OperationID, attachment.Checksum).
+			Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			*attachment = existing
+			return nil
+		}
+
+		created = true
+		return tx.Create(attachment).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return created, nil
+}
+
+// DeleteAttachment removes one of the user's attachments and returns it.
+// The file stays in the blob store until the sweep finds nothing refers to
+// it, so an upload of the same file racing the delete never loses it.
+func (s *Storage) DeleteAttachment(userID, id uuid.UUID) (*domain.Attachment, error) {
+	const fn = "storage.postgresql.DeleteAttachment"
+
+	var attachment domain.Attachment
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", id, userID).First(&attachment)
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return storage.ErrItemNotFound
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		return tx.Unscoped().Delete(&attachment).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &attachment, nil
+}
+
+// GetAttachmentChecksums reports which of the checksums an attachment of the
+// user still refers to, including attachments of operations in the trash.
+func (s *Storage) GetAttachmentChecksums(userID uuid.UUID, checksums []string) (map[string]bool, error) {
+	const fn = "storage.postgresql.GetAttachmentChecksums"
+
+	used := make(map[string]bool)
+	if len(checksums) == 0 {
+		return used, nil
+	}
+
+	var found []string
+	result := s.db.Model(&domain.Attachment{}).
+		Distinct("checksum").
+		Where("user_id = ? AND checksum IN ?", userID, checksums).
+		Pluck("checksum", &found)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+	for _, checksum := range found {
+		used[checksum] = true
+	}
+
+	return used, nil
+}
+
+// ownedOperation fails with ErrItemNotFound unless the live operation id
+// belongs to the user.
+func ownedOperation(tx *gorm.DB, userID, id uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&domain.Operation{}).Where("id = ? AND user_id = ?", id, userID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return storage.ErrItemNotFound
+	}
+	return nil
+}
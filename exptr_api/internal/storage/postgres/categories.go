@@ -3,9 +3,13 @@ package postgres
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/categorytree"
+	"alex_gorbunov_exptr_api/internal/lib/templates"
 	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -15,21 +19,92 @@ func (s *Storage) CreateCategory(category *models.CategoryRequest) error {
 	const fn = "storage.postgresql.CreateCategory"
 
 	cat := domain.Category{
-		UserID: uuid.MustParse(category.UserID),
-		Name:   category.Name,
-		Type:   category.Type,
-		Color:  category.Color,
-		Icon:   category.Icon,
+		UserID:   uuid.MustParse(category.UserID),
+		ParentID: category.ParentID,
+		Name:     category.Name,
+		Type:     category.Type,
+		Color:    category.Color,
+		Icon:     category.Icon,
 	}
 
-	result := s.db.Create(&cat)
-	if result.Error != nil {
-		return fmt.Errorf("%s: %w", fn, result.Error)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if cat.ParentID != nil {
+			var parent domain.Category
+			if err := ownedCategory(tx, cat.UserID, *cat.ParentID, &parent); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&cat).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
 }
 
+// ApplyCategoryTemplate adds the template categories the user does not have
+// yet and returns how many were created.
+func (s *Storage) ApplyCategoryTemplate(userID uuid.UUID, categories []domain.Category) (int, error) {
+	const fn = "storage.postgresql.ApplyCategoryTemplate"
+
+	var created int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var existing []domain.Category
+		if err := tx.Where("user_id = ?", userID).Find(&existing).Error; err != nil {
+			return err
+		}
+
+		missing := templates.Missing(categories, existing)
+		if len(missing) == 0 {
+			return nil
+		}
+		created = len(missing)
+		return tx.CreateInBatches(&missing, 200).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return created, nil
+}
+
+// MoveCategory puts a category, together with its subtree, under parentID or
+// at the top level when parentID is nil. Moving it under itself or one of its
+// descendants fails with ErrCategoryCycle.
+func (s *Storage) MoveCategory(userID, id uuid.UUID, parentID *uuid.UUID) (*domain.Category, error) {
+	const fn = "storage.postgresql.MoveCategory"
+
+	var category domain.Category
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ownedCategory(tx, userID, id, &category); err != nil {
+			return err
+		}
+
+		if parentID != nil {
+			var categories []domain.Category
+			if err := tx.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+				return err
+			}
+			var parent domain.Category
+			if err := ownedCategory(tx, userID, *parentID, &parent); err != nil {
+				return err
+			}
+			if categorytree.New(categories).Contains(id, *parentID) {
+				return storage.ErrCategoryCycle
+			}
+		}
+
+		category.ParentID = parentID
+		return tx.Model(&category).Update("parent_id", parentID).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &category, nil
+}
+
 func (s *Storage) UpdateCategory(category *domain.Category) error {
 	const fn = "storage.postgresql.UpdateCategory"
 
@@ -79,24 +154,187 @@ func (s *Storage) GetCategoryByID(id uuid.UUID) (*domain.Category, error) {
 	return &category, nil
 }
 
-func (s *Storage) DeleteCategory(id uuid.UUID) error {
+// DeleteCategory soft-deletes a category together with its operations and
+// returns how many operations went with it. Both get the same deleted_at so
+// RestoreCategory can bring them back as a unit. A category that split lines
+// of other operations use is refused with ErrCategoryInUse.
+func (s *Storage) DeleteCategory(userID, id uuid.UUID) (int, error) {
 	const fn = "storage.postgresql.DeleteCategory"
 
-	// First check if category exists
-	var category domain.Category
-	result := s.db.Where("id = ?", id).First(&category)
-	if result.Error != nil {
-		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("%s: category not found", fn)
+	var trashed int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category domain.Category
+		if err := ownedCategory(tx, userID, id, &category); err != nil {
+			return err
 		}
-		return fmt.Errorf("%s: %w", fn, result.Error)
+
+		// Split lines of operations booked elsewhere would be left pointing
+		// at a deleted category.
+		var split int64
+		err := tx.Model(&domain.OperationSplit{}).
+			Joins("JOIN operations ON operations.id = operation_splits.operation_id").
+			Where("operation_splits.category_id = ? AND operations.category_id <> ? AND operations.deleted_at IS NULL", id, id).
+			Count(&split).Error
+		if err != nil {
+			return err
+		}
+		if split > 0 {
+			return storage.ErrCategoryInUse
+		}
+
+		if err := reparentChildren(tx, id, category.ParentID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&domain.Operation{}).Where("category_id = ?", id).Update("deleted_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		trashed = int(result.RowsAffected)
+
+		return tx.Model(&category).Update("deleted_at", now).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	// Delete the category (soft delete due to gorm.DeletedAt in BaseEntity)
-	result = s.db.Delete(&category)
-	if result.Error != nil {
-		return fmt.Errorf("%s: %w", fn, result.Error)
+	return trashed, nil
+}
+
+// DeleteUnusedCategory soft-deletes a category only if no live operation or
+// split line uses it, returning ErrCategoryInUse otherwise.
+func (s *Storage) DeleteUnusedCategory(userID, id uuid.UUID) error {
+	const fn = "storage.postgresql.DeleteUnusedCategory"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category domain.Category
+		if err := ownedCategory(tx, userID, id, &category); err != nil {
+			return err
+		}
+
+		var used, split int64
+		if err := tx.Model(&domain.Operation{}).Where("category_id = ?", id).Count(&used).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&domain.OperationSplit{}).
+			Joins("JOIN operations ON operations.id = operation_splits.operation_id").
+			Where("operation_splits.category_id = ? AND operations.deleted_at IS NULL", id).
+			Count(&split).Error
+		if err != nil {
+			return err
+		}
+		if used+split > 0 {
+			return storage.ErrCategoryInUse
+		}
+
+		if err := reparentChildren(tx, id, category.ParentID); err != nil {
+			return err
+		}
+
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
 }
+
+// ReassignCategory moves the live operations and split lines of a category
+// to targetID and soft-deletes it, returning how many operations moved. With
+// merge the two categories are combined for good: operations in the trash,
+// import profiles defaulting to the category and rules setting it follow it
+// as well.
+func (s *Storage) ReassignCategory(userID, id, targetID uuid.UUID, merge bool) (int, error) {
+	const fn = "storage.postgresql.ReassignCategory"
+
+	var moved int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category, target domain.Category
+		if err := ownedCategory(tx, userID, id, &category); err != nil {
+			return err
+		}
+		if err := ownedCategory(tx, userID, targetID, &target); err != nil {
+			return err
+		}
+
+		operations := tx.Model(&domain.Operation{})
+		if merge {
+			operations = operations.Unscoped()
+		}
+		result := operations.Where("category_id = ?", id).Update("category_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = int(result.RowsAffected)
+
+		splits := tx.Model(&domain.OperationSplit{}).Where("category_id = ?", id)
+		if !merge {
+			splits = splits.Where("operation_id IN (SELECT id FROM operations WHERE deleted_at IS NULL)")
+		}
+		if err := splits.Update("category_id", targetID).Error; err != nil {
+			return err
+		}
+
+		if merge {
+			err := tx.Model(&domain.ImportProfile{}).
+				Where("user_id = ? AND default_category_id = ?", userID, id).
+				Update("default_category_id", targetID).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&domain.Rule{}).
+				Where("user_id = ? AND actions->>'category_id' = ?", userID, id.String()).
+				Update("actions", gorm.Expr("jsonb_set(actions, '{category_id}', to_jsonb(?::text))", targetID.String())).Error
+			if err != nil {
+				return err
+			}
+			if err := mergeChildren(tx, userID, &category, &target); err != nil {
+				return err
+			}
+		} else if err := reparentChildren(tx, id, category.ParentID); err != nil {
+			return err
+		}
+
+		return tx.Delete(&category).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return moved, nil
+}
+
+// reparentChildren moves the direct subcategories of id under parentID.
+func reparentChildren(tx *gorm.DB, id uuid.UUID, parentID *uuid.UUID) error {
+	return tx.Model(&domain.Category{}).Where("parent_id = ?", id).Update("parent_id", parentID).Error
+}
+
+// mergeChildren moves the subcategories of category under target. A target
+// inside the merged subtree first takes the place of category so the tree
+// does not loop.
+func mergeChildren(tx *gorm.DB, userID uuid.UUID, category, target *domain.Category) error {
+	var categories []domain.Category
+	if err := tx.Where("user_id = ?", userID).Find(&categories).Error; err != nil {
+		return err
+	}
+
+	if categorytree.New(categories).Contains(category.ID, target.ID) {
+		if err := tx.Model(target).Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&domain.Category{}).
+		Where("parent_id = ? AND id <> ?", category.ID, target.ID).
+		Update("parent_id", target.ID).Error
+}
+
+func ownedCategory(tx *gorm.DB, userID, id uuid.UUID, category *domain.Category) error {
+	err := tx.Where("id = ? AND user_id = ?", id, userID).First(category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return storage.ErrItemNotFound
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/dedup"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetDuplicateDismissals returns the pairs the user marked as not duplicates.
+func (s *Storage) GetDuplicateDismissals(userID uuid.UUID) (map[dedup.Key]bool, error) {
+	const fn = "storage.postgresql.GetDuplicateDismissals"
+
+	var dismissals []domain.DuplicateDismissal
+	result := s.db.Where("user_id = ?", userID).Find(&dismissals)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	dismissed := make(map[dedup.Key]bool, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[dedup.PairKey(d.OperationID, d.OtherID)] = true
+	}
+
+	return dismissed, nil
+}
+
+// DismissDuplicate remembers that two of the user's operations are not duplicates.
+func (s *Storage) DismissDuplicate(userID, operationID, otherID uuid.UUID) error {
+	const fn = "storage.postgresql.DismissDuplicate"
+
+	var count int64
+	result := s.db.Model(&domain.Operation{}).
+		Where("user_id = ? AND id IN ?", userID, []uuid.UUID{operationID, otherID}).
+		Count(&count)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+	if count != 2 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	key := dedup.PairKey(operationID, otherID)
+	dismissal := domain.DuplicateDismissal{
+		UserID:      userID,
+		OperationID: key[0],
+		OtherID:     key[1],
+	}
+
+	result = s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&dismissal)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return nil
+}
+
+// MergeOperations keeps one operation of a duplicate pair and deletes the
+// other. Details missing on the kept operation, such as the comment, the
+// settled amount or the external id, are taken from the dropped one so a
+// later import of the same statement still recognizes it. The kept operation
+// gains the tags of the dropped one.
+func (s *Storage) MergeOperations(userID, keepID, dropID uuid.UUID) (*domain.Operation, error) {
+	const fn = "storage.postgresql.MergeOperations"
+
+	var keep domain.Operation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var drop domain.Operation
+		if err := tx.Where("id = ? AND user_id = ?", keepID, userID).First(&keep).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND user_id = ?", dropID, userID).First(&drop).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if keep.Comment == "" && drop.Comment != "" {
+			updates["comment"] = drop.Comment
+		}
+		if !keep.Settled() && drop.Settled() {
+			updates["settled_amount"] = drop.SettledAmount
+			updates["settled_currency"] = drop.SettledCurrency
+			updates["effective_rate"] = drop.EffectiveRate
+		}
+		if keep.ExternalID == "" && drop.ExternalID != "" {
+			updates["external_id"] = drop.ExternalID
+		}
+
+		// The external id is unique per user, so it is moved off the
+		// dropped operation before it lands on the kept one.
+		if err := tx.Model(&drop).Update("external_id", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&drop).Error; err != nil {
+			return err
+		}
+		err := tx.Exec(`INSERT INTO operation_tags (operation_id, tag_id)
+			SELECT ?, tag_id FROM operation_tags WHERE operation_id = ?
+			ON CONFLICT DO NOTHING`, keep.ID, drop.ID).Error
+		if err != nil {
+			return err
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&keep).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Where("id = ?", keep.ID).First(&keep).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &keep, nil
+}
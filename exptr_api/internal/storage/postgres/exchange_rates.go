@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveExchangeRates upserts rates, replacing the rate of an existing date and pair.
+func (s *Storage) SaveExchangeRates(rates []domain.ExchangeRate) error {
+	const fn = "storage.postgresql.SaveExchangeRates"
+
+	if len(rates) == 0 {
+		return nil
+	}
+
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}, {Name: "base"}, {Name: "quote"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "source", "updated_at"}),
+	}).CreateInBatches(&rates, 500)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return nil
+}
+
+// GetExchangeRate returns the latest rate for the pair published on or before the given date.
+func (s *Storage) GetExchangeRate(base, quote string, on time.Time) (float64, error) {
+	const fn = "storage.postgresql.GetExchangeRate"
+
+	var rate domain.ExchangeRate
+	result := s.db.
+		Where("base = ? AND quote = ? AND date <= ?", base, quote, on).
+		Order("date DESC").
+		First(&rate)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+		}
+		return 0, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return rate.Rate, nil
+}
+
+func (s *Storage) GetExchangeRates(base string, on time.Time) ([]domain.ExchangeRate, error) {
+	const fn = "storage.postgresql.GetExchangeRates"
+
+	var rates []domain.ExchangeRate
+	result := s.db.
+		Where("base = ? AND date = (?)", base,
+			s.db.Model(&domain.ExchangeRate{}).Select("MAX(date)").Where("base = ? AND date <= ?", base, on)).
+		Order("quote").
+		Find(&rates)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (s *Storage) CreateImportProfile(profile *domain.ImportProfile) error {
+	const fn = "storage.postgresql.CreateImportProfile"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDefaultCategory(tx, profile); err != nil {
+			return err
+		}
+		return tx.Create(profile).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) UpdateImportProfile(profile *domain.ImportProfile) error {
+	const fn = "storage.postgresql.UpdateImportProfile"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkDefaultCategory(tx, profile); err != nil {
+			return err
+		}
+		result := tx.Model(&domain.ImportProfile{}).
+			Where("id = ? AND user_id = ?", profile.ID, profile.UserID).
+			Updates(map[string]interface{}{
+				"name":    profile.Name,
+				"bank":    profile.Bank,
+				"mapping": profile.Mapping,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("import profile not found")
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// checkDefaultCategory fails with storage.ErrDefaultCategory when the
+// profile's default category is not one of its owner's.
+func checkDefaultCategory(tx *gorm.DB, profile *domain.ImportProfile) error {
+	id := profile.Mapping.DefaultCategoryID
+	if id == nil {
+		return nil
+	}
+
+	var owned int64
+	if err := tx.Model(&domain.Category{}).Where("id = ? AND user_id = ?", *id, profile.UserID).Count(&owned).Error; err != nil {
+		return err
+	}
+	if owned == 0 {
+		return storage.ErrDefaultCategory
+	}
+	return nil
+}
+
+func (s *Storage) GetImportProfiles(userID uuid.UUID) ([]domain.ImportProfile, error) {
+	const fn = "storage.postgresql.GetImportProfiles"
+
+	var profiles []domain.ImportProfile
+	result := s.db.Where("user_id = ?", userID).Order("name").Find(&profiles)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return profiles, nil
+}
+
+func (s *Storage) GetImportProfile(id, userID uuid.UUID) (*domain.ImportProfile, error) {
+	const fn = "storage.postgresql.GetImportProfile"
+
+	var profile domain.ImportProfile
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).First(&profile)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: import profile not found", fn)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return &profile, nil
+}
+
+func (s *Storage) DeleteImportProfile(id, userID uuid.UUID) error {
+	const fn = "storage.postgresql.DeleteImportProfile"
+
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.ImportProfile{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: import profile not found", fn)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/items"
+	"alex_gorbunov_exptr_api/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetItemOperations returns the user's operations matching the filter that
+// have a receipt line containing every word of query, newest first, with
+// only the matching lines loaded. An empty query matches every line. A
+// positive limit caps the number of operations.
+func (s *Storage) GetItemOperations(userID uuid.UUID, query string, filter models.OperationFilter, limit int) ([]domain.Operation, error) {
+	const fn = "storage.postgresql.GetItemOperations"
+
+	matching := func(db *gorm.DB) *gorm.DB {
+		for _, word := range items.Words(query) {
+			db = db.Where("key LIKE ?", "%"+items.EscapeLike(word)+"%")
+		}
+		return db
+	}
+
+	lines := matching(s.db.Model(&domain.OperationItem{}).Select("operation_id"))
+	q := applyOperationFilter(s.db.Where("user_id = ?", userID), filter).
+		Where("id IN (?)", lines).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return orderItems(matching(db)) }).
+		Order("created_at DESC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+
+	var operations []domain.Operation
+	if result := q.Find(&operations); result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return operations, nil
+}
+
+func newItems(lines []models.ItemRequest) []domain.OperationItem {
+	if len(lines) == 0 {
+		return nil
+	}
+	list := make([]domain.OperationItem, 0, len(lines))
+	for i, line := range lines {
+		description := strings.TrimSpace(line.Description)
+		quantity := line.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		list = append(list, domain.OperationItem{
+			Position:    i,
+			Description: description,
+			Key:         items.Key(description),
+			Quantity:    quantity,
+			UnitPrice:   line.UnitPrice,
+			CategoryID:  line.CategoryID,
+		})
+	}
+	return list
+}
+
+// dropForeignItemCategories clears the categories of receipt lines that the
+// user does not own.
+func dropForeignItemCategories(tx *gorm.DB, userID uuid.UUID, lines []domain.OperationItem) error {
+	var ids []uuid.UUID
+	for _, line := range lines {
+		if line.CategoryID != nil {
+			ids = append(ids, *line.CategoryID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var owned []uuid.UUID
+	if err := tx.Model(&domain.Category{}).Where("user_id = ? AND id IN ?", userID, ids).Pluck("id", &owned).Error; err != nil {
+		return err
+	}
+	found := uniqueIDs(owned)
+	for i := range lines {
+		if lines[i].CategoryID != nil && !found[*lines[i].CategoryID] {
+			lines[i].CategoryID = nil
+		}
+	}
+	return nil
+}
+
+func orderItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
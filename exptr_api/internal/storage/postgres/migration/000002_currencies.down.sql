@@ -0,0 +1,8 @@
+-- Drop indexes on exchange_rates
+DROP INDEX IF EXISTS idx_exchange_rates_deleted_at;
+DROP INDEX IF EXISTS idx_exchange_rates_created_at;
+DROP INDEX IF EXISTS idx_exchange_rates_date_pair;
+
+DROP TABLE IF EXISTS exchange_rates;
+
+ALTER TABLE users DROP COLUMN IF EXISTS base_currency;
@@ -0,0 +1,20 @@
+-- Base currency used for reporting
+ALTER TABLE users ADD COLUMN IF NOT EXISTS base_currency VARCHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Exchange rates table
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    date DATE NOT NULL,
+    base VARCHAR(3) NOT NULL,
+    quote VARCHAR(3) NOT NULL,
+    rate DECIMAL(19, 8) NOT NULL,
+    source VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes on exchange_rates
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exchange_rates_date_pair ON exchange_rates(date, base, quote);
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_created_at ON exchange_rates(created_at);
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_deleted_at ON exchange_rates(deleted_at);
@@ -0,0 +1,3 @@
+ALTER TABLE operations DROP COLUMN IF EXISTS effective_rate;
+ALTER TABLE operations DROP COLUMN IF EXISTS settled_currency;
+ALTER TABLE operations DROP COLUMN IF EXISTS settled_amount;
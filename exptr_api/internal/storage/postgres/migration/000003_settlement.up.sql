@@ -0,0 +1,4 @@
+-- Amount actually charged for foreign-currency operations
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS settled_amount DECIMAL(19, 4);
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS settled_currency VARCHAR(3);
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS effective_rate DECIMAL(19, 8);
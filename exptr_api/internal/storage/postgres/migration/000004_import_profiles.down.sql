@@ -0,0 +1,6 @@
+-- Drop indexes on import_profiles
+DROP INDEX IF EXISTS idx_import_profiles_deleted_at;
+DROP INDEX IF EXISTS idx_import_profiles_created_at;
+DROP INDEX IF EXISTS idx_import_profiles_user_id;
+
+DROP TABLE IF EXISTS import_profiles;
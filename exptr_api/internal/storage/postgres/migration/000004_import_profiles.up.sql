@@ -0,0 +1,17 @@
+-- Import profiles table
+CREATE TABLE IF NOT EXISTS import_profiles (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    bank VARCHAR(255),
+    mapping JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_import_profiles_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Create indexes on import_profiles
+CREATE INDEX IF NOT EXISTS idx_import_profiles_user_id ON import_profiles(user_id);
+CREATE INDEX IF NOT EXISTS idx_import_profiles_created_at ON import_profiles(created_at);
+CREATE INDEX IF NOT EXISTS idx_import_profiles_deleted_at ON import_profiles(deleted_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_operations_user_external_id;
+DROP INDEX IF EXISTS idx_operations_external_id;
+
+ALTER TABLE operations DROP COLUMN IF EXISTS external_id;
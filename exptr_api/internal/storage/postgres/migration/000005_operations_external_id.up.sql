@@ -0,0 +1,6 @@
+-- Source identifier of imported operations
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS external_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_operations_external_id ON operations(external_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_operations_user_external_id ON operations(user_id, external_id)
+    WHERE external_id IS NOT NULL AND external_id <> '' AND deleted_at IS NULL;
@@ -0,0 +1,16 @@
+-- Suspected duplicate pairs the user chose to keep
+CREATE TABLE IF NOT EXISTS duplicate_dismissals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    operation_id UUID NOT NULL,
+    other_id UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_duplicate_dismissals_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_duplicate_dismissals_operation FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_duplicate_dismissals_other FOREIGN KEY (other_id) REFERENCES operations(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_duplicate_dismissals_pair ON duplicate_dismissals(user_id, operation_id, other_id);
+CREATE INDEX IF NOT EXISTS idx_duplicate_dismissals_deleted_at ON duplicate_dismissals(deleted_at);
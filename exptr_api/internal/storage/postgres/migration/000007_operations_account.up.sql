@@ -0,0 +1,2 @@
+-- Bank account or wallet an operation moved money through
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS account VARCHAR(255);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS account_tombstones;
+
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS fk_operations_user;
+ALTER TABLE operations ADD CONSTRAINT fk_operations_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS idx_users_delete_after;
+ALTER TABLE users DROP COLUMN IF EXISTS delete_after;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_requested_at;
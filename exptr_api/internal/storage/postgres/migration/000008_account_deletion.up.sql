@@ -0,0 +1,27 @@
+-- Pending account deletions and the audit trail of purged accounts
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS delete_after TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_delete_after ON users(delete_after);
+
+-- Operations used to outlive their owner with a NULL user_id
+ALTER TABLE operations DROP CONSTRAINT IF EXISTS fk_operations_user;
+ALTER TABLE operations ADD CONSTRAINT fk_operations_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS account_tombstones (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    email_hash VARCHAR(64) NOT NULL,
+    requested_at TIMESTAMP WITH TIME ZONE,
+    categories INTEGER NOT NULL DEFAULT 0,
+    operations INTEGER NOT NULL DEFAULT 0,
+    import_profiles INTEGER NOT NULL DEFAULT 0,
+    sessions INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tombstones_user_id ON account_tombstones(user_id);
+CREATE INDEX IF NOT EXISTS idx_account_tombstones_email_hash ON account_tombstones(email_hash);
+CREATE INDEX IF NOT EXISTS idx_account_tombstones_deleted_at ON account_tombstones(deleted_at);
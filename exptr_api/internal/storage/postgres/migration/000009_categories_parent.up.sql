@@ -0,0 +1,5 @@
+-- Subcategories point at their parent category
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id UUID;
+ALTER TABLE categories ADD CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
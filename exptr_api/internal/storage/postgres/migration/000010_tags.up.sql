@@ -0,0 +1,25 @@
+-- Labels cutting across categories
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    color VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_tags_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags(user_id, LOWER(name)) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_tags_user_id ON tags(user_id);
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags(deleted_at);
+
+CREATE TABLE IF NOT EXISTS operation_tags (
+    operation_id UUID NOT NULL,
+    tag_id UUID NOT NULL,
+    PRIMARY KEY (operation_id, tag_id),
+    CONSTRAINT fk_operation_tags_operation FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_operation_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_operation_tags_tag_id ON operation_tags(tag_id);
@@ -0,0 +1,13 @@
+-- Lines of an operation spread over several categories
+CREATE TABLE IF NOT EXISTS operation_splits (
+    operation_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    category_id UUID NOT NULL,
+    amount DECIMAL(19,4) NOT NULL,
+    note TEXT,
+    PRIMARY KEY (operation_id, position),
+    CONSTRAINT fk_operation_splits_operation FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_operation_splits_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE RESTRICT
+);
+
+CREATE INDEX IF NOT EXISTS idx_operation_splits_category_id ON operation_splits(category_id);
@@ -0,0 +1,3 @@
+ALTER TABLE operations DROP COLUMN IF EXISTS payee_id;
+DROP TABLE IF EXISTS payee_aliases;
+DROP TABLE IF EXISTS payees;
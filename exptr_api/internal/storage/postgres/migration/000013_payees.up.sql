@@ -0,0 +1,29 @@
+-- Merchants and people on the other side of operations
+CREATE TABLE IF NOT EXISTS payees (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_payees_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payees_user_id ON payees(user_id);
+CREATE INDEX IF NOT EXISTS idx_payees_deleted_at ON payees(deleted_at);
+
+-- Normalized operation names leading to a payee
+CREATE TABLE IF NOT EXISTS payee_aliases (
+    user_id UUID NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    payee_id UUID NOT NULL,
+    PRIMARY KEY (user_id, key),
+    CONSTRAINT fk_payee_aliases_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payee_aliases_payee FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_payee_aliases_payee_id ON payee_aliases(payee_id);
+
+ALTER TABLE operations ADD COLUMN IF NOT EXISTS payee_id UUID;
+ALTER TABLE operations ADD CONSTRAINT fk_operations_payee FOREIGN KEY (payee_id) REFERENCES payees(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_operations_payee_id ON operations(payee_id);
@@ -0,0 +1,22 @@
+-- Receipts and documents attached to operations; files live in the blob store
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL,
+    operation_id UUID NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    checksum VARCHAR(64) NOT NULL,
+    thumbnail BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT fk_attachments_user FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_attachments_operation FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_user_id ON attachments(user_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_operation_id ON attachments(operation_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_checksum ON attachments(checksum);
+CREATE INDEX IF NOT EXISTS idx_attachments_deleted_at ON attachments(deleted_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachments_operation_checksum ON attachments(operation_id, checksum);
@@ -0,0 +1,16 @@
+-- Receipt lines of operations
+CREATE TABLE IF NOT EXISTS operation_items (
+    operation_id UUID NOT NULL,
+    position INTEGER NOT NULL,
+    description VARCHAR(255) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    quantity DECIMAL(12,3) NOT NULL,
+    unit_price DECIMAL(19,4) NOT NULL,
+    category_id UUID,
+    PRIMARY KEY (operation_id, position),
+    CONSTRAINT fk_operation_items_operation FOREIGN KEY (operation_id) REFERENCES operations(id) ON DELETE CASCADE,
+    CONSTRAINT fk_operation_items_category FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_operation_items_key ON operation_items(key);
+CREATE INDEX IF NOT EXISTS idx_operation_items_category_id ON operation_items(category_id);
@@ -6,50 +6,276 @@ import (
 
 	"alex_gorbunov_exptr_api/internal/domain"
 	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func (s *Storage) CreateOperation(operation models.OperationRequest) error {
 	const fn = "storage.postgresql.CreateOperation"
 
-	op := domain.Operation{
-		UserID:     operation.UserID,
-		CategoryID: operation.CategoryID,
-		Amount:     operation.Amount,
-		Currency:   operation.Currency,
-		Name:       operation.Name,
-		Comment:    operation.Comment,
-		Type:       operation.Type,
+	op := newOperation(operation)
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := assignPayees(tx, []*domain.Operation{&op}); err != nil {
+			return err
+		}
+		if err := checkSplitCategories(tx, op.UserID, op.Splits); err != nil {
+			return err
+		}
+		if err := dropForeignItemCategories(tx, op.UserID, op.Items); err != nil {
+			return err
+		}
+		if err := tx.Create(&op).Error; err != nil {
+			return err
+		}
+		return linkTags(tx, []domain.Operation{op}, map[uuid.UUID][]string{op.ID: operation.Tags})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
-	result := s.db.Create(&op)
-	if result.Error != nil {
-		return fmt.Errorf("%s: %w", fn, result.Error)
+	return nil
+}
+
+// CreateOperations inserts operations in batches within a single transaction.
+// Operations whose external id is already stored for the user are skipped.
+func (s *Storage) CreateOperations(operations []models.OperationRequest) error {
+	const fn = "storage.postgresql.CreateOperations"
+
+	if err := s.ImportOperations(nil, operations); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
 }
 
-func (s *Storage) UpdateOperation(id uuid.UUID, operation *models.OperationRequest) error {
-	const fn = "storage.postgresql.UpdateOperation"
+// ImportOperations creates the categories an import needs and inserts its
+// operations in one transaction, so a failed import leaves no new
+// categories behind.
+func (s *Storage) ImportOperations(categories []domain.Category, operations []models.OperationRequest) error {
+	const fn = "storage.postgresql.ImportOperations"
+
+	if len(operations) == 0 {
+		return nil
+	}
 
-	result := s.db.Model(&domain.Operation{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"category_id": operation.CategoryID,
-		"amount":      operation.Amount,
-		"currency":    operation.Currency,
-		"name":        operation.Name,
-		"comment":     operation.Comment,
-		"type":        operation.Type,
+	ops := make([]domain.Operation, 0, len(operations))
+	tags := make(map[uuid.UUID][]string)
+	for _, operation := range operations {
+		op := newOperation(operation)
+		if len(operation.Tags) > 0 {
+			op.ID = uuid.New()
+			tags[op.ID] = operation.Tags
+		}
+		ops = append(ops, op)
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if len(categories) > 0 {
+			if err := tx.Create(&categories).Error; err != nil {
+				return err
+			}
+		}
+		linked := make([]*domain.Operation, 0, len(ops))
+		for i := range ops {
+			linked = append(linked, &ops[i])
+		}
+		if err := assignPayees(tx, linked); err != nil {
+			return err
+		}
+		for _, op := range linked {
+			if err := checkSplitCategories(tx, op.UserID, op.Splits); err != nil {
+				return err
+			}
+			if err := dropForeignItemCategories(tx, op.UserID, op.Items); err != nil {
+				return err
+			}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&ops, 200).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+
+		// Operations skipped as already imported keep the tags they have.
+		ids := make([]uuid.UUID, 0, len(tags))
+		for id := range tags {
+			ids = append(ids, id)
+		}
+		var inserted []domain.Operation
+		if err := tx.Select("id", "user_id").Where("id IN ?", ids).Find(&inserted).Error; err != nil {
+			return err
+		}
+		return linkTags(tx, inserted, tags)
 	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// GetExistingExternalIDs returns which of the given external ids the user already imported.
+func (s *Storage) GetExistingExternalIDs(userID uuid.UUID, externalIDs []string) ([]string, error) {
+	const fn = "storage.postgresql.GetExistingExternalIDs"
 
+	if len(externalIDs) == 0 {
+		return nil, nil
+	}
+
+	var existing []string
+	result := s.db.Model(&domain.Operation{}).
+		Where("user_id = ? AND external_id IN ?", userID, externalIDs).
+		Pluck("external_id", &existing)
 	if result.Error != nil {
-		return fmt.Errorf("%s: %w", fn, result.Error)
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("%s: operation not found", fn)
+	return existing, nil
+}
+
+func newOperation(operation models.OperationRequest) domain.Operation {
+	return domain.Operation{
+		BaseEntity: domain.BaseEntity{
+			CreatedAt: operation.CreatedAt,
+		},
+		UserID:          operation.UserID,
+		CategoryID:      operation.CategoryID,
+		Amount:          operation.Amount,
+		Currency:        operation.Currency,
+		SettledAmount:   operation.SettledAmount,
+		SettledCurrency: operation.SettledCurrency,
+		EffectiveRate:   operation.EffectiveRate,
+		Name:            operation.Name,
+		Comment:         operation.Comment,
+		Type:            operation.Type,
+		Account:         operation.Account,
+		ExternalID:      operation.ExternalID,
+		Splits:          newSplits(operation.Splits),
+		Items:           newItems(operation.Items),
+		PayeeID:         operation.PayeeID,
+	}
+}
+
+func newSplits(splits []models.SplitRequest) []domain.OperationSplit {
+	if len(splits) == 0 {
+		return nil
+	}
+	lines := make([]domain.OperationSplit, 0, len(splits))
+	for i, split := range splits {
+		lines = append(lines, domain.OperationSplit{
+			Position:   i,
+			CategoryID: split.CategoryID,
+			Amount:     split.Amount,
+			Note:       split.Note,
+		})
+	}
+	return lines
+}
+
+func (s *Storage) UpdateOperation(id uuid.UUID, operation *models.OperationRequest) error {
+	const fn = "storage.postgresql.UpdateOperation"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var current domain.Operation
+		if err := tx.Select("id", "user_id").Where("id = ?", id).First(&current).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("operation not found")
+			}
+			return err
+		}
+		payee := domain.Operation{UserID: current.UserID, Name: operation.Name, PayeeID: operation.PayeeID}
+		if err := assignPayees(tx, []*domain.Operation{&payee}); err != nil {
+			return err
+		}
+
+		result := tx.Model(&domain.Operation{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"category_id":      operation.CategoryID,
+			"amount":           operation.Amount,
+			"currency":         operation.Currency,
+			"settled_amount":   operation.SettledAmount,
+			"settled_currency": operation.SettledCurrency,
+			"effective_rate":   operation.EffectiveRate,
+			"name":             operation.Name,
+			"comment":          operation.Comment,
+			"type":             operation.Type,
+			"account":          operation.Account,
+			"payee_id":         payee.PayeeID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("operation not found")
+		}
+
+		// nil keeps the current splits as long as they still add up.
+		if operation.Splits == nil {
+			var sum struct{ Lines, Total int }
+			err := tx.Model(&domain.OperationSplit{}).
+				Select("COUNT(*) AS lines, COALESCE(SUM(amount), 0) AS total").
+				Where("operation_id = ?", id).
+				Scan(&sum).Error
+			if err != nil {
+				return err
+			}
+			if sum.Lines > 0 && sum.Total != operation.Amount {
+				return storage.ErrSplitMismatch
+			}
+		} else {
+			if err := tx.Where("operation_id = ?", id).Delete(&domain.OperationSplit{}).Error; err != nil {
+				return err
+			}
+			if splits := newSplits(operation.Splits); len(splits) > 0 {
+				for i := range splits {
+					splits[i].OperationID = id
+				}
+				if err := checkSplitCategories(tx, current.UserID, splits); err != nil {
+					return err
+				}
+				if err := tx.Create(&splits).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// nil keeps the current items, an empty list clears them.
+		if operation.Items != nil {
+			if err := tx.Where("operation_id = ?", id).Delete(&domain.OperationItem{}).Error; err != nil {
+				return err
+			}
+			if lines := newItems(operation.Items); len(lines) > 0 {
+				for i := range lines {
+					lines[i].OperationID = id
+				}
+				if err := dropForeignItemCategories(tx, current.UserID, lines); err != nil {
+					return err
+				}
+				if err := tx.Create(&lines).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// nil keeps the current tags, an empty list clears them.
+		if operation.Tags == nil {
+			return nil
+		}
+		var updated domain.Operation
+		if err := tx.Where("id = ?", id).First(&updated).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("operation_id = ?", id).Delete(&domain.OperationTag{}).Error; err != nil {
+			return err
+		}
+		return linkTags(tx, []domain.Operation{updated}, map[uuid.UUID][]string{id: operation.Tags})
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
@@ -59,7 +285,7 @@ func (s *Storage) GetOperationByID(id uuid.UUID) (*domain.Operation, error) {
 	const fn = "storage.postgresql.GetOperationByID"
 
 	var operation domain.Operation
-	result := s.db.Where("id = ?", id).First(&operation)
+	result := s.db.Preload("Splits", orderSplits).Where("id = ?", id).First(&operation)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, fmt.Errorf("%s: operation not found", fn)
@@ -74,7 +300,22 @@ func (s *Storage) GetOperationsByUserID(userID uuid.UUID) ([]domain.Operation, e
 	const fn = "storage.postgresql.GetOperationsByUserID"
 
 	var operations []domain.Operation
-	result := s.db.Where("user_id = ?", userID).Find(&operations)
+	result := s.db.Preload("Tags").Preload("Splits", orderSplits).Preload("Items", orderItems).Where("user_id = ?", userID).Find(&operations)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return operations, nil
+}
+
+// GetOperations returns the user's operations matching the filter, oldest first.
+func (s *Storage) GetOperations(userID uuid.UUID, filter models.OperationFilter) ([]domain.Operation, error) {
+	const fn = "storage.postgresql.GetOperations"
+
+	var operations []domain.Operation
+	result := applyOperationFilter(s.db.Preload("Tags").Preload("Splits", orderSplits).Preload("Items", orderItems).Where("user_id = ?", userID), filter).
+		Order("created_at").
+		Find(&operations)
 	if result.Error != nil {
 		return nil, fmt.Errorf("%s: %w", fn, result.Error)
 	}
@@ -82,6 +323,180 @@ func (s *Storage) GetOperationsByUserID(userID uuid.UUID) ([]domain.Operation, e
 	return operations, nil
 }
 
+// GetTrainingOperations returns up to limit of the user's most recent live
+// operations booked to a single category, with only the fields a
+// categorizer learns from.
+func (s *Storage) GetTrainingOperations(userID uuid.UUID, limit int) ([]domain.Operation, error) {
+	const fn = "storage.postgresql.GetTrainingOperations"
+
+	var operations []domain.Operation
+	result := s.db.Select("id", "category_id", "name", "comment", "amount", "currency", "type").
+		Where("user_id = ?", userID).
+		Where("NOT EXISTS (SELECT 1 FROM operation_splits WHERE operation_splits.operation_id = operations.id)").
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&operations)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return operations, nil
+}
+
+// EachOperation calls each for every operation matching the filter, oldest
+// first, reading rows from the database one at a time instead of loading
+// them all.
+func (s *Storage) EachOperation(userID uuid.UUID, filter models.OperationFilter, each func(*domain.Operation) error) error {
+	const fn = "storage.postgresql.EachOperation"
+
+	rows, err := applyOperationFilter(s.db.Model(&domain.Operation{}).Where("user_id = ?", userID), filter).
+		Order("created_at").
+		Rows()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	// Preload does not apply to scanned rows, so splits are loaded for a
+	// batch of operations at a time.
+	batch := make([]domain.Operation, 0, eachBatchSize)
+	flush := func() error {
+		if err := s.loadSplits(batch); err != nil {
+			return err
+		}
+		for i := range batch {
+			if err := each(&batch[i]); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	for rows.Next() {
+		var operation domain.Operation
+		if err := s.db.ScanRows(rows, &operation); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+		batch = append(batch, operation)
+		if len(batch) == eachBatchSize {
+			if err := flush(); err != nil {
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if err := flush(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// eachBatchSize is how many operations EachOperation holds while it loads
+// their splits.
+const eachBatchSize = 500
+
+// loadSplits fills in the splits of operations with one query.
+func (s *Storage) loadSplits(operations []domain.Operation) error {
+	if len(operations) == 0 {
+		return nil
+	}
+
+	index := make(map[uuid.UUID]*domain.Operation, len(operations))
+	ids := make([]uuid.UUID, len(operations))
+	for i := range operations {
+		index[operations[i].ID] = &operations[i]
+		ids[i] = operations[i].ID
+	}
+
+	var splits []domain.OperationSplit
+	if err := orderSplits(s.db.Where("operation_id IN ?", ids)).Find(&splits).Error; err != nil {
+		return err
+	}
+	for _, split := range splits {
+		op := index[split.OperationID]
+		op.Splits = append(op.Splits, split)
+	}
+	return nil
+}
+
+func applyOperationFilter(query *gorm.DB, filter models.OperationFilter) *gorm.DB {
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if len(filter.CategoryIDs) > 0 {
+		subtree := `WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id IN ?
+				UNION
+				SELECT categories.id FROM categories JOIN subtree ON categories.parent_id = subtree.id
+			)
+			SELECT id FROM subtree`
+		query = query.Where(`(category_id IN (`+subtree+`)
+			OR id IN (SELECT operation_id FROM operation_splits WHERE category_id IN (`+subtree+`)))`,
+			filter.CategoryIDs, filter.CategoryIDs)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if len(filter.TagIDs) > 0 {
+		if filter.AllTags {
+			query = query.Where(`id IN (
+				SELECT operation_id FROM operation_tags WHERE tag_id IN ?
+				GROUP BY operation_id HAVING COUNT(DISTINCT tag_id) = ?
+			)`, filter.TagIDs, len(uniqueIDs(filter.TagIDs)))
+		} else {
+			query = query.Where("id IN (SELECT operation_id FROM operation_tags WHERE tag_id IN ?)", filter.TagIDs)
+		}
+	}
+	if len(filter.PayeeIDs) > 0 {
+		query = query.Where("payee_id IN ?", filter.PayeeIDs)
+	}
+	return query
+}
+
+// checkSplitCategories fails with storage.ErrSplitCategory unless the user
+// owns every category the splits are booked on. Unlike item categories they
+// cannot be dropped: the splits would no longer say where the money went.
+func checkSplitCategories(tx *gorm.DB, userID uuid.UUID, splits []domain.OperationSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(splits))
+	for _, split := range splits {
+		ids = append(ids, split.CategoryID)
+	}
+	unique := uniqueIDs(ids)
+
+	var owned int64
+	if err := tx.Model(&domain.Category{}).Where("user_id = ? AND id IN ?", userID, ids).Count(&owned).Error; err != nil {
+		return err
+	}
+	if int(owned) != len(unique) {
+		return storage.ErrSplitCategory
+	}
+	return nil
+}
+
+func orderSplits(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+func uniqueIDs(ids []uuid.UUID) map[uuid.UUID]bool {
+	unique := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	return unique
+}
+
 func (s *Storage) DeleteOperation(id uuid.UUID) error {
 	const fn = "storage.postgresql.DeleteOperation"
 
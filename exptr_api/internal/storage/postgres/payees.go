@@ -0,0 +1,362 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/lib/payees"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetPayees returns the user's payees sorted by name with their aliases and
+// the number of live operations linked to each.
+func (s *Storage) GetPayees(userID uuid.UUID) ([]models.PayeeUsage, error) {
+	const fn = "storage.postgresql.GetPayees"
+
+	var list []domain.Payee
+	result := s.db.Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("key") }).
+		Where("user_id = ?", userID).
+		Order("LOWER(name)").
+		Find(&list)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	var counts []struct {
+		PayeeID    uuid.UUID
+		Operations int
+	}
+	result = s.db.Model(&domain.Operation{}).
+		Select("payee_id, COUNT(*) AS operations").
+		Where("user_id = ? AND payee_id IS NOT NULL", userID).
+		Group("payee_id").
+		Scan(&counts)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+	used := make(map[uuid.UUID]int, len(counts))
+	for _, c := range counts {
+		used[c.PayeeID] = c.Operations
+	}
+
+	usage := make([]models.PayeeUsage, 0, len(list))
+	for _, payee := range list {
+		usage = append(usage, models.PayeeUsage{Payee: payee, Operations: used[payee.ID]})
+	}
+
+	return usage, nil
+}
+
+// CreatePayee adds a payee with the given aliases, normalized the way
+// operation names are, or with its own name as the only alias when none are
+// given. An alias of another payee fails with ErrPayeeAliasTaken.
+func (s *Storage) CreatePayee(payee *domain.Payee, aliases []string) error {
+	const fn = "storage.postgresql.CreatePayee"
+
+	keys := payeeKeys(aliases)
+	if len(keys) == 0 {
+		keys = payeeKeys([]string{payee.Name})
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkPayeeAliases(tx, payee.UserID, uuid.Nil, keys); err != nil {
+			return err
+		}
+		payee.Aliases = newPayeeAliases(payee.UserID, keys)
+		return tx.Create(payee).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// UpdatePayee renames a payee. Aliases, unless nil, replace its aliases; an
+// empty list leaves it to be picked by hand only. An alias of another payee
+// fails with ErrPayeeAliasTaken; use MergePayees to combine them.
+func (s *Storage) UpdatePayee(userID, id uuid.UUID, name string, aliases []string) (*domain.Payee, error) {
+	const fn = "storage.postgresql.UpdatePayee"
+
+	var payee domain.Payee
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ownedPayee(tx, userID, id, &payee); err != nil {
+			return err
+		}
+
+		if aliases != nil {
+			keys := payeeKeys(aliases)
+			if err := checkPayeeAliases(tx, userID, id, keys); err != nil {
+				return err
+			}
+			if err := tx.Where("payee_id = ?", id).Delete(&domain.PayeeAlias{}).Error; err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				created := newPayeeAliases(userID, keys)
+				for i := range created {
+					created[i].PayeeID = id
+				}
+				if err := tx.Create(&created).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Model(&payee).Update("name", name).Error; err != nil {
+			return err
+		}
+		return tx.Preload("Aliases", func(db *gorm.DB) *gorm.DB { return db.Order("key") }).
+			Where("id = ?", id).First(&payee).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &payee, nil
+}
+
+// DeletePayee removes a payee and its aliases. Its operations are kept
+// without a payee.
+func (s *Storage) DeletePayee(userID, id uuid.UUID) error {
+	const fn = "storage.postgresql.DeletePayee"
+
+	result := s.db.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Payee{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// MergePayees moves the operations and aliases of id over to targetID and
+// deletes id, so every name that led to it leads to the target from now
+// on. It returns how many operations were moved.
+func (s *Storage) MergePayees(userID, id, targetID uuid.UUID) (int, error) {
+	const fn = "storage.postgresql.MergePayees"
+
+	var moved int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source, target domain.Payee
+		if err := ownedPayee(tx, userID, id, &source); err != nil {
+			return err
+		}
+		if err := ownedPayee(tx, userID, targetID, &target); err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Model(&domain.Operation{}).Where("payee_id = ?", id).Update("payee_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = int(result.RowsAffected)
+
+		if err := tx.Model(&domain.PayeeAlias{}).Where("payee_id = ?", id).Update("payee_id", targetID).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&source).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return moved, nil
+}
+
+// DetectPayees links the user's operations that have no payee yet, such as
+// those stored before payees existed, creating payees as needed. It returns
+// how many operations were linked.
+func (s *Storage) DetectPayees(userID uuid.UUID) (int, error) {
+	const fn = "storage.postgresql.DetectPayees"
+
+	var linked int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var operations []domain.Operation
+		err := tx.Unscoped().Select("id", "user_id", "name").
+			Where("user_id = ? AND payee_id IS NULL", userID).
+			Find(&operations).Error
+		if err != nil {
+			return err
+		}
+
+		ops := make([]*domain.Operation, 0, len(operations))
+		for i := range operations {
+			ops = append(ops, &operations[i])
+		}
+		if err := assignPayees(tx, ops); err != nil {
+			return err
+		}
+
+		byPayee := make(map[uuid.UUID][]uuid.UUID)
+		for _, op := range operations {
+			if op.PayeeID != nil {
+				byPayee[*op.PayeeID] = append(byPayee[*op.PayeeID], op.ID)
+			}
+		}
+		for payeeID, ids := range byPayee {
+			result := tx.Unscoped().Model(&domain.Operation{}).Where("id IN ?", ids).Update("payee_id", payeeID)
+			if result.Error != nil {
+				return result.Error
+			}
+			linked += int(result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return linked, nil
+}
+
+// assignPayees links each operation without a payee to the payee its name
+// leads to, creating payees for merchants not seen before. A payee that
+// does not belong to the operation's user is dropped and found by name
+// instead. Operations whose name has no words stay without a payee.
+func assignPayees(tx *gorm.DB, operations []*domain.Operation) error {
+	byUser := make(map[uuid.UUID][]*domain.Operation)
+	for _, op := range operations {
+		byUser[op.UserID] = append(byUser[op.UserID], op)
+	}
+
+	for userID, ops := range byUser {
+		if err := dropForeignPayees(tx, userID, ops); err != nil {
+			return err
+		}
+
+		keys := make(map[*domain.Operation]string, len(ops))
+		var candidates []string
+		for _, op := range ops {
+			if op.PayeeID != nil {
+				continue
+			}
+			keys[op] = payees.Key(op.Name)
+			candidates = append(candidates, payees.Candidates(keys[op])...)
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		var aliases []domain.PayeeAlias
+		if err := tx.Where("user_id = ? AND key IN ?", userID, candidates).Find(&aliases).Error; err != nil {
+			return err
+		}
+		known := make(map[string]uuid.UUID, len(aliases))
+		for _, alias := range aliases {
+			known[alias.Key] = alias.PayeeID
+		}
+
+		var created []domain.Payee
+		for _, op := range ops {
+			key, ok := keys[op]
+			if !ok || key == "" {
+				continue
+			}
+			id, ok := payees.Match(key, known)
+			if !ok {
+				id = uuid.New()
+				created = append(created, domain.Payee{
+					BaseEntity: domain.BaseEntity{ID: id},
+					UserID:     userID,
+					Name:       payees.Name(key),
+					Aliases:    newPayeeAliases(userID, []string{key}),
+				})
+				known[key] = id
+			}
+			op.PayeeID = &id
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, 200).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// dropForeignPayees clears payees the user does not own from ops.
+func dropForeignPayees(tx *gorm.DB, userID uuid.UUID, ops []*domain.Operation) error {
+	var ids []uuid.UUID
+	for _, op := range ops {
+		if op.PayeeID != nil {
+			ids = append(ids, *op.PayeeID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var owned []uuid.UUID
+	if err := tx.Model(&domain.Payee{}).Where("user_id = ? AND id IN ?", userID, ids).Pluck("id", &owned).Error; err != nil {
+		return err
+	}
+	found := uniqueIDs(owned)
+	for _, op := range ops {
+		if op.PayeeID != nil && !found[*op.PayeeID] {
+			op.PayeeID = nil
+		}
+	}
+	return nil
+}
+
+func ownedPayee(tx *gorm.DB, userID, id uuid.UUID, payee *domain.Payee) error {
+	result := tx.Where("id = ? AND user_id = ?", id, userID).First(payee)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return storage.ErrItemNotFound
+	}
+	return result.Error
+}
+
+// checkPayeeAliases fails with ErrPayeeAliasTaken when a payee other than id
+// already has one of the keys.
+func checkPayeeAliases(tx *gorm.DB, userID, id uuid.UUID, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var taken int64
+	err := tx.Model(&domain.PayeeAlias{}).
+		Where("user_id = ? AND key IN ? AND payee_id <> ?", userID, keys, id).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return storage.ErrPayeeAliasTaken
+	}
+	return nil
+}
+
+// payeeKeys normalizes aliases as given by a user, who may paste them
+// straight from a statement, dropping blanks and repeats.
+func payeeKeys(aliases []string) []string {
+	seen := make(map[string]bool, len(aliases))
+	keys := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		key := payees.Key(alias)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func newPayeeAliases(userID uuid.UUID, keys []string) []domain.PayeeAlias {
+	aliases := make([]domain.PayeeAlias, 0, len(keys))
+	for _, key := range keys {
+		aliases = append(aliases, domain.PayeeAlias{UserID: userID, Key: key})
+	}
+	return aliases
+}
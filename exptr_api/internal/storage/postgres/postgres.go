@@ -45,6 +45,18 @@ func NewStorageWithAutoMigrate() (*Storage, error) {
 		&domain.UserSession{},
 		&domain.Category{},
 		&domain.Operation{},
+		&domain.ExchangeRate{},
+		&domain.ImportProfile{},
+		&domain.DuplicateDismissal{},
+		&domain.AccountTombstone{},
+		&domain.Tag{},
+		&domain.OperationTag{},
+		&domain.OperationSplit{},
+		&domain.OperationItem{},
+		&domain.Rule{},
+		&domain.Payee{},
+		&domain.PayeeAlias{},
+		&domain.Attachment{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("%s: failed to auto migrate: %w", fn, err)
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+)
+
+const defaultPurgeBatchSize = 1000
+
+// PurgedRows counts rows one table lost to a retention run.
+type PurgedRows struct {
+	Table string
+	Rows  int64
+}
+
+// retentionTargets lists the soft-deleted tables in purge order. Rows that
+// reference each other go first so their parents are free to go afterwards.
+// A category is kept while any operation or split line still points at it:
+// the foreign key cascades and would otherwise take live history with it.
+var retentionTargets = []struct {
+	table     string
+	condition string
+}{
+	{table: "duplicate_dismissals"},
+	{table: "operations"},
+	{table: "categories", condition: unusedCategory},
+	{table: "import_profiles"},
+	{table: "rules"},
+	{table: "users_sessions"},
+}
+
+// PurgeSoftDeleted hard-deletes rows soft-deleted before the cutoff. Each
+// table is purged in batches of batchSize so a large backlog never holds
+// long locks.
+func (s *Storage) PurgeSoftDeleted(before time.Time, batchSize int) ([]PurgedRows, error) {
+	const fn = "storage.postgresql.PurgeSoftDeleted"
+
+	if batchSize <= 0 {
+		batchSize = defaultPurgeBatchSize
+	}
+
+	var purged []PurgedRows
+	for _, target := range retentionTargets {
+		where := "deleted_at IS NOT NULL AND deleted_at < ?"
+		if target.condition != "" {
+			where += " AND " + target.condition
+		}
+		query := fmt.Sprintf("DELETE FROM %s WHERE id IN (SELECT id FROM %s WHERE %s LIMIT ?)",
+			target.table, target.table, where)
+
+		var total int64
+		for {
+			result := s.db.Exec(query, before, batchSize)
+			if result.Error != nil {
+				return purged, fmt.Errorf("%s: %s: %w", fn, target.table, result.Error)
+			}
+			total += result.RowsAffected
+			if result.RowsAffected < int64(batchSize) {
+				break
+			}
+		}
+
+		purged = append(purged, PurgedRows{Table: target.table, Rows: total})
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GetRules returns the user's rules in the order they run.
+func (s *Storage) GetRules(userID uuid.UUID) ([]domain.Rule, error) {
+	const fn = "storage.postgresql.GetRules"
+
+	var rules []domain.Rule
+	result := s.db.Where("user_id = ?", userID).Order("priority, created_at").Find(&rules)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return rules, nil
+}
+
+func (s *Storage) GetRule(userID, id uuid.UUID) (*domain.Rule, error) {
+	const fn = "storage.postgresql.GetRule"
+
+	var rule domain.Rule
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).First(&rule)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return &rule, nil
+}
+
+// CreateRule stores a rule. The category it sets must belong to the user,
+// otherwise ErrItemNotFound is returned.
+func (s *Storage) CreateRule(rule *domain.Rule) error {
+	const fn = "storage.postgresql.CreateRule"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkRuleCategory(tx, rule); err != nil {
+			return err
+		}
+		return tx.Create(rule).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// UpdateRule replaces the rule's settings.
+func (s *Storage) UpdateRule(rule *domain.Rule) error {
+	const fn = "storage.postgresql.UpdateRule"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkRuleCategory(tx, rule); err != nil {
+			return err
+		}
+		result := tx.Model(&domain.Rule{}).
+			Where("id = ? AND user_id = ?", rule.ID, rule.UserID).
+			Updates(map[string]interface{}{
+				"name":       rule.Name,
+				"priority":   rule.Priority,
+				"enabled":    rule.Enabled,
+				"stop":       rule.Stop,
+				"conditions": rule.Conditions,
+				"actions":    rule.Actions,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return storage.ErrItemNotFound
+		}
+		return tx.Where("id = ?", rule.ID).First(rule).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (s *Storage) DeleteRule(userID, id uuid.UUID) error {
+	const fn = "storage.postgresql.DeleteRule"
+
+	result := s.db.Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Rule{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// ApplyRuleActions applies rule actions to the given operations of the user
+// and returns how many were changed. Split operations keep their category,
+// which their splits decide.
+func (s *Storage) ApplyRuleActions(userID uuid.UUID, actions domain.RuleActions, operationIDs []uuid.UUID) (int, error) {
+	const fn = "storage.postgresql.ApplyRuleActions"
+
+	if len(operationIDs) == 0 {
+		return 0, nil
+	}
+
+	var operations []domain.Operation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "user_id").Where("user_id = ? AND id IN ?", userID, operationIDs).Find(&operations).Error; err != nil {
+			return err
+		}
+		if len(operations) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, 0, len(operations))
+		for _, op := range operations {
+			ids = append(ids, op.ID)
+		}
+
+		if actions.CategoryID != nil {
+			var category domain.Category
+			if err := ownedCategory(tx, userID, *actions.CategoryID, &category); err != nil {
+				return err
+			}
+			err := tx.Model(&domain.Operation{}).
+				Where("id IN ? AND NOT EXISTS (SELECT 1 FROM operation_splits WHERE operation_splits.operation_id = operations.id)", ids).
+				Update("category_id", category.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+		if actions.Name != "" {
+			if err := tx.Model(&domain.Operation{}).Where("id IN ?", ids).Update("name", actions.Name).Error; err != nil {
+				return err
+			}
+		}
+		if len(actions.Tags) > 0 {
+			names := make(map[uuid.UUID][]string, len(operations))
+			for _, op := range operations {
+				names[op.ID] = actions.Tags
+			}
+			return linkTags(tx, operations, names)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return len(operations), nil
+}
+
+func checkRuleCategory(tx *gorm.DB, rule *domain.Rule) error {
+	if rule.Actions.CategoryID == nil {
+		return nil
+	}
+	var category domain.Category
+	return ownedCategory(tx, rule.UserID, *rule.Actions.CategoryID, &category)
+}
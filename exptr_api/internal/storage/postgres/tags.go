@@ -0,0 +1,246 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/models"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetTags returns the user's tags sorted by name with their usage counts.
+func (s *Storage) GetTags(userID uuid.UUID) ([]models.TagUsage, error) {
+	const fn = "storage.postgresql.GetTags"
+
+	var tags []models.TagUsage
+	result := s.db.Model(&domain.Tag{}).
+		Select("tags.*, COUNT(operations.id) AS operations").
+		Joins("LEFT JOIN operation_tags ON operation_tags.tag_id = tags.id").
+		Joins("LEFT JOIN operations ON operations.id = operation_tags.operation_id AND operations.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID).
+		Group("tags.id").
+		Order("LOWER(tags.name)").
+		Scan(&tags)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return tags, nil
+}
+
+// CreateTag adds a tag, failing with ErrTagExists when the user already has
+// one of that name.
+func (s *Storage) CreateTag(tag *domain.Tag) error {
+	const fn = "storage.postgresql.CreateTag"
+
+	tag.Name = normalizeTagName(tag.Name)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkTagName(tx, tag.UserID, uuid.Nil, tag.Name); err != nil {
+			return err
+		}
+		return tx.Create(tag).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// UpdateTag renames or recolors a tag. Renaming it to the name of another
+// tag fails with ErrTagExists; use MergeTags to combine them.
+func (s *Storage) UpdateTag(userID, id uuid.UUID, name, color string) (*domain.Tag, error) {
+	const fn = "storage.postgresql.UpdateTag"
+
+	var tag domain.Tag
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := ownedTag(tx, userID, id, &tag); err != nil {
+			return err
+		}
+
+		name = normalizeTagName(name)
+		if err := checkTagName(tx, userID, id, name); err != nil {
+			return err
+		}
+
+		tag.Name = name
+		tag.Color = color
+		return tx.Model(&tag).Updates(map[string]interface{}{"name": name, "color": color}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tag, nil
+}
+
+// DeleteTag removes a tag and detaches it from all operations. Tags carry no
+// history of their own, so they are deleted outright rather than trashed.
+func (s *Storage) DeleteTag(userID, id uuid.UUID) error {
+	const fn = "storage.postgresql.DeleteTag"
+
+	result := s.db.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&domain.Tag{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// MergeTags moves every operation tagged with id over to targetID and
+// deletes id. It returns how many operations were tagged with id.
+func (s *Storage) MergeTags(userID, id, targetID uuid.UUID) (int, error) {
+	const fn = "storage.postgresql.MergeTags"
+
+	var moved int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var source, target domain.Tag
+		if err := ownedTag(tx, userID, id, &source); err != nil {
+			return err
+		}
+		if err := ownedTag(tx, userID, targetID, &target); err != nil {
+			return err
+		}
+
+		result := tx.Exec(`INSERT INTO operation_tags (operation_id, tag_id)
+			SELECT operation_id, ? FROM operation_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, targetID, id)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		result = tx.Where("tag_id = ?", id).Delete(&domain.OperationTag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = int(result.RowsAffected)
+
+		return tx.Unscoped().Delete(&source).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return moved, nil
+}
+
+func ownedTag(tx *gorm.DB, userID, id uuid.UUID, tag *domain.Tag) error {
+	result := tx.Where("id = ? AND user_id = ?", id, userID).First(tag)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return storage.ErrItemNotFound
+	}
+	return result.Error
+}
+
+// checkTagName fails with ErrTagExists when a tag other than id already has
+// the name.
+func checkTagName(tx *gorm.DB, userID, id uuid.UUID, name string) error {
+	var taken int64
+	err := tx.Model(&domain.Tag{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, id).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return storage.ErrTagExists
+	}
+	return nil
+}
+
+// normalizeTagName trims a tag name and collapses inner runs of whitespace.
+func normalizeTagName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// resolveTags returns the user's tags with the given names, creating the
+// missing ones. Names are matched ignoring case; blanks and repeats are
+// dropped.
+func resolveTags(tx *gorm.DB, userID uuid.UUID, names []string) ([]domain.Tag, error) {
+	seen := make(map[string]bool, len(names))
+	var wanted, lower []string
+	for _, name := range names {
+		name = normalizeTagName(name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		wanted = append(wanted, name)
+		lower = append(lower, key)
+	}
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+
+	var tags []domain.Tag
+	if err := tx.Where("user_id = ? AND LOWER(name) IN ?", userID, lower).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		found[strings.ToLower(tag.Name)] = true
+	}
+
+	var created []domain.Tag
+	for _, name := range wanted {
+		if !found[strings.ToLower(name)] {
+			created = append(created, domain.Tag{UserID: userID, Name: name})
+		}
+	}
+	if len(created) > 0 {
+		if err := tx.Create(&created).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return append(tags, created...), nil
+}
+
+// linkTags tags each operation with the named tags of its user, creating
+// missing tags. Links that already exist are kept.
+func linkTags(tx *gorm.DB, operations []domain.Operation, names map[uuid.UUID][]string) error {
+	byUser := make(map[uuid.UUID][]string)
+	for _, operation := range operations {
+		byUser[operation.UserID] = append(byUser[operation.UserID], names[operation.ID]...)
+	}
+
+	var links []domain.OperationTag
+	for userID, userNames := range byUser {
+		tags, err := resolveTags(tx, userID, userNames)
+		if err != nil {
+			return err
+		}
+		ids := make(map[string]uuid.UUID, len(tags))
+		for _, tag := range tags {
+			ids[strings.ToLower(tag.Name)] = tag.ID
+		}
+
+		for _, operation := range operations {
+			if operation.UserID != userID {
+				continue
+			}
+			for _, name := range names[operation.ID] {
+				if id, ok := ids[strings.ToLower(normalizeTagName(name))]; ok {
+					links = append(links, domain.OperationTag{OperationID: operation.ID, TagID: id})
+				}
+			}
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(&links, 500).Error
+}
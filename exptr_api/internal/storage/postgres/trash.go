@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+
+	"alex_gorbunov_exptr_api/internal/domain"
+	"alex_gorbunov_exptr_api/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// unusedCategory matches categories that no operation or split line points
+// at, live or deleted.
+const unusedCategory = "NOT EXISTS (SELECT 1 FROM operations WHERE operations.category_id = categories.id)" +
+	" AND NOT EXISTS (SELECT 1 FROM operation_splits WHERE operation_splits.category_id = categories.id)"
+
+// GetDeletedOperations returns the user's soft-deleted operations, most
+// recently deleted first.
+func (s *Storage) GetDeletedOperations(userID uuid.UUID) ([]domain.Operation, error) {
+	const fn = "storage.postgresql.GetDeletedOperations"
+
+	var operations []domain.Operation
+	result := s.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&operations)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return operations, nil
+}
+
+// GetDeletedCategories returns the user's soft-deleted categories, most
+// recently deleted first.
+func (s *Storage) GetDeletedCategories(userID uuid.UUID) ([]domain.Category, error) {
+	const fn = "storage.postgresql.GetDeletedCategories"
+
+	var categories []domain.Category
+	result := s.db.Unscoped().
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Order("deleted_at DESC").
+		Find(&categories)
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return categories, nil
+}
+
+// RestoreOperation brings a deleted operation back. Its category and the
+// categories of its splits are restored as well if they sit in the trash, so
+// the operation never points at a deleted category.
+func (s *Storage) RestoreOperation(userID, id uuid.UUID) (*domain.Operation, error) {
+	const fn = "storage.postgresql.RestoreOperation"
+
+	var operation domain.Operation
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&operation)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return storage.ErrItemNotFound
+			}
+			return result.Error
+		}
+
+		err := tx.Unscoped().Model(&domain.Category{}).
+			Where("deleted_at IS NOT NULL").
+			Where("id = ? OR id IN (SELECT category_id FROM operation_splits WHERE operation_id = ?)", operation.CategoryID, id).
+			Update("deleted_at", nil).Error
+		if err != nil {
+			return err
+		}
+
+		operation.DeletedAt = gorm.DeletedAt{}
+		return tx.Unscoped().Model(&operation).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &operation, nil
+}
+
+// RestoreCategory brings a deleted category back. With withOperations it
+// also restores the operations deleted together with it, recognised by the
+// shared deleted_at, and returns how many there were.
+func (s *Storage) RestoreCategory(userID, id uuid.UUID, withOperations bool) (*domain.Category, int, error) {
+	const fn = "storage.postgresql.RestoreCategory"
+
+	var category domain.Category
+	var restored int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&category)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return storage.ErrItemNotFound
+			}
+			return result.Error
+		}
+
+		if withOperations {
+			result = tx.Unscoped().Model(&domain.Operation{}).
+				Where("category_id = ? AND deleted_at = ?", id, category.DeletedAt.Time).
+				Update("deleted_at", nil)
+			if result.Error != nil {
+				return result.Error
+			}
+			restored = int(result.RowsAffected)
+		}
+
+		category.DeletedAt = gorm.DeletedAt{}
+		return tx.Unscoped().Model(&category).Update("deleted_at", nil).Error
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &category, restored, nil
+}
+
+// PurgeOperation permanently deletes an operation from the trash.
+func (s *Storage) PurgeOperation(userID, id uuid.UUID) error {
+	const fn = "storage.postgresql.PurgeOperation"
+
+	result := s.db.Unscoped().
+		Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).
+		Delete(&domain.Operation{})
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrItemNotFound)
+	}
+
+	return nil
+}
+
+// PurgeCategory permanently deletes a category from the trash along with its
+// deleted operations. The foreign key cascades, so a category that live
+// operations still point at is refused with ErrCategoryInUse, as is one that
+// split lines of other operations use.
+func (s *Storage) PurgeCategory(userID, id uuid.UUID) (int, error) {
+	const fn = "storage.postgresql.PurgeCategory"
+
+	var purged int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var category domain.Category
+		result := tx.Unscoped().Where("id = ? AND user_id = ? AND deleted_at IS NOT NULL", id, userID).First(&category)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return storage.ErrItemNotFound
+			}
+			return result.Error
+		}
+
+		var live int64
+		if err := tx.Model(&domain.Operation{}).Where("category_id = ?", id).Count(&live).Error; err != nil {
+			return err
+		}
+		if live > 0 {
+			return storage.ErrCategoryInUse
+		}
+
+		result = tx.Unscoped().Where("category_id = ?", id).Delete(&domain.Operation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = int(result.RowsAffected)
+
+		var split int64
+		if err := tx.Model(&domain.OperationSplit{}).Where("category_id = ?", id).Count(&split).Error; err != nil {
+			return err
+		}
+		if split > 0 {
+			return storage.ErrCategoryInUse
+		}
+
+		return tx.Unscoped().Delete(&category).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return purged, nil
+}
+
+// EmptyTrash permanently deletes all of the user's deleted operations and the
+// deleted categories no live operation points at.
+func (s *Storage) EmptyTrash(userID uuid.UUID) (operations, categories int, err error) {
+	const fn = "storage.postgresql.EmptyTrash"
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+			Delete(&domain.Operation{})
+		if result.Error != nil {
+			return result.Error
+		}
+		operations = int(result.RowsAffected)
+
+		result = tx.Unscoped().
+			Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+			Where(unusedCategory).
+			Delete(&domain.Category{})
+		if result.Error != nil {
+			return result.Error
+		}
+		categories = int(result.RowsAffected)
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return operations, categories, nil
+}
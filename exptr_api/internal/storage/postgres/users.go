@@ -22,6 +22,27 @@ func (s *Storage) CreateUser(user *domain.User) error {
 	return nil
 }
 
+// CreateUserWithCategories creates the user and their starting categories in
+// one transaction. Categories must be ordered parents first.
+func (s *Storage) CreateUserWithCategories(user *domain.User, categories []domain.Category) error {
+	const fn = "storage.postgresql.CreateUserWithCategories"
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		if len(categories) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(&categories, 200).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
 func (s *Storage) GetUserByEmail(email string) (*domain.User, error) {
 	const fn = "storage.postgresql.GetUserByEmail"
 
@@ -37,6 +58,36 @@ func (s *Storage) GetUserByEmail(email string) (*domain.User, error) {
 	return &user, nil
 }
 
+func (s *Storage) GetUserByID(id uuid.UUID) (*domain.User, error) {
+	const fn = "storage.postgresql.GetUserByID"
+
+	var user domain.User
+	result := s.db.Where("id = ?", id).First(&user)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: user not found", fn)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	return &user, nil
+}
+
+func (s *Storage) UpdateUserBaseCurrency(id uuid.UUID, currency string) error {
+	const fn = "storage.postgresql.UpdateUserBaseCurrency"
+
+	result := s.db.Model(&domain.User{}).Where("id = ?", id).Update("base_currency", currency)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", fn, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: user not found", fn)
+	}
+
+	return nil
+}
+
 func (s *Storage) SetUserSession(userID uuid.UUID, token string) error {
 	const fn = "storage.postgresql.SetUserSession"
 
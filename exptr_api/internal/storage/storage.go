@@ -3,5 +3,13 @@ package storage
 import "errors"
 
 var (
-	ErrItemNotFound = errors.New("item not found")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrAccountNotEmpty = errors.New("account is not empty")
+	ErrCategoryInUse   = errors.New("category is in use")
+	ErrCategoryCycle   = errors.New("category cannot be nested under itself")
+	ErrTagExists       = errors.New("tag already exists")
+	ErrSplitMismatch   = errors.New("splits do not add up to the amount")
+	ErrSplitCategory   = errors.New("split category not found")
+	ErrDefaultCategory = errors.New("default category not found")
+	ErrPayeeAliasTaken = errors.New("alias belongs to another payee")
 )